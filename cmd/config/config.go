@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func Command(root *cobra.Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage configuration files.",
+		// The config file is validated rather than applied.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+	cmd.AddCommand(validateCommand(root))
+	return cmd
+}
+
+func validateCommand(root *cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Validate a configuration file.",
+		Long: `Validates a configuration file given as an argument or with --config, checking every flag
+exists on the commands it applies to and has a valid value, and that per-table settings are valid.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := cmd.Flags().GetString("config")
+			if err != nil {
+				return err
+			}
+			if len(args) > 0 {
+				path = args[0]
+			}
+			if path == "" {
+				return errors.Newf("a config file must be given as an argument or with --config")
+			}
+			cfg, err := cmdutil.ReadConfigFile(path, root)
+			if err != nil {
+				return err
+			}
+			if err := cfg.Validate(root); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+			_, err = fmt.Fprintf(
+				cmd.OutOrStdout(),
+				"%s is valid: %d flags, %d command sections, %d tables\n",
+				path,
+				len(cfg.Flags),
+				len(cfg.Commands),
+				len(cfg.Tables),
+			)
+			return err
+		},
+	}
+}
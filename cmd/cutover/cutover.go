@@ -0,0 +1,141 @@
+package cutover
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/cockroachdb/molt/cutover"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	cfg := cutover.Config{
+		MaxLagBytes:    1 << 20,
+		HeartbeatTable: cutover.DefaultHeartbeatTable,
+		MaxLag:         5 * time.Second,
+		LagTimeout:     30 * time.Minute,
+		DrainTimeout:   10 * time.Minute,
+		PollInterval:   5 * time.Second,
+		StatePath:      cutover.DefaultStatePath,
+	}
+	cmd := &cobra.Command{
+		Use: "cutover",
+		Long: `Coordinates cutting over from the original database (--source) to the database replicated to (--target).
+Waits for replication lag to drop below a threshold, optionally makes the source read-only, waits for the
+replicator to drain, verifies row counts and checksums, then prints a go/no-go report.
+Steps which succeeded are recorded in --state-path and skipped when cutover is rerun with --resume.`,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			logger, err := cmdutil.Logger()
+			if err != nil {
+				return err
+			}
+			cmdutil.RunMetricsServer(logger)
+
+			conns, err := cmdutil.LoadDBConns(ctx)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				for _, conn := range conns {
+					_ = conn.Close(context.Background())
+				}
+			}()
+			report, err := cutover.Cutover(ctx, cfg, logger, conns, cmdutil.TableFilter())
+			if err != nil {
+				return err
+			}
+			if err := report.Write(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+			if !report.Go {
+				cmd.SilenceUsage = true
+				return &cmdutil.ExitError{
+					Code: 1,
+					Err:  errors.Newf("cutover is not safe to proceed; rerun cutover with --resume to resume from the failed step"),
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().BoolVar(
+		&cfg.ReadOnly,
+		"read-only",
+		false,
+		"make the source read-only (default_transaction_read_only on PostgreSQL, terminating sessions which started before, and super_read_only on MySQL) before waiting for the replicator to drain",
+	)
+	cmd.PersistentFlags().StringVar(
+		&cfg.PGReplicationSlot,
+		"pg-logical-replication-slot-name",
+		"",
+		"replication slot created by fetch; if set, lag is measured by the slot instead of a heartbeat table",
+	)
+	cmd.PersistentFlags().Int64Var(
+		&cfg.MaxLagBytes,
+		"max-lag-bytes",
+		cfg.MaxLagBytes,
+		"maximum lag of the replication slot in bytes",
+	)
+	cmd.PersistentFlags().StringVar(
+		&cfg.HeartbeatTable,
+		"heartbeat-table",
+		cfg.HeartbeatTable,
+		"table created on both databases to measure lag by, which must be replicated",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&cfg.MaxLag,
+		"max-lag",
+		cfg.MaxLag,
+		"maximum lag of the heartbeat table",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&cfg.LagTimeout,
+		"lag-timeout",
+		cfg.LagTimeout,
+		"how long to wait for lag to drop below the maximum",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&cfg.DrainTimeout,
+		"drain-timeout",
+		cfg.DrainTimeout,
+		"how long to wait for the replicator to drain",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&cfg.PollInterval,
+		"poll-interval",
+		cfg.PollInterval,
+		"how often to measure lag",
+	)
+	cmd.PersistentFlags().StringVar(
+		&cfg.StatePath,
+		"state-path",
+		cfg.StatePath,
+		"file to record the progress of each step in; cutover refuses to start if it exists unless --resume is set",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&cfg.Resume,
+		"resume",
+		false,
+		"resume from the progress recorded in --state-path, skipping the steps which succeeded",
+	)
+	cmd.PersistentFlags().IntVar(
+		&cfg.VerifyConcurrency,
+		"concurrency",
+		0,
+		"number of tables to verify at a time (defaults to number of CPUs)",
+	)
+	cmdutil.RegisterDBConnFlags(cmd)
+	cmdutil.RegisterLoggerFlags(cmd)
+	cmdutil.RegisterNameFilterFlags(cmd)
+	cmdutil.RegisterMetricsFlags(cmd)
+	return cmd
+}
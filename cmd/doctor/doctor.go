@@ -0,0 +1,83 @@
+package doctor
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/cockroachdb/molt/doctor"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	var cfg doctor.Config
+	cmd := &cobra.Command{
+		Use: "doctor",
+		Long: `Checks the source, target and store are set up for fetch and verify, using the same flags.
+Checks privileges, source replication settings, that the store can be written to, read from and deleted from,
+that the target can reach the store, and that tables are compatible, then prints a pass/fail report.`,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			logger, err := cmdutil.Logger()
+			if err != nil {
+				return err
+			}
+
+			conns, err := cmdutil.LoadDBConns(ctx)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				for _, conn := range conns {
+					_ = conn.Close(context.Background())
+				}
+			}()
+			if cmdutil.StoreConfigured() {
+				if cfg.Store, err = cmdutil.LoadStore(ctx, logger, conns); err != nil {
+					return err
+				}
+				defer func() {
+					if err := cfg.Store.Cleanup(context.Background()); err != nil {
+						logger.Err(err).Msgf("error cleaning up store")
+					}
+				}()
+			}
+
+			report, err := doctor.Doctor(ctx, cfg, logger, conns, cmdutil.TableFilter())
+			if err != nil {
+				return err
+			}
+			if err := report.Write(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+			if !report.Passed() {
+				cmd.SilenceUsage = true
+				return &cmdutil.ExitError{
+					Code: 1,
+					Err:  errors.Newf("doctor found failing checks"),
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&cfg.PGReplicationSlot,
+		"pg-logical-replication-slot-name",
+		"",
+		"if set, the name of the replication slot fetch will create, which requires logical replication",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&cfg.Live,
+		"live",
+		false,
+		"whether fetch will run in live mode, in which the target does not read from the store",
+	)
+	cmdutil.RegisterDBConnFlags(cmd)
+	cmdutil.RegisterStoreFlags(cmd)
+	cmdutil.RegisterLoggerFlags(cmd)
+	cmdutil.RegisterNameFilterFlags(cmd)
+	return cmd
+}
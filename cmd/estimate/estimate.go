@@ -0,0 +1,60 @@
+package estimate
+
+import (
+	"context"
+
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/cockroachdb/molt/estimate"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	var cfg estimate.Config
+	cmd := &cobra.Command{
+		Use: "estimate",
+		Long: `Estimates the size and duration of fetching the tables found on both the source and target.
+Row counts and sizes are taken from the catalog statistics of the source. With --sample-rows, a sample
+of each table is exported to estimate the export size, compressed size and fetch duration.`,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			logger, err := cmdutil.Logger()
+			if err != nil {
+				return err
+			}
+
+			conns, err := cmdutil.LoadDBConns(ctx)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				for _, conn := range conns {
+					_ = conn.Close(context.Background())
+				}
+			}()
+			res, err := estimate.Estimate(ctx, cfg, logger, conns, cmdutil.TableFilter())
+			if err != nil {
+				return err
+			}
+			return res.Write(cmd.OutOrStdout())
+		},
+	}
+
+	cmd.PersistentFlags().IntVar(
+		&cfg.Concurrency,
+		"concurrency",
+		4,
+		"number of tables fetch will move data with at a time",
+	)
+	cmd.PersistentFlags().IntVar(
+		&cfg.SampleRows,
+		"sample-rows",
+		10_000,
+		"number of rows of each table to export to benchmark the export; 0 skips the benchmark",
+	)
+	cmdutil.RegisterDBConnFlags(cmd)
+	cmdutil.RegisterLoggerFlags(cmd)
+	cmdutil.RegisterNameFilterFlags(cmd)
+	return cmd
+}
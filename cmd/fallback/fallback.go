@@ -0,0 +1,84 @@
+package fallback
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/cockroachdb/molt/fallback"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	cfg := fallback.Config{
+		ListenAddr:       "0.0.0.0:30004",
+		CheckpointPath:   fallback.DefaultCheckpointPath,
+		ResolvedInterval: 10 * time.Second,
+	}
+	cmd := &cobra.Command{
+		Use: "fallback",
+		Long: `Replicates changes made on CockroachDB (--target) since the cutover back to the original database (--source).
+A changefeed is created on the migrated tables, which posts changes to a webhook sink served by molt.`,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			logger, err := cmdutil.Logger()
+			if err != nil {
+				return err
+			}
+			cmdutil.RunMetricsServer(logger)
+
+			conns, err := cmdutil.LoadDBConns(ctx)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				for _, conn := range conns {
+					_ = conn.Close(context.Background())
+				}
+			}()
+			return fallback.Fallback(ctx, cfg, logger, conns, cmdutil.TableFilter())
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&cfg.CutoverTimestamp,
+		"cutover-timestamp",
+		"",
+		"CockroachDB timestamp of the cutover (from SELECT cluster_logical_timestamp()), from which changes are replicated; ignored when resuming from a checkpoint",
+	)
+	cmd.PersistentFlags().StringVar(
+		&cfg.ListenAddr,
+		"listen-addr",
+		cfg.ListenAddr,
+		"address to serve the changefeed webhook sink on",
+	)
+	cmd.PersistentFlags().StringVar(
+		&cfg.SinkAddr,
+		"sink-addr",
+		"",
+		"address CockroachDB can access the webhook sink on; defaults to --listen-addr",
+	)
+	cmd.PersistentFlags().StringVar(
+		&cfg.CheckpointPath,
+		"checkpoint-path",
+		cfg.CheckpointPath,
+		"file to keep the latest resolved timestamp in, which fallback resumes from when restarted",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&cfg.ResolvedInterval,
+		"resolved-interval",
+		cfg.ResolvedInterval,
+		"how often the changefeed emits resolved timestamps, which are checkpointed",
+	)
+	cmdutil.RegisterDBConnFlags(cmd)
+	cmdutil.RegisterLoggerFlags(cmd)
+	cmdutil.RegisterNameFilterFlags(cmd)
+	cmdutil.RegisterMetricsFlags(cmd)
+	return cmd
+}
@@ -3,29 +3,17 @@ package fetch
 import (
 	"context"
 
-	"cloud.google.com/go/storage"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
 	"github.com/cockroachdb/molt/compression"
-	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/fetch"
-	"github.com/cockroachdb/molt/fetch/datablobstorage"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag/v2"
-	"golang.org/x/oauth2/google"
 )
 
 func Command() *cobra.Command {
-	var (
-		s3Bucket                string
-		gcpBucket               string
-		localPath               string
-		localPathListenAddr     string
-		localPathCRDBAccessAddr string
-		directCRDBCopy          bool
-		cfg                     fetch.Config
-	)
+	var cfg fetch.Config
 	cmd := &cobra.Command{
 		Use:  "fetch",
 		Long: `Fetches data from source to directly import into target.`,
@@ -55,41 +43,15 @@ func Command() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if !conns[1].IsCockroach() {
-				return errors.AssertionFailedf("target must be cockroach")
+
+			cfg.ExportSettings.TableRowBatchSizes = map[dbtable.Name]int{}
+			for name, tc := range cmdutil.ConfigTables() {
+				cfg.ExportSettings.TableRowBatchSizes[name] = tc.RowBatchSize
 			}
 
-			var src datablobstorage.Store
-			switch {
-			case directCRDBCopy:
-				src = datablobstorage.NewCopyCRDBDirect(logger, conns[1].(*dbconn.PGConn).Conn)
-			case gcpBucket != "":
-				creds, err := google.FindDefaultCredentials(ctx)
-				if err != nil {
-					return err
-				}
-				gcpClient, err := storage.NewClient(context.Background())
-				if err != nil {
-					return err
-				}
-				src = datablobstorage.NewGCPStore(logger, gcpClient, creds, gcpBucket)
-			case s3Bucket != "":
-				sess, err := session.NewSession()
-				if err != nil {
-					return err
-				}
-				creds, err := sess.Config.Credentials.Get()
-				if err != nil {
-					return err
-				}
-				src = datablobstorage.NewS3Store(logger, sess, creds, s3Bucket)
-			case localPath != "":
-				src, err = datablobstorage.NewLocalStore(logger, localPath, localPathListenAddr, localPathCRDBAccessAddr)
-				if err != nil {
-					return err
-				}
-			default:
-				return errors.AssertionFailedf("data source must be configured (--s3-bucket, --gcp-bucket, --direct-copy)")
+			src, err := cmdutil.LoadStore(ctx, logger, conns)
+			if err != nil {
+				return err
 			}
 			return fetch.Fetch(
 				ctx,
@@ -102,12 +64,6 @@ func Command() *cobra.Command {
 		},
 	}
 
-	cmd.PersistentFlags().BoolVar(
-		&directCRDBCopy,
-		"direct-copy",
-		false,
-		"whether to use direct copy mode",
-	)
 	cmd.PersistentFlags().BoolVar(
 		&cfg.Cleanup,
 		"cleanup",
@@ -138,36 +94,6 @@ func Command() *cobra.Command {
 		4,
 		"number of tables to move data with at a time",
 	)
-	cmd.PersistentFlags().StringVar(
-		&s3Bucket,
-		"s3-bucket",
-		"",
-		"s3 bucket",
-	)
-	cmd.PersistentFlags().StringVar(
-		&gcpBucket,
-		"gcp-bucket",
-		"",
-		"gcp bucket",
-	)
-	cmd.PersistentFlags().StringVar(
-		&localPath,
-		"local-path",
-		"",
-		"path to upload files to locally",
-	)
-	cmd.PersistentFlags().StringVar(
-		&localPathListenAddr,
-		"local-path-listen-addr",
-		"",
-		"local address to listen to for traffic",
-	)
-	cmd.PersistentFlags().StringVar(
-		&localPathCRDBAccessAddr,
-		"local-path-crdb-access-addr",
-		"",
-		"address CockroachDB can access to connect to the --local-path-listen-addr",
-	)
 	cmd.PersistentFlags().BoolVar(
 		&cfg.Truncate,
 		"truncate",
@@ -209,6 +135,7 @@ func Command() *cobra.Command {
 		"compression to use (default/gzip/none)",
 	)
 	cmdutil.RegisterDBConnFlags(cmd)
+	cmdutil.RegisterStoreFlags(cmd)
 	cmdutil.RegisterLoggerFlags(cmd)
 	cmdutil.RegisterNameFilterFlags(cmd)
 	cmdutil.RegisterMetricsFlags(cmd)
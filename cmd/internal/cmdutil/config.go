@@ -0,0 +1,315 @@
+package cmdutil
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+var configPath string
+
+// configFile is the configuration file loaded from --config, if set.
+var configFile *ConfigFile
+
+// tablesKey is the key of the per-table settings in the configuration file.
+const tablesKey = "tables"
+
+// ConfigFile is a YAML or JSON file setting the flags of commands. Keys are
+// flag names: those at the top level apply to every command with the flag,
+// and those under a command name only apply to that command. Per-table
+// settings are set under "tables".
+type ConfigFile struct {
+	// Flags are the flag values applied to any command with the flag.
+	Flags map[string][]string
+	// Commands are the flag values of each command.
+	Commands map[string]map[string][]string
+	// Tables are the settings of specific tables.
+	Tables map[dbtable.Name]TableConfig
+}
+
+// TableConfig overrides settings for a single table.
+type TableConfig struct {
+	// RowBatchSize is the number of rows read from the table at a time.
+	RowBatchSize int `yaml:"row-batch-size"`
+	// TableSplits is the number of shards the table is verified in.
+	TableSplits int `yaml:"table-splits"`
+	// Exclude excludes the table from all commands.
+	Exclude bool `yaml:"exclude"`
+	// ExcludeColumns are columns of the table which are not verified.
+	ExcludeColumns []string `yaml:"exclude-columns"`
+	// IncludeColumns are the only columns of the table which are verified.
+	IncludeColumns []string `yaml:"include-columns"`
+}
+
+// RegisterConfigFlag registers --config, which applies to cmd and all its
+// subcommands.
+func RegisterConfigFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&configPath,
+		"config",
+		"",
+		"YAML or JSON file setting flags, under the top level or the command name, and per-table settings under tables; flags set on the command line take precedence",
+	)
+}
+
+// LoadConfigFile loads the file set by --config, if any, and sets the flags
+// of cmd which were not set on the command line.
+func LoadConfigFile(cmd *cobra.Command) error {
+	if configPath == "" {
+		return nil
+	}
+	cfg, err := ReadConfigFile(configPath, cmd.Root())
+	if err != nil {
+		return err
+	}
+	if err := cfg.apply(cmd); err != nil {
+		return errors.Wrapf(err, "error applying %s", configPath)
+	}
+	configFile = cfg
+	return nil
+}
+
+// ReadConfigFile reads and parses the configuration file at path, checking
+// the commands are subcommands of root.
+func ReadConfigFile(path string, root *cobra.Command) (*ConfigFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading config file")
+	}
+	cfg, err := ParseConfigFile(b, root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing %s", path)
+	}
+	return cfg, nil
+}
+
+// ParseConfigFile parses a configuration file, checking the commands are
+// subcommands of root.
+func ParseConfigFile(b []byte, root *cobra.Command) (*ConfigFile, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	cfg := &ConfigFile{
+		Flags:    map[string][]string{},
+		Commands: map[string]map[string][]string{},
+		Tables:   map[dbtable.Name]TableConfig{},
+	}
+	// An empty file has no content.
+	if len(doc.Content) == 0 {
+		return cfg, nil
+	}
+	top := doc.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return nil, errors.Newf("line %d: expected a mapping of flag names to values", top.Line)
+	}
+	for i := 0; i < len(top.Content); i += 2 {
+		key, val := top.Content[i], top.Content[i+1]
+		switch {
+		case key.Value == tablesKey:
+			if err := parseTables(val, cfg.Tables); err != nil {
+				return nil, err
+			}
+		case val.Kind == yaml.MappingNode:
+			if !isSubcommand(root, key.Value) {
+				return nil, errors.Newf("line %d: unknown command %q", key.Line, key.Value)
+			}
+			flags := map[string][]string{}
+			for j := 0; j < len(val.Content); j += 2 {
+				vals, err := flagValues(val.Content[j+1])
+				if err != nil {
+					return nil, err
+				}
+				flags[val.Content[j].Value] = vals
+			}
+			cfg.Commands[key.Value] = flags
+		default:
+			vals, err := flagValues(val)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Flags[key.Value] = vals
+		}
+	}
+	return cfg, nil
+}
+
+func isSubcommand(root *cobra.Command, name string) bool {
+	for _, c := range root.Commands() {
+		if c.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValues returns the values of a flag, which has a single value unless
+// it is a list.
+func flagValues(n *yaml.Node) ([]string, error) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return []string{n.Value}, nil
+	case yaml.SequenceNode:
+		ret := make([]string, len(n.Content))
+		for i, item := range n.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, errors.Newf("line %d: expected a list of values", item.Line)
+			}
+			ret[i] = item.Value
+		}
+		return ret, nil
+	}
+	return nil, errors.Newf("line %d: expected a value or list of values", n.Line)
+}
+
+func parseTables(n *yaml.Node, tables map[dbtable.Name]TableConfig) error {
+	if n.Kind != yaml.MappingNode {
+		return errors.Newf("line %d: expected a mapping of schema.table to settings", n.Line)
+	}
+	for i := 0; i < len(n.Content); i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		schema, table, ok := strings.Cut(key.Value, ".")
+		if !ok || schema == "" || table == "" || strings.Contains(table, ".") {
+			return errors.Newf("line %d: expected table %q to be in the form schema.table", key.Line, key.Value)
+		}
+		var tc TableConfig
+		// Decode strictly so that misspelt settings are not ignored.
+		b, err := yaml.Marshal(val)
+		if err != nil {
+			return err
+		}
+		dec := yaml.NewDecoder(strings.NewReader(string(b)))
+		dec.KnownFields(true)
+		if err := dec.Decode(&tc); err != nil {
+			return errors.Wrapf(err, "line %d: invalid settings for table %s", key.Line, key.Value)
+		}
+		if tc.RowBatchSize < 0 || tc.TableSplits < 0 {
+			return errors.Newf("line %d: row-batch-size and table-splits of table %s must not be negative", key.Line, key.Value)
+		}
+		for _, col := range append(append([]string(nil), tc.ExcludeColumns...), tc.IncludeColumns...) {
+			if col == "" || strings.Contains(col, ".") {
+				return errors.Newf("line %d: expected column %q of table %s to be a column name", key.Line, col, key.Value)
+			}
+		}
+		tables[dbtable.Name{Schema: tree.Name(schema), Table: tree.Name(table)}] = tc
+	}
+	return nil
+}
+
+// apply sets the flags of cmd which were not set on the command line. Flags
+// under the command's name take precedence over those at the top level.
+func (c *ConfigFile) apply(cmd *cobra.Command) error {
+	values := map[string][]string{}
+	for name, vals := range c.Flags {
+		if lookupFlag(cmd, name) != nil {
+			values[name] = vals
+		}
+	}
+	for name, vals := range c.Commands[cmd.Name()] {
+		if lookupFlag(cmd, name) == nil {
+			return errors.Newf("unknown flag %q for command %s", name, cmd.Name())
+		}
+		values[name] = vals
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := lookupFlag(cmd, name)
+		if f.Changed {
+			continue
+		}
+		if name == "config" {
+			return errors.Newf("config files cannot set --config")
+		}
+		for _, v := range values[name] {
+			if err := f.Value.Set(v); err != nil {
+				return errors.Wrapf(err, "invalid value %q for flag %q", v, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks every flag in the file is a flag of a subcommand of root
+// and has a valid value.
+func (c *ConfigFile) Validate(root *cobra.Command) error {
+	var errs []string
+	for _, sub := range root.Commands() {
+		if _, ok := c.Commands[sub.Name()]; !ok {
+			continue
+		}
+		if err := c.apply(sub); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	for name := range c.Flags {
+		found := false
+		for _, sub := range root.Commands() {
+			if lookupFlag(sub, name) != nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, "unknown flag "+name)
+		}
+	}
+	// Check values of top-level flags against commands without a section,
+	// as those with a section were applied above.
+	for _, sub := range root.Commands() {
+		if _, ok := c.Commands[sub.Name()]; ok {
+			continue
+		}
+		if err := c.apply(sub); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return errors.Newf("invalid config file:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// lookupFlag returns the flag of cmd with the given name, including
+// persistent flags before they are merged on execution.
+func lookupFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	if f := cmd.Flags().Lookup(name); f != nil {
+		return f
+	}
+	return cmd.PersistentFlags().Lookup(name)
+}
+
+// ConfigTables returns the per-table settings of the configuration file.
+func ConfigTables() map[dbtable.Name]TableConfig {
+	if configFile == nil {
+		return nil
+	}
+	return configFile.Tables
+}
+
+// ConfigColumns returns the excluded and included columns of each table in
+// the configuration file, in the form schema.table.column.
+func ConfigColumns() (exclude []string, include []string) {
+	for name, tc := range ConfigTables() {
+		for _, col := range tc.ExcludeColumns {
+			exclude = append(exclude, name.SafeString()+"."+col)
+		}
+		for _, col := range tc.IncludeColumns {
+			include = append(include, name.SafeString()+"."+col)
+		}
+	}
+	sort.Strings(exclude)
+	sort.Strings(include)
+	return exclude, include
+}
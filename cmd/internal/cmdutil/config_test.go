@@ -0,0 +1,158 @@
+package cmdutil
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func testRoot() *cobra.Command {
+	root := &cobra.Command{Use: "molt"}
+	fetch := &cobra.Command{Use: "fetch"}
+	fetch.PersistentFlags().String("source", "", "")
+	fetch.PersistentFlags().Int("flush-rows", 0, "")
+	fetch.PersistentFlags().Bool("live", false, "")
+	verify := &cobra.Command{Use: "verify"}
+	verify.PersistentFlags().String("source", "", "")
+	verify.PersistentFlags().Int("concurrency", 0, "")
+	verify.PersistentFlags().StringSlice("exclude-columns", nil, "")
+	root.AddCommand(fetch, verify)
+	return root
+}
+
+func subcommand(root *cobra.Command, name string) *cobra.Command {
+	for _, c := range root.Commands() {
+		if c.Name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestParseConfigFile(t *testing.T) {
+	root := testRoot()
+	cfg, err := ParseConfigFile([]byte(`
+source: postgres://localhost/src
+fetch:
+  flush-rows: 100
+  live: true
+verify:
+  concurrency: 4
+  exclude-columns: [a.b.c, a.b.d]
+tables:
+  public.orders:
+    row-batch-size: 1000
+    table-splits: 8
+    exclude-columns: [notes]
+  public.audit:
+    exclude: true
+`), root)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"source": {"postgres://localhost/src"}}, cfg.Flags)
+	require.Equal(
+		t,
+		map[string]map[string][]string{
+			"fetch":  {"flush-rows": {"100"}, "live": {"true"}},
+			"verify": {"concurrency": {"4"}, "exclude-columns": {"a.b.c", "a.b.d"}},
+		},
+		cfg.Commands,
+	)
+	require.Equal(
+		t,
+		map[dbtable.Name]TableConfig{
+			{Schema: "public", Table: "orders"}: {RowBatchSize: 1000, TableSplits: 8, ExcludeColumns: []string{"notes"}},
+			{Schema: "public", Table: "audit"}:  {Exclude: true},
+		},
+		cfg.Tables,
+	)
+	require.NoError(t, cfg.Validate(root))
+
+	// JSON is a subset of YAML.
+	jsonCfg, err := ParseConfigFile([]byte(`{"source": "postgres://localhost/src", "verify": {"concurrency": 4}}`), root)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"source": {"postgres://localhost/src"}}, jsonCfg.Flags)
+	require.Equal(t, map[string]map[string][]string{"verify": {"concurrency": {"4"}}}, jsonCfg.Commands)
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		config   string
+		expected string
+	}{
+		{desc: "not a mapping", config: "- a\n- b", expected: "expected a mapping"},
+		{desc: "unknown command", config: "fetchh:\n  live: true", expected: `unknown command "fetchh"`},
+		{desc: "nested value", config: "source:\n  - [a]", expected: "expected a list of values"},
+		{desc: "bad table name", config: "tables:\n  orders:\n    table-splits: 2", expected: "schema.table"},
+		{desc: "unknown table setting", config: "tables:\n  public.orders:\n    splits: 2", expected: "field splits not found"},
+		{desc: "negative table setting", config: "tables:\n  public.orders:\n    table-splits: -1", expected: "must not be negative"},
+		{desc: "qualified column", config: "tables:\n  public.orders:\n    exclude-columns: [public.orders.id]", expected: "to be a column name"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseConfigFile([]byte(tc.config), testRoot())
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expected)
+		})
+	}
+}
+
+func TestConfigFileValidate(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		config   string
+		expected string
+	}{
+		{desc: "unknown top-level flag", config: "sourcee: a", expected: "unknown flag sourcee"},
+		{desc: "unknown command flag", config: "fetch:\n  concurrency: 4", expected: `unknown flag "concurrency" for command fetch`},
+		{desc: "invalid value", config: "verify:\n  concurrency: many", expected: `invalid value "many" for flag "concurrency"`},
+		{desc: "invalid top-level value", config: "live: maybe", expected: `invalid value "maybe" for flag "live"`},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			root := testRoot()
+			cfg, err := ParseConfigFile([]byte(tc.config), root)
+			require.NoError(t, err)
+			err = cfg.Validate(root)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expected)
+		})
+	}
+}
+
+func TestConfigFileApply(t *testing.T) {
+	root := testRoot()
+	cfg, err := ParseConfigFile([]byte(`
+source: postgres://localhost/src
+concurrency: 2
+verify:
+  concurrency: 4
+`), root)
+	require.NoError(t, err)
+
+	// Flags set on the command line take precedence.
+	verify := subcommand(root, "verify")
+	require.NoError(t, verify.PersistentFlags().Set("source", "postgres://localhost/other"))
+	require.NoError(t, cfg.apply(verify))
+	require.Equal(t, "postgres://localhost/other", verify.PersistentFlags().Lookup("source").Value.String())
+	// Command sections take precedence over the top level.
+	require.Equal(t, "4", verify.PersistentFlags().Lookup("concurrency").Value.String())
+
+	// Top-level flags are only applied to commands with the flag.
+	fetch := subcommand(root, "fetch")
+	require.NoError(t, cfg.apply(fetch))
+	require.Equal(t, "postgres://localhost/src", fetch.PersistentFlags().Lookup("source").Value.String())
+}
+
+func TestConfigColumns(t *testing.T) {
+	defer func(old *ConfigFile) { configFile = old }(configFile)
+	configFile = &ConfigFile{
+		Tables: map[dbtable.Name]TableConfig{
+			{Schema: "public", Table: "orders"}: {ExcludeColumns: []string{"notes", "audit"}},
+			{Schema: "public", Table: "users"}:  {IncludeColumns: []string{"id"}},
+		},
+	}
+	exclude, include := ConfigColumns()
+	require.Equal(t, []string{"public.orders.audit", "public.orders.notes"}, exclude)
+	require.Equal(t, []string{"public.users.id"}, include)
+}
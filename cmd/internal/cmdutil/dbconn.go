@@ -3,8 +3,10 @@ package cmdutil
 import (
 	"context"
 
+	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
 )
 
 var DBConnConfig = dbconn.Config{}
@@ -14,30 +16,157 @@ func RegisterDBConnFlags(cmd *cobra.Command) {
 		&DBConnConfig.Source,
 		"source",
 		"",
-		"URL of the source database",
+		"URL of the source database (required)",
 	)
 	cmd.PersistentFlags().StringVar(
 		&DBConnConfig.Target,
 		"target",
 		"",
-		"URL of the target database",
+		"URL of the target database (required)",
 	)
+	cmd.PersistentFlags().StringVar(
+		&DBConnConfig.SourcePasswordFrom,
+		"source-password-from",
+		"",
+		"reads the password of --source from env:<variable>, file:<path> or the output of command:<command> instead of the URL",
+	)
+	cmd.PersistentFlags().StringVar(
+		&DBConnConfig.TargetPasswordFrom,
+		"target-password-from",
+		"",
+		"reads the password of --target from env:<variable>, file:<path> or the output of command:<command> instead of the URL",
+	)
+	cmd.PersistentFlags().IntVar(
+		&DBConnConfig.SourcePool.MaxConns,
+		"source-max-conns",
+		0,
+		"maximum number of connections open to the source at once, shared by all workers, including one kept open for the whole run; must be 0 (unlimited) or at least 2",
+	)
+	cmd.PersistentFlags().IntVar(
+		&DBConnConfig.TargetPool.MaxConns,
+		"target-max-conns",
+		0,
+		"maximum number of connections open to the target at once, shared by all workers, including one kept open for the whole run; must be 0 (unlimited) or at least 2",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&DBConnConfig.SourcePool.StatementTimeout,
+		"source-statement-timeout",
+		0,
+		"aborts statements on the source running longer than this (SELECT only on MySQL, unsupported on Oracle); 0 disables the timeout",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&DBConnConfig.TargetPool.StatementTimeout,
+		"target-statement-timeout",
+		0,
+		"aborts statements on the target running longer than this (SELECT only on MySQL, unsupported on Oracle); 0 disables the timeout",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&DBConnConfig.SourcePool.AcquireTimeout,
+		"source-acquire-timeout",
+		0,
+		"fails if a worker waits longer than this for a connection to the source once --source-max-conns are open; 0 waits indefinitely",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&DBConnConfig.TargetPool.AcquireTimeout,
+		"target-acquire-timeout",
+		0,
+		"fails if a worker waits longer than this for a connection to the target once --target-max-conns are open; 0 waits indefinitely",
+	)
+	registerTLSFlags(cmd, "source", &DBConnConfig.SourceTLS)
+	registerTLSFlags(cmd, "target", &DBConnConfig.TargetTLS)
+}
 
-	for _, required := range []string{"source", "target"} {
-		if err := cmd.MarkPersistentFlagRequired(required); err != nil {
-			panic(err)
-		}
-	}
+// registerTLSFlags registers the --<id>-tls-* flags, which override the TLS
+// settings of the connection string.
+func registerTLSFlags(cmd *cobra.Command, id string, settings *dbconn.TLSSettings) {
+	cmd.PersistentFlags().Var(
+		enumflag.New(
+			&settings.Mode,
+			id+"-tls-mode",
+			dbconn.TLSModeStringRepresentations,
+			enumflag.EnumCaseInsensitive,
+		),
+		id+"-tls-mode",
+		"TLS mode of --"+id+" (default/disable/require/verify-ca/verify-full); default uses the connection string, or verify-full if any other --"+id+"-tls-* flag is set; the only TLS flag supported for Oracle, which does not support verify-ca",
+	)
+	cmd.PersistentFlags().StringVar(
+		&settings.CAFile,
+		id+"-tls-ca",
+		"",
+		"PEM file of the CAs trusted to sign the certificate of --"+id+"; the system CAs are trusted if unset (unsupported for Oracle; set WALLET in the connection string instead)",
+	)
+	cmd.PersistentFlags().StringVar(
+		&settings.CertFile,
+		id+"-tls-cert",
+		"",
+		"PEM file of the client certificate presented to --"+id+", set with --"+id+"-tls-key (unsupported for Oracle; set WALLET in the connection string instead)",
+	)
+	cmd.PersistentFlags().StringVar(
+		&settings.KeyFile,
+		id+"-tls-key",
+		"",
+		"PEM file of the key of --"+id+"-tls-cert (unsupported for Oracle; set WALLET in the connection string instead)",
+	)
+	cmd.PersistentFlags().StringVar(
+		&settings.ServerName,
+		id+"-tls-server-name",
+		"",
+		"name the certificate of --"+id+" is verified against with verify-full; defaults to the host (unsupported for Oracle)",
+	)
 }
 
 func LoadDBConns(ctx context.Context) (dbconn.OrderedConns, error) {
-	source, err := dbconn.Connect(ctx, "source", DBConnConfig.Source)
+	// The flags are not marked as required by cobra, as they may be set by
+	// the config file once flags have been validated.
+	for _, required := range []struct {
+		flag string
+		val  string
+	}{
+		{flag: "source", val: DBConnConfig.Source},
+		{flag: "target", val: DBConnConfig.Target},
+	} {
+		if required.val == "" {
+			return dbconn.OrderedConns{}, errors.Newf("--%s must be set on the command line or in --config", required.flag)
+		}
+	}
+	source, err := connect(ctx, "source", DBConnConfig.Source, DBConnConfig.SourcePasswordFrom, DBConnConfig.SourcePool, DBConnConfig.SourceTLS)
 	if err != nil {
 		return dbconn.OrderedConns{}, err
 	}
-	target, err := dbconn.Connect(ctx, "target", DBConnConfig.Target)
+	target, err := connect(ctx, "target", DBConnConfig.Target, DBConnConfig.TargetPasswordFrom, DBConnConfig.TargetPool, DBConnConfig.TargetTLS)
 	if err != nil {
 		return dbconn.OrderedConns{}, err
 	}
 	return dbconn.OrderedConns{source, target}, nil
 }
+
+// ConnectAdditionalTarget connects to a further target with the connection
+// limits, timeouts and TLS settings of --target, except the TLS server name,
+// which names the host of --target.
+func ConnectAdditionalTarget(
+	ctx context.Context, id dbconn.ID, connStr string,
+) (dbconn.Conn, error) {
+	tlsSettings := DBConnConfig.TargetTLS
+	tlsSettings.ServerName = ""
+	return connect(ctx, id, connStr, "", DBConnConfig.TargetPool, tlsSettings)
+}
+
+func connect(
+	ctx context.Context,
+	id dbconn.ID,
+	connStr string,
+	passwordFrom string,
+	poolSettings dbconn.PoolSettings,
+	tlsSettings dbconn.TLSSettings,
+) (dbconn.Conn, error) {
+	if passwordFrom != "" {
+		password, err := dbconn.ReadPassword(ctx, passwordFrom)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading password of --%s", id)
+		}
+		if connStr, err = dbconn.WithPassword(connStr, password); err != nil {
+			return nil, err
+		}
+	}
+	return dbconn.ConnectPool(ctx, id, connStr, poolSettings, dbconn.WithTLS(tlsSettings))
+}
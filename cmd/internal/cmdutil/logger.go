@@ -1,16 +1,56 @@
 package cmdutil
 
 import (
+	"io"
+	"os"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/cockroachdb/molt/moltlogger"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// LogFormat is the format logs are written in.
+type LogFormat enumflag.Flag
+
+const (
+	// LogFormatConsole writes human readable logs.
+	LogFormatConsole LogFormat = iota
+	// LogFormatJSON writes a JSON object per line.
+	LogFormatJSON
+)
+
+var LogFormatStringRepresentations = map[LogFormat][]string{
+	LogFormatConsole: {"console"},
+	LogFormatJSON:    {"json"},
+}
+
 type loggerConfig struct {
-	level string
+	level  string
+	format LogFormat
+	file   string
+	// maxSizeMB, maxBackups and maxAgeDays configure the rotation of file.
+	maxSizeMB  int
+	maxBackups int
+	maxAgeDays int
 }
 
 var loggerConfigInst = loggerConfig{
-	level: zerolog.InfoLevel.String(),
+	level:      zerolog.InfoLevel.String(),
+	format:     LogFormatConsole,
+	maxSizeMB:  100,
+	maxBackups: 10,
+}
+
+// runID identifies this invocation of molt in logs and results.
+var runID = uuid.MakeV4().String()
+
+// RunID returns the ID of this invocation of molt, which is logged with
+// every event.
+func RunID() string {
+	return runID
 }
 
 func RegisterLoggerFlags(cmd *cobra.Command) {
@@ -20,13 +60,66 @@ func RegisterLoggerFlags(cmd *cobra.Command) {
 		loggerConfigInst.level,
 		"what level to log at - maps to zerolog.Level",
 	)
+	cmd.PersistentFlags().Var(
+		enumflag.New(
+			&loggerConfigInst.format,
+			"log-format",
+			LogFormatStringRepresentations,
+			enumflag.EnumCaseInsensitive,
+		),
+		"log-format",
+		"format to write logs in (console/json)",
+	)
+	cmd.PersistentFlags().StringVar(
+		&loggerConfigInst.file,
+		"log-file",
+		"",
+		"if set, writes logs to this file instead of stdout, rotating it once it reaches --log-file-max-size",
+	)
+	cmd.PersistentFlags().IntVar(
+		&loggerConfigInst.maxSizeMB,
+		"log-file-max-size",
+		loggerConfigInst.maxSizeMB,
+		"size in megabytes at which --log-file is rotated",
+	)
+	cmd.PersistentFlags().IntVar(
+		&loggerConfigInst.maxBackups,
+		"log-file-max-backups",
+		loggerConfigInst.maxBackups,
+		"number of rotated log files to keep, or 0 to keep all of them",
+	)
+	cmd.PersistentFlags().IntVar(
+		&loggerConfigInst.maxAgeDays,
+		"log-file-max-age",
+		loggerConfigInst.maxAgeDays,
+		"number of days to keep rotated log files for, or 0 to keep them regardless of age",
+	)
 }
 
 func Logger() (zerolog.Logger, error) {
-	logger := zerolog.New(zerolog.NewConsoleWriter())
+	logger := newLogger(loggerConfigInst)
 	lvl, err := zerolog.ParseLevel(loggerConfigInst.level)
 	if err != nil {
 		return logger, err
 	}
 	return logger.Level(lvl), err
 }
+
+func newLogger(cfg loggerConfig) zerolog.Logger {
+	var w io.Writer = os.Stdout
+	if cfg.file != "" {
+		w = &lumberjack.Logger{
+			Filename:   cfg.file,
+			MaxSize:    cfg.maxSizeMB,
+			MaxBackups: cfg.maxBackups,
+			MaxAge:     cfg.maxAgeDays,
+		}
+	}
+	if cfg.format == LogFormatConsole {
+		w = zerolog.ConsoleWriter{Out: w, NoColor: cfg.file != ""}
+	}
+	return zerolog.New(w).With().
+		Timestamp().
+		Str(moltlogger.RunIDKey, runID).
+		Logger()
+}
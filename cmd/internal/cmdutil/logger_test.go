@@ -0,0 +1,55 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/molt/moltlogger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		format LogFormat
+		check  func(t *testing.T, line string)
+	}{
+		{
+			desc:   "json",
+			format: LogFormatJSON,
+			check: func(t *testing.T, line string) {
+				var event map[string]any
+				require.NoError(t, json.Unmarshal([]byte(line), &event))
+				require.Equal(t, "info", event["level"])
+				require.Equal(t, RunID(), event[moltlogger.RunIDKey])
+				require.Equal(t, "public.orders", event[moltlogger.TableKey])
+				require.Equal(t, "hello", event["message"])
+				require.Contains(t, event, "time")
+			},
+		},
+		{
+			desc:   "console",
+			format: LogFormatConsole,
+			check: func(t *testing.T, line string) {
+				require.Contains(t, line, "INF hello")
+				require.Contains(t, line, moltlogger.RunIDKey+"="+RunID())
+				require.Contains(t, line, moltlogger.TableKey+"=public.orders")
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "molt.log")
+			logger := newLogger(loggerConfig{format: tc.format, file: path, maxSizeMB: 1})
+			logger.Info().Str(moltlogger.TableKey, "public.orders").Msgf("hello")
+
+			b, err := os.ReadFile(path)
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+			require.Len(t, lines, 1)
+			tc.check(t, lines[0])
+		})
+	}
+}
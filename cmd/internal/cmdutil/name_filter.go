@@ -1,6 +1,7 @@
 package cmdutil
 
 import (
+	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/verify/dbverify"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +23,15 @@ func RegisterNameFilterFlags(cmd *cobra.Command) {
 	)
 }
 
+// TableFilter returns the table filter of the flags, excluding tables
+// excluded by the config file.
 func TableFilter() dbverify.FilterConfig {
-	return tableFilter
+	ret := tableFilter
+	ret.ExcludedTables = append([]dbtable.Name(nil), tableFilter.ExcludedTables...)
+	for name, tc := range ConfigTables() {
+		if tc.Exclude {
+			ret.ExcludedTables = append(ret.ExcludedTables, name)
+		}
+	}
+	return ret
 }
@@ -0,0 +1,112 @@
+package cmdutil
+
+import (
+	"context"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/fetch/datablobstorage"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2/google"
+)
+
+var storeFlags struct {
+	s3Bucket                string
+	gcpBucket               string
+	localPath               string
+	localPathListenAddr     string
+	localPathCRDBAccessAddr string
+	directCRDBCopy          bool
+}
+
+func RegisterStoreFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(
+		&storeFlags.directCRDBCopy,
+		"direct-copy",
+		false,
+		"whether to use direct copy mode",
+	)
+	cmd.PersistentFlags().StringVar(
+		&storeFlags.s3Bucket,
+		"s3-bucket",
+		"",
+		"s3 bucket",
+	)
+	cmd.PersistentFlags().StringVar(
+		&storeFlags.gcpBucket,
+		"gcp-bucket",
+		"",
+		"gcp bucket",
+	)
+	cmd.PersistentFlags().StringVar(
+		&storeFlags.localPath,
+		"local-path",
+		"",
+		"path to upload files to locally",
+	)
+	cmd.PersistentFlags().StringVar(
+		&storeFlags.localPathListenAddr,
+		"local-path-listen-addr",
+		"",
+		"local address to listen to for traffic",
+	)
+	cmd.PersistentFlags().StringVar(
+		&storeFlags.localPathCRDBAccessAddr,
+		"local-path-crdb-access-addr",
+		"",
+		"address CockroachDB can access to connect to the --local-path-listen-addr",
+	)
+}
+
+// StoreConfigured returns whether any of the store flags are set.
+func StoreConfigured() bool {
+	return storeFlags.directCRDBCopy ||
+		storeFlags.gcpBucket != "" ||
+		storeFlags.s3Bucket != "" ||
+		storeFlags.localPath != ""
+}
+
+// LoadStore returns the store configured by the store flags.
+func LoadStore(
+	ctx context.Context, logger zerolog.Logger, conns dbconn.OrderedConns,
+) (datablobstorage.Store, error) {
+	switch {
+	case storeFlags.directCRDBCopy:
+		pgConn, ok := conns[1].(*dbconn.PGConn)
+		if !ok {
+			return nil, errors.Newf("direct copy is not supported for a %s target", conns[1].Dialect())
+		}
+		return datablobstorage.NewCopyCRDBDirect(logger, pgConn), nil
+	case storeFlags.gcpBucket != "":
+		creds, err := google.FindDefaultCredentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		gcpClient, err := storage.NewClient(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return datablobstorage.NewGCPStore(logger, gcpClient, creds, storeFlags.gcpBucket), nil
+	case storeFlags.s3Bucket != "":
+		sess, err := session.NewSession()
+		if err != nil {
+			return nil, err
+		}
+		creds, err := sess.Config.Credentials.Get()
+		if err != nil {
+			return nil, err
+		}
+		return datablobstorage.NewS3Store(logger, sess, creds, storeFlags.s3Bucket), nil
+	case storeFlags.localPath != "":
+		return datablobstorage.NewLocalStore(
+			logger,
+			storeFlags.localPath,
+			storeFlags.localPathListenAddr,
+			storeFlags.localPathCRDBAccessAddr,
+		)
+	}
+	return nil, errors.AssertionFailedf("data source must be configured (--s3-bucket, --gcp-bucket, --direct-copy)")
+}
@@ -4,7 +4,14 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/cmd/config"
+	"github.com/cockroachdb/molt/cmd/cutover"
+	"github.com/cockroachdb/molt/cmd/doctor"
+	"github.com/cockroachdb/molt/cmd/estimate"
+	"github.com/cockroachdb/molt/cmd/fallback"
 	"github.com/cockroachdb/molt/cmd/fetch"
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
 	"github.com/cockroachdb/molt/cmd/verify"
 	"github.com/spf13/cobra"
 )
@@ -13,16 +20,29 @@ var rootCmd = &cobra.Command{
 	Use:   "molt",
 	Short: "Onboarding assistance for migrating to CockroachDB",
 	Long:  `MOLT (Migrate Off Legacy Things) provides tooling which assists migrating off other database providers to CockroachDB.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return cmdutil.LoadConfigFile(cmd)
+	},
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		var exitErr *cmdutil.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
 
 func init() {
+	cmdutil.RegisterConfigFlag(rootCmd)
+	rootCmd.AddCommand(doctor.Command())
 	rootCmd.AddCommand(verify.Command())
+	rootCmd.AddCommand(estimate.Command())
 	rootCmd.AddCommand(fetch.Command())
+	rootCmd.AddCommand(fallback.Command())
+	rootCmd.AddCommand(cutover.Command())
+	rootCmd.AddCommand(config.Command(rootCmd))
 }
@@ -2,15 +2,23 @@ package verify
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/retry"
 	"github.com/cockroachdb/molt/verify"
+	"github.com/cockroachdb/molt/verify/aggverify"
+	"github.com/cockroachdb/molt/verify/comparator"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/cockroachdb/molt/verify/summary"
+	"github.com/cockroachdb/molt/verify/tableverify"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
 )
 
 func Command() *cobra.Command {
@@ -36,6 +44,30 @@ func Command() *cobra.Command {
 		}
 		verifyLimitRowsPerSecond int
 		verifyRows               bool
+		verifyAggregatesOnly     bool
+		verifyAggregateSettings  = aggverify.Settings{
+			ColumnAggregates: true,
+		}
+		verifyCheckpointFile     string
+		verifyResume             bool
+		verifyCompareRule        comparator.Rule
+		verifyCompareTimezone    string
+		verifyCompareColumnRules []string
+		verifyExcludeColumns     []string
+		verifyIncludeColumns     []string
+		verifySchemaObjects      bool
+		verifyAsOf               string
+		verifyAsOfSlot           string
+		verifyAsOfTimeout        time.Duration
+		verifySummaryFile        string
+		verifyHTMLReport         string
+		verifyHTMLMaxRows        int
+		verifyResults            bool
+		verifyResultsTable       string
+		verifyResultsBatchSize   int
+		verifyResultsFlushEvery  time.Duration
+		verifyWebhookSettings    = inconsistency.DefaultWebhookSettings
+		verifyAdditionalTargets  []string
 	)
 
 	cmd := &cobra.Command{
@@ -51,13 +83,92 @@ func Command() *cobra.Command {
 
 			reporter := inconsistency.CombinedReporter{}
 			reporter.Reporters = append(reporter.Reporters, &inconsistency.LogReporter{Logger: logger})
+			if verifyHTMLReport != "" {
+				reporter.Reporters = append(
+					reporter.Reporters,
+					inconsistency.NewHTMLReporter(verifyHTMLReport, verifyHTMLMaxRows, logger),
+				)
+			}
+			if verifyWebhookSettings.URL != "" {
+				if verifyWebhookSettings.DigestInterval <= 0 {
+					return errors.Newf("--webhook-digest-interval must be positive")
+				}
+				reporter.Reporters = append(
+					reporter.Reporters,
+					inconsistency.NewWebhookReporter(verifyWebhookSettings, logger),
+				)
+			}
 			defer reporter.Close()
 
+			valueComparator, err := comparatorFromFlags(verifyCompareRule, verifyCompareTimezone, verifyCompareColumnRules)
+			if err != nil {
+				return err
+			}
+
+			configExcludeColumns, configIncludeColumns := cmdutil.ConfigColumns()
+			columnFilter, err := tableverify.ParseColumnFilter(
+				append(verifyExcludeColumns, configExcludeColumns...),
+				append(verifyIncludeColumns, configIncludeColumns...),
+			)
+			if err != nil {
+				return err
+			}
+
+			var asOf *time.Time
+			if verifyAsOf != "" {
+				t, err := verify.ParseAsOf(verifyAsOf, time.Now())
+				if err != nil {
+					return errors.Wrap(err, "error parsing --as-of")
+				}
+				asOf = &t
+			}
+
 			ctx := context.Background()
 			conns, err := cmdutil.LoadDBConns(ctx)
 			if err != nil {
 				return err
 			}
+			additionalTargets := make([]dbconn.Conn, len(verifyAdditionalTargets))
+			for i, connStr := range verifyAdditionalTargets {
+				if additionalTargets[i], err = cmdutil.ConnectAdditionalTarget(
+					ctx,
+					dbconn.ID(fmt.Sprintf("target_%d", i+2)),
+					connStr,
+				); err != nil {
+					return err
+				}
+				defer func(conn dbconn.Conn) { _ = conn.Close(ctx) }(additionalTargets[i])
+			}
+			if len(additionalTargets) > 0 && verifyFixup {
+				return errors.Newf("--fixup is not supported with --additional-target")
+			}
+			dbFilter := cmdutil.TableFilter()
+			if verifyResults {
+				resultsConn, err := conns[1].Clone(ctx)
+				if err != nil {
+					return errors.Wrap(err, "error establishing connection to write results")
+				}
+				runID := cmdutil.RunID()
+				tableReporter, err := inconsistency.NewTableReporter(
+					ctx,
+					resultsConn,
+					verifyResultsTable,
+					runID,
+					verifyResultsBatchSize,
+					verifyResultsFlushEvery,
+					logger,
+				)
+				if err != nil {
+					_ = resultsConn.Close(ctx)
+					return err
+				}
+				reporter.Reporters = append(reporter.Reporters, tableReporter)
+				dbFilter.ExcludedTables = append(dbFilter.ExcludedTables, tableReporter.TableName())
+				logger.Info().
+					Str("run_id", runID).
+					Str("table", verifyResultsTable).
+					Msgf("writing results to the target")
+			}
 			if verifyFixup {
 				fixupConn, err := conns[1].Clone(ctx)
 				if err != nil {
@@ -69,6 +180,7 @@ func Command() *cobra.Command {
 				})
 			}
 
+			collector := summary.NewCollector()
 			reporter.Report(inconsistency.StatusReport{Info: "verification in progress"})
 			if err := verify.Verify(
 				ctx,
@@ -77,16 +189,48 @@ func Command() *cobra.Command {
 				reporter,
 				verify.WithConcurrency(verifyConcurrency),
 				verify.WithTableSplits(verifyTableSplits),
+				verify.WithTableSettings(tableSettings()),
 				verify.WithRowBatchSize(verifyRowBatchSize),
 				verify.WithContinuous(verifyContinuous, verifyContinuousPause),
 				verify.WithLive(verifyLive, verifyLiveVerificationSettings),
-				verify.WithDBFilter(cmdutil.TableFilter()),
+				verify.WithDBFilter(dbFilter),
 				verify.WithRowsPerSecond(verifyLimitRowsPerSecond),
 				verify.WithRows(verifyRows),
+				verify.WithAggregatesOnly(verifyAggregatesOnly, verifyAggregateSettings),
+				verify.WithCheckpoints(verifyCheckpointFile, verifyResume),
+				verify.WithComparator(valueComparator),
+				verify.WithColumnFilter(columnFilter),
+				verify.WithSchemaObjects(verifySchemaObjects),
+				verify.WithAsOf(asOf),
+				verify.WithAsOfReplicationSlot(verifyAsOfSlot, verifyAsOfTimeout),
+				verify.WithSummary(collector),
+				verify.WithAdditionalTargets(additionalTargets...),
 			); err != nil {
 				return errors.Wrapf(err, "error verifying")
 			}
 			reporter.Report(inconsistency.StatusReport{Info: "verification complete"})
+
+			s := collector.Summary()
+			if err := s.WriteTable(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+			if verifySummaryFile != "" {
+				if err := s.WriteJSONFile(verifySummaryFile); err != nil {
+					return err
+				}
+			}
+			if code := s.ExitCode(); code != 0 {
+				cmd.SilenceUsage = true
+				return &cmdutil.ExitError{
+					Code: code,
+					Err: errors.Newf(
+						"verification found %d schema and %d row inconsistencies, and %d shards failed",
+						s.SchemaInconsistencies,
+						s.RowInconsistencies,
+						s.FailedShards,
+					),
+				}
+			}
 			return nil
 		},
 	}
@@ -145,6 +289,200 @@ func Command() *cobra.Command {
 		true,
 		"whether rows should be verified (otherwise, performs a more basic schema check)",
 	)
+	cmd.PersistentFlags().BoolVar(
+		&verifySchemaObjects,
+		"schema-objects",
+		false,
+		"whether secondary indexes, CHECK and FOREIGN KEY constraints, column defaults, computed columns, sequences, enums, views, functions and procedures are also verified",
+	)
+	cmd.PersistentFlags().StringVar(
+		&verifyHTMLReport,
+		"html-report",
+		"",
+		"if set, writes an HTML report of mismatching, missing and extraneous rows to this file",
+	)
+	cmd.PersistentFlags().IntVar(
+		&verifyHTMLMaxRows,
+		"html-report-max-rows",
+		inconsistency.DefaultHTMLMaxRowsPerTable,
+		"maximum number of rows of each table to include in the HTML report",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&verifyResults,
+		"results",
+		false,
+		"whether inconsistencies are written to a results table on the target, which must be PostgreSQL or CockroachDB",
+	)
+	cmd.PersistentFlags().StringVar(
+		&verifyResultsTable,
+		"results-table",
+		inconsistency.DefaultResultsTable,
+		"table on the target to write results to with --results; created if it does not exist",
+	)
+	cmd.PersistentFlags().IntVar(
+		&verifyResultsBatchSize,
+		"results-batch-size",
+		inconsistency.DefaultResultsBatchSize,
+		"number of results to write to the results table at a time",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&verifyResultsFlushEvery,
+		"results-flush-interval",
+		inconsistency.DefaultResultsFlushInterval,
+		"interval at which results are written to the results table, even if fewer than --results-batch-size are pending",
+	)
+	cmd.PersistentFlags().StringVar(
+		&verifyWebhookSettings.URL,
+		"webhook-url",
+		"",
+		"if set, posts digests of inconsistencies to this webhook URL",
+	)
+	cmd.PersistentFlags().Var(
+		enumflag.New(
+			&verifyWebhookSettings.Format,
+			"webhook-format",
+			inconsistency.WebhookFormatStringRepresentations,
+			enumflag.EnumCaseInsensitive,
+		),
+		"webhook-format",
+		"payload format to post to --webhook-url (json/slack/teams)",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&verifyWebhookSettings.DigestInterval,
+		"webhook-digest-interval",
+		verifyWebhookSettings.DigestInterval,
+		"how often inconsistencies found since the last digest are posted to the webhook; must be positive",
+	)
+	cmd.PersistentFlags().IntVar(
+		&verifyWebhookSettings.MaxPerHour,
+		"webhook-max-per-hour",
+		verifyWebhookSettings.MaxPerHour,
+		"maximum number of digests posted to the webhook an hour; further inconsistencies are included in the next digest (unlimited if 0)",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&verifyWebhookSettings.DedupeWindow,
+		"webhook-dedupe-window",
+		verifyWebhookSettings.DedupeWindow,
+		"how long an inconsistency on the same primary key is not posted to the webhook again (always posted if 0)",
+	)
+	cmd.PersistentFlags().IntVar(
+		&verifyWebhookSettings.MaxSamplesPerTable,
+		"webhook-max-samples",
+		verifyWebhookSettings.MaxSamplesPerTable,
+		"number of primary keys of each table included in a webhook digest",
+	)
+	cmd.PersistentFlags().StringArrayVar(
+		&verifyAdditionalTargets,
+		"additional-target",
+		nil,
+		"URL of a further target to verify against the source in the same scan (may be repeated); inconsistencies are labelled with target IDs target, target_2, target_3 and so on; connected with the --target-max-conns, --target-statement-timeout, --target-acquire-timeout and --target-tls-* settings except --target-tls-server-name",
+	)
+	cmd.PersistentFlags().StringVar(
+		&verifySummaryFile,
+		"summary-file",
+		"",
+		"file to write a JSON summary of verification to; the summary is only printed if unset",
+	)
+	cmd.PersistentFlags().StringVar(
+		&verifyAsOf,
+		"as-of",
+		"",
+		"verify rows AS OF SYSTEM TIME this timestamp, HLC timestamp or negative duration (e.g. -30s); only supported if both sides are CockroachDB",
+	)
+	cmd.PersistentFlags().StringVar(
+		&verifyAsOfSlot,
+		"as-of-replication-slot",
+		"",
+		"verify rows at a point in time by reading a PostgreSQL source under a single exported snapshot, and the CockroachDB target once the replicator reading this replication slot has confirmed the snapshot",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&verifyAsOfTimeout,
+		"as-of-timeout",
+		10*time.Minute,
+		"maximum time to wait for --as-of-replication-slot to confirm the snapshot",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&verifyAggregatesOnly,
+		"aggregates-only",
+		false,
+		"compares row counts and column aggregates of each shard instead of verifying every row",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&verifyAggregateSettings.ColumnAggregates,
+		"aggregate-columns",
+		verifyAggregateSettings.ColumnAggregates,
+		"whether sums, minimums, maximums and null counts of columns are compared in --aggregates-only mode (otherwise, only row counts are compared)",
+	)
+	cmd.PersistentFlags().StringVar(
+		&verifyCheckpointFile,
+		"checkpoint-file",
+		"",
+		"file to persist the progress of row verification to, so it can be resumed with --resume; progress is not persisted if unset",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&verifyResume,
+		"resume",
+		false,
+		"resumes row verification from the progress persisted in --checkpoint-file by an earlier run",
+	)
+	cmd.PersistentFlags().Float64Var(
+		&verifyCompareRule.FloatEpsilon,
+		"compare-float-epsilon",
+		0,
+		"if set, floats which differ by at most this amount (absolutely or relative to the larger value) are considered equal",
+	)
+	cmd.PersistentFlags().Uint64Var(
+		&verifyCompareRule.FloatULPs,
+		"compare-float-ulps",
+		0,
+		"if set, floats which are at most this many units in the last place apart are considered equal",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&verifyCompareRule.DecimalStrictScale,
+		"compare-decimal-strict-scale",
+		false,
+		"whether decimals must have the same scale to be considered equal (e.g. 1.10 and 1.1)",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&verifyCompareRule.TimestampPrecision,
+		"compare-timestamp-precision",
+		0,
+		"if set, timestamps are truncated to this precision (e.g. 1ms) before being compared",
+	)
+	cmd.PersistentFlags().StringVar(
+		&verifyCompareTimezone,
+		"compare-timestamp-timezone",
+		"",
+		"time zone TIMESTAMP values are assumed to be in when compared against TIMESTAMPTZ values (defaults to UTC)",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&verifyCompareRule.IgnoreTrailingWhitespace,
+		"compare-ignore-trailing-whitespace",
+		false,
+		"whether trailing spaces on strings (e.g. CHAR padding) are ignored when comparing",
+	)
+	cmd.PersistentFlags().StringArrayVar(
+		&verifyCompareColumnRules,
+		"compare-column-rule",
+		nil,
+		"overrides comparison options for a column, in the form schema.table.column:option=value,... "+
+			"where options are float-epsilon, float-ulps, decimal-strict-scale, timestamp-precision, timestamp-timezone and ignore-trailing-whitespace "+
+			"(may be repeated)",
+	)
+	cmd.PersistentFlags().StringSliceVar(
+		&verifyExcludeColumns,
+		"exclude-columns",
+		nil,
+		"columns which are not verified, in the form table.column or schema.table.column (comma separated). "+
+			"Primary key columns are always verified",
+	)
+	cmd.PersistentFlags().StringSliceVar(
+		&verifyIncludeColumns,
+		"include-columns",
+		nil,
+		"columns which are verified, in the form table.column or schema.table.column (comma separated). "+
+			"Tables with included columns only verify those columns and their primary key; other tables verify all columns",
+	)
 	cmd.PersistentFlags().IntVar(
 		&verifyLiveVerificationSettings.RunsPerSecond,
 		"live-runs-per-second",
@@ -198,3 +536,42 @@ func Command() *cobra.Command {
 	cmdutil.RegisterMetricsFlags(cmd)
 	return cmd
 }
+
+// tableSettings returns the per-table settings of the config file.
+func tableSettings() map[dbtable.Name]verify.TableSettings {
+	ret := map[dbtable.Name]verify.TableSettings{}
+	for name, tc := range cmdutil.ConfigTables() {
+		ret[name] = verify.TableSettings{
+			RowBatchSize: tc.RowBatchSize,
+			TableSplits:  tc.TableSplits,
+		}
+	}
+	return ret
+}
+
+func comparatorFromFlags(
+	rule comparator.Rule, timezone string, columnRules []string,
+) (comparator.Comparator, error) {
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error loading --compare-timestamp-timezone")
+		}
+		rule.TimestampLocation = loc
+	}
+	settings := comparator.Settings{
+		Default: rule,
+		Columns: make(map[comparator.ColumnRef]comparator.Rule, len(columnRules)),
+	}
+	for _, s := range columnRules {
+		ref, columnRule, err := comparator.ParseColumnRule(s, rule)
+		if err != nil {
+			return nil, err
+		}
+		settings.Columns[ref] = columnRule
+	}
+	if settings.Default == (comparator.Rule{}) && len(settings.Columns) == 0 {
+		return comparator.Exact, nil
+	}
+	return comparator.New(settings), nil
+}
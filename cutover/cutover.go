@@ -0,0 +1,438 @@
+// Package cutover coordinates the steps of cutting over from the source to
+// the target once data has been fetched and is being replicated.
+package cutover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/molttelemetry"
+	"github.com/cockroachdb/molt/verify"
+	"github.com/cockroachdb/molt/verify/aggverify"
+	"github.com/cockroachdb/molt/verify/dbverify"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/cockroachdb/molt/verify/summary"
+	"github.com/rs/zerolog"
+)
+
+// DefaultStatePath is the default file the progress of cutover is kept in.
+const DefaultStatePath = "molt_cutover_state.json"
+
+// DefaultHeartbeatTable is the default table used to measure lag.
+const DefaultHeartbeatTable = "molt_cutover_heartbeat"
+
+// Config configures cutover.
+type Config struct {
+	// ReadOnly makes the source read-only before waiting for the replicator
+	// to drain.
+	ReadOnly bool
+	// PGReplicationSlot is the replication slot created by fetch. If set,
+	// lag is measured in bytes by the slot, otherwise by a heartbeat table.
+	PGReplicationSlot string
+	// MaxLagBytes is the lag threshold when measuring lag by the slot.
+	MaxLagBytes int64
+	// HeartbeatTable is the table used to measure lag if there is no slot.
+	HeartbeatTable string
+	// MaxLag is the lag threshold when measuring lag by the heartbeat table.
+	MaxLag time.Duration
+	// LagTimeout is how long to wait for lag to drop below the threshold.
+	LagTimeout time.Duration
+	// DrainTimeout is how long to wait for the replicator to drain.
+	DrainTimeout time.Duration
+	// PollInterval is how often lag is measured.
+	PollInterval time.Duration
+	// StatePath is the file the progress of each step is kept in.
+	StatePath string
+	// Resume resumes from the progress at StatePath. Otherwise, cutover
+	// refuses to start if StatePath exists, so that progress from an
+	// unrelated run does not skip steps.
+	Resume bool
+	// VerifyConcurrency is the number of tables verified at a time.
+	VerifyConcurrency int
+}
+
+// Step is a step of cutover.
+type Step string
+
+const (
+	StepWaitForLag Step = "wait_for_lag"
+	StepReadOnly   Step = "read_only"
+	StepDrain      Step = "drain"
+	StepVerify     Step = "verify"
+)
+
+// Steps are the steps of cutover, in order.
+var Steps = []Step{StepWaitForLag, StepReadOnly, StepDrain, StepVerify}
+
+// StepStatus is the outcome of a step.
+type StepStatus string
+
+const (
+	StepSucceeded StepStatus = "succeeded"
+	StepFailed    StepStatus = "failed"
+	// StepSkipped is the status of steps which were not run, either as they
+	// are disabled or an earlier step failed.
+	StepSkipped StepStatus = "skipped"
+)
+
+// StepResult is the outcome of a step.
+type StepResult struct {
+	Step        Step       `json:"step"`
+	Status      StepStatus `json:"status"`
+	Detail      string     `json:"detail"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt time.Time  `json:"completed_at"`
+}
+
+// state is the progress of cutover, which is kept so that it can be resumed.
+type state struct {
+	Steps []StepResult `json:"steps"`
+	// DrainMark is the position of the source the replicator must reach to
+	// have drained.
+	DrainMark string `json:"drain_mark,omitempty"`
+	// Revert is the statement which makes the source writable again, if it
+	// was made read-only.
+	Revert string `json:"revert,omitempty"`
+}
+
+func (s *state) result(step Step) (StepResult, bool) {
+	for _, r := range s.Steps {
+		if r.Step == step {
+			return r, true
+		}
+	}
+	return StepResult{}, false
+}
+
+func (s *state) setResult(res StepResult) {
+	for i, r := range s.Steps {
+		if r.Step == res.Step {
+			s.Steps[i] = res
+			return
+		}
+	}
+	s.Steps = append(s.Steps, res)
+}
+
+// loadState loads the state at path if resuming, and otherwise checks there
+// is no state at path.
+func loadState(path string, resume bool) (state, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if oserror.IsNotExist(err) {
+			if resume {
+				return state{}, errors.Newf("no cutover state to resume at %s", path)
+			}
+			return state{}, nil
+		}
+		return state{}, errors.Wrapf(err, "error reading cutover state %s", path)
+	}
+	if !resume {
+		return state{}, errors.Newf(
+			"cutover state %s exists from a previous run; resume it with --resume, or remove it to start again",
+			path,
+		)
+	}
+	var s state
+	if err := json.Unmarshal(b, &s); err != nil {
+		return state{}, errors.Wrapf(err, "error decoding cutover state %s", path)
+	}
+	return s, nil
+}
+
+// save writes the state to path, replacing the previous state atomically.
+func (s *state) save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "error writing cutover state")
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return errors.Wrap(err, "error writing cutover state")
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return errors.Wrap(err, "error writing cutover state")
+	}
+	return errors.Wrap(os.Rename(f.Name(), path), "error writing cutover state")
+}
+
+// Report is the go/no-go report of cutover.
+type Report struct {
+	Steps []StepResult
+	// Go is whether it is safe to cut over to the target.
+	Go bool
+	// Revert is the statement which makes the source writable again, if it
+	// was made read-only.
+	Revert string
+}
+
+// Write writes the report in a human readable form.
+func (r Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tSTATUS\tDETAIL")
+	for _, res := range r.Steps {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", res.Step, res.Status, res.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	decision := "NO-GO"
+	if r.Go {
+		decision = "GO"
+	}
+	if _, err := fmt.Fprintf(w, "decision: %s\n", decision); err != nil {
+		return err
+	}
+	if r.Revert != "" {
+		if _, err := fmt.Fprintf(w, "the source is read-only; to make it writable again, run: %s\n", r.Revert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cutover runs each step of cutover in order, stopping at the first step
+// which fails, and returns the go/no-go report. If cfg.Resume is set, steps
+// which succeeded in a previous run, as recorded at cfg.StatePath, are not
+// run again.
+func Cutover(
+	ctx context.Context,
+	cfg Config,
+	logger zerolog.Logger,
+	conns dbconn.OrderedConns,
+	tableFilter dbverify.FilterConfig,
+) (Report, error) {
+	if err := dbconn.RegisterTelemetry(conns); err != nil {
+		return Report{}, err
+	}
+	molttelemetry.ReportTelemetryAsync(logger, "molt_cutover_dialect_"+conns[0].Dialect())
+
+	s, err := loadState(cfg.StatePath, cfg.Resume)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var probe lagProbe
+	if cfg.PGReplicationSlot != "" {
+		pgConn, ok := conns[0].(*dbconn.PGConn)
+		if !ok || pgConn.IsCockroach() {
+			return Report{}, errors.Newf("replication slots are only supported for PostgreSQL sources")
+		}
+		probe = &slotLagProbe{conn: pgConn, slotName: cfg.PGReplicationSlot, maxBytes: cfg.MaxLagBytes}
+	} else {
+		if probe, err = newHeartbeatLagProbe(ctx, conns, cfg.HeartbeatTable, cfg.MaxLag); err != nil {
+			return Report{}, err
+		}
+	}
+
+	c := &cutover{
+		cfg:         cfg,
+		logger:      logger,
+		conns:       conns,
+		tableFilter: tableFilter,
+		probe:       probe,
+		state:       &s,
+	}
+	return c.run(ctx)
+}
+
+type cutover struct {
+	cfg         Config
+	logger      zerolog.Logger
+	conns       dbconn.OrderedConns
+	tableFilter dbverify.FilterConfig
+	probe       lagProbe
+	state       *state
+	now         func() time.Time
+	// verify runs the final verification, returning a description of the
+	// result and whether it found no inconsistencies.
+	verify func(ctx context.Context) (string, bool, error)
+}
+
+func (c *cutover) run(ctx context.Context) (Report, error) {
+	if c.now == nil {
+		c.now = time.Now
+	}
+	if c.verify == nil {
+		c.verify = c.runVerify
+	}
+	failed := false
+	for _, step := range Steps {
+		logger := c.logger.With().Str("step", string(step)).Logger()
+		if prev, ok := c.state.result(step); ok && prev.Status == StepSucceeded && !failed {
+			logger.Info().
+				Time("completed_at", prev.CompletedAt).
+				Msgf("step already succeeded, skipping")
+			continue
+		}
+		res := StepResult{Step: step, StartedAt: c.now().UTC()}
+		if failed {
+			res.Status = StepSkipped
+			res.Detail = "an earlier step failed"
+		} else {
+			logger.Info().Msgf("starting step")
+			res.Status, res.Detail = c.runStep(ctx, step)
+			if res.Status == StepFailed {
+				failed = true
+				logger.Error().Str("detail", res.Detail).Msgf("step failed")
+			} else {
+				logger.Info().Str("status", string(res.Status)).Str("detail", res.Detail).Msgf("step complete")
+			}
+		}
+		res.CompletedAt = c.now().UTC()
+		c.state.setResult(res)
+		if err := c.state.save(c.cfg.StatePath); err != nil {
+			return Report{}, err
+		}
+	}
+
+	report := Report{Go: true, Revert: c.state.Revert}
+	for _, step := range Steps {
+		res, _ := c.state.result(step)
+		report.Steps = append(report.Steps, res)
+		if res.Status == StepFailed || (res.Status == StepSkipped && step != StepReadOnly) {
+			report.Go = false
+		}
+	}
+	return report, nil
+}
+
+func (c *cutover) runStep(ctx context.Context, step Step) (StepStatus, string) {
+	var detail string
+	var ok bool
+	var err error
+	switch step {
+	case StepWaitForLag:
+		detail, ok, err = c.waitForLag(ctx)
+	case StepReadOnly:
+		if !c.cfg.ReadOnly {
+			return StepSkipped, "read-only mode is not enabled"
+		}
+		detail, ok, err = c.setReadOnly(ctx)
+	case StepDrain:
+		detail, ok, err = c.drain(ctx)
+	case StepVerify:
+		detail, ok, err = c.verify(ctx)
+	default:
+		err = errors.AssertionFailedf("unknown step %s", step)
+	}
+	if err != nil {
+		return StepFailed, err.Error()
+	}
+	if !ok {
+		return StepFailed, detail
+	}
+	return StepSucceeded, detail
+}
+
+// poll calls fn every PollInterval until it returns true, or the timeout
+// passes.
+func (c *cutover) poll(
+	ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (bool, error),
+) (bool, error) {
+	deadline := c.now().Add(timeout)
+	for {
+		done, err := fn(ctx)
+		if err != nil || done {
+			return done, err
+		}
+		if !c.now().Before(deadline) {
+			return false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(c.cfg.PollInterval):
+		}
+	}
+}
+
+func (c *cutover) waitForLag(ctx context.Context) (string, bool, error) {
+	var detail string
+	ok, err := c.poll(ctx, c.cfg.LagTimeout, func(ctx context.Context) (bool, error) {
+		var ok bool
+		var err error
+		detail, ok, err = c.probe.lag(ctx)
+		if err == nil {
+			c.logger.Info().Str("step", string(StepWaitForLag)).Msgf("replication %s", detail)
+		}
+		return ok, err
+	})
+	if err == nil && !ok {
+		detail = fmt.Sprintf("timed out after %s with %s", c.cfg.LagTimeout, detail)
+	}
+	return detail, ok, err
+}
+
+func (c *cutover) setReadOnly(ctx context.Context) (string, bool, error) {
+	mark, revert, err := setReadOnly(ctx, c.conns[0], c.probe.mark)
+	if revert != "" {
+		c.state.Revert = revert
+	}
+	if err != nil {
+		return "", false, err
+	}
+	c.state.DrainMark = mark
+	return fmt.Sprintf("source is read-only at %s", mark), true, nil
+}
+
+func (c *cutover) drain(ctx context.Context) (string, bool, error) {
+	if c.state.DrainMark == "" || !c.cfg.ReadOnly {
+		mark, err := c.probe.mark(ctx)
+		if err != nil {
+			return "", false, err
+		}
+		c.state.DrainMark = mark
+	}
+	ok, err := c.poll(ctx, c.cfg.DrainTimeout, func(ctx context.Context) (bool, error) {
+		return c.probe.drained(ctx, c.state.DrainMark)
+	})
+	if err != nil {
+		return "", false, err
+	}
+	if !ok {
+		return fmt.Sprintf("timed out after %s waiting for the replicator to reach %s", c.cfg.DrainTimeout, c.state.DrainMark), false, nil
+	}
+	return fmt.Sprintf("replicator reached %s", c.state.DrainMark), true, nil
+}
+
+// runVerify compares the row count and column aggregates of every table.
+func (c *cutover) runVerify(ctx context.Context) (string, bool, error) {
+	collector := summary.NewCollector()
+	if err := verify.Verify(
+		ctx,
+		c.conns,
+		c.logger,
+		&inconsistency.LogReporter{Logger: c.logger},
+		verify.WithConcurrency(c.cfg.VerifyConcurrency),
+		verify.WithDBFilter(c.tableFilter),
+		verify.WithAggregatesOnly(true, aggverify.Settings{ColumnAggregates: true}),
+		verify.WithSummary(collector),
+	); err != nil {
+		return "", false, err
+	}
+	sum := collector.Summary()
+	detail := fmt.Sprintf(
+		"%d tables verified: %d schema inconsistencies, %d row count or aggregate inconsistencies, %d failed shards",
+		len(sum.Tables),
+		sum.SchemaInconsistencies,
+		sum.RowInconsistencies,
+		sum.FailedShards,
+	)
+	return detail, sum.ExitCode() == 0, nil
+}
@@ -0,0 +1,106 @@
+package cutover
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProbe struct {
+	lagOK     bool
+	drainedOK bool
+	marks     int
+}
+
+func (p *fakeProbe) lag(ctx context.Context) (string, bool, error) {
+	return "lag of 1s (max 5s)", p.lagOK, nil
+}
+
+func (p *fakeProbe) mark(ctx context.Context) (string, error) {
+	p.marks++
+	return "mark", nil
+}
+
+func (p *fakeProbe) drained(ctx context.Context, mark string) (bool, error) {
+	return p.drainedOK, nil
+}
+
+func TestCutover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	probe := &fakeProbe{lagOK: true}
+	verifyRuns := 0
+	newCutover := func(s *state) *cutover {
+		return &cutover{
+			cfg:    Config{StatePath: path, PollInterval: time.Millisecond},
+			logger: zerolog.Nop(),
+			probe:  probe,
+			state:  s,
+			verify: func(ctx context.Context) (string, bool, error) {
+				verifyRuns++
+				return "1 tables verified", true, nil
+			},
+		}
+	}
+
+	// The replicator does not drain, so verify is skipped.
+	s, err := loadState(path, false)
+	require.NoError(t, err)
+	report, err := newCutover(&s).run(context.Background())
+	require.NoError(t, err)
+	require.False(t, report.Go)
+	var statuses []StepStatus
+	for _, res := range report.Steps {
+		statuses = append(statuses, res.Status)
+	}
+	require.Equal(t, []StepStatus{StepSucceeded, StepSkipped, StepFailed, StepSkipped}, statuses)
+	require.Equal(t, 0, verifyRuns)
+
+	// Resuming does not rerun the steps which succeeded.
+	probe.lagOK = false
+	probe.drainedOK = true
+	_, err = loadState(path, false)
+	require.ErrorContains(t, err, "exists from a previous run")
+	s, err = loadState(path, true)
+	require.NoError(t, err)
+	require.Equal(t, "mark", s.DrainMark)
+	report, err = newCutover(&s).run(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.Go)
+	statuses = nil
+	for _, res := range report.Steps {
+		statuses = append(statuses, res.Status)
+	}
+	require.Equal(t, []StepStatus{StepSucceeded, StepSkipped, StepSucceeded, StepSucceeded}, statuses)
+	require.Equal(t, 1, verifyRuns)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf))
+	require.Contains(t, buf.String(), "decision: GO\n")
+}
+
+func TestLoadStateResume(t *testing.T) {
+	_, err := loadState(filepath.Join(t.TempDir(), "state.json"), true)
+	require.ErrorContains(t, err, "no cutover state to resume")
+}
+
+func TestReportWrite(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Report{
+		Steps: []StepResult{
+			{Step: StepWaitForLag, Status: StepSucceeded, Detail: "lag of 1s (max 5s)"},
+			{Step: StepReadOnly, Status: StepFailed, Detail: "permission denied"},
+		},
+		Revert: "SET GLOBAL read_only = OFF",
+	}.Write(&buf))
+	require.Equal(t, `STEP          STATUS     DETAIL
+wait_for_lag  succeeded  lag of 1s (max 5s)
+read_only     failed     permission denied
+decision: NO-GO
+the source is read-only; to make it writable again, run: SET GLOBAL read_only = OFF
+`, buf.String())
+}
@@ -0,0 +1,224 @@
+package cutover
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/mysqlconv"
+	"github.com/jackc/pgx/v5"
+)
+
+// lagProbe measures how far the replicator is behind the source.
+type lagProbe interface {
+	// lag returns a description of the current replication lag, and whether
+	// it is within the threshold.
+	lag(ctx context.Context) (string, bool, error)
+	// mark returns the current position of the source. Once writes to the
+	// source have stopped, the replicator has drained when it has applied
+	// everything up to the mark.
+	mark(ctx context.Context) (string, error)
+	// drained returns whether the replicator has applied everything up to
+	// the mark.
+	drained(ctx context.Context, mark string) (bool, error)
+}
+
+// slotLagProbe measures lag by how far the confirmed position of the
+// replication slot created by fetch at its CDC cursor is behind the WAL.
+type slotLagProbe struct {
+	conn     *dbconn.PGConn
+	slotName string
+	maxBytes int64
+}
+
+func (p *slotLagProbe) lag(ctx context.Context) (string, bool, error) {
+	var lagBytes *int64
+	if err := p.conn.QueryRow(
+		ctx,
+		"SELECT pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn)::INT8 FROM pg_replication_slots WHERE slot_name = $1",
+		p.slotName,
+	).Scan(&lagBytes); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, errors.Newf("replication slot %q not found", p.slotName)
+		}
+		return "", false, errors.Wrapf(err, "error querying replication slot %q", p.slotName)
+	}
+	if lagBytes == nil {
+		return "", false, errors.Newf("replication slot %q has not confirmed any position", p.slotName)
+	}
+	return fmt.Sprintf("lag of %d bytes (max %d)", *lagBytes, p.maxBytes), *lagBytes <= p.maxBytes, nil
+}
+
+func (p *slotLagProbe) mark(ctx context.Context) (string, error) {
+	var lsn string
+	if err := p.conn.QueryRow(ctx, "SELECT pg_current_wal_lsn()::TEXT").Scan(&lsn); err != nil {
+		return "", errors.Wrap(err, "error querying WAL position")
+	}
+	return lsn, nil
+}
+
+func (p *slotLagProbe) drained(ctx context.Context, mark string) (bool, error) {
+	var drained *bool
+	if err := p.conn.QueryRow(
+		ctx,
+		"SELECT confirmed_flush_lsn >= $1::TEXT::pg_lsn FROM pg_replication_slots WHERE slot_name = $2",
+		mark,
+		p.slotName,
+	).Scan(&drained); err != nil {
+		return false, errors.Wrapf(err, "error querying replication slot %q", p.slotName)
+	}
+	return drained != nil && *drained, nil
+}
+
+// heartbeatID is the ID of the row in the heartbeat table.
+const heartbeatID = 1
+
+// heartbeatLagProbe measures lag by writing the current time to a heartbeat
+// table on the source, and reading how old the heartbeat replicated to the
+// target is. The heartbeat table must be replicated.
+type heartbeatLagProbe struct {
+	conns  dbconn.OrderedConns
+	table  string
+	maxLag time.Duration
+	now    func() time.Time
+}
+
+func newHeartbeatLagProbe(
+	ctx context.Context, conns dbconn.OrderedConns, table string, maxLag time.Duration,
+) (*heartbeatLagProbe, error) {
+	p := &heartbeatLagProbe{conns: conns, table: table, maxLag: maxLag, now: time.Now}
+	for _, conn := range conns {
+		var err error
+		switch conn := conn.(type) {
+		case *dbconn.PGConn:
+			_, err = conn.Exec(ctx, fmt.Sprintf(
+				"CREATE TABLE IF NOT EXISTS %s (id INT8 PRIMARY KEY, ts TIMESTAMPTZ NOT NULL)",
+				pgQuoteIdent(table),
+			))
+		case *dbconn.MySQLConn:
+			_, err = conn.ExecContext(ctx, fmt.Sprintf(
+				"CREATE TABLE IF NOT EXISTS %s (id BIGINT PRIMARY KEY, ts DATETIME(6) NOT NULL)",
+				mysqlconv.QuoteIdent(table),
+			))
+		default:
+			return nil, errors.AssertionFailedf("unknown conn type: %T", conn)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating heartbeat table %s on %s", table, conn.ID())
+		}
+	}
+	return p, nil
+}
+
+// write writes the current time as the heartbeat on the source, returning
+// the time written.
+func (p *heartbeatLagProbe) write(ctx context.Context) (time.Time, error) {
+	ts := p.now().UTC().Truncate(time.Microsecond)
+	var err error
+	switch conn := p.conns[0].(type) {
+	case *dbconn.PGConn:
+		// The session may write even if the source is read-only by default.
+		_, err = conn.Exec(ctx, fmt.Sprintf(
+			"BEGIN READ WRITE; INSERT INTO %s (id, ts) VALUES (%d, '%s') ON CONFLICT (id) DO UPDATE SET ts = excluded.ts; COMMIT",
+			pgQuoteIdent(p.table),
+			heartbeatID,
+			ts.Format(time.RFC3339Nano),
+		))
+	case *dbconn.MySQLConn:
+		_, err = conn.ExecContext(
+			ctx,
+			fmt.Sprintf(
+				"INSERT INTO %s (id, ts) VALUES (?, ?) ON DUPLICATE KEY UPDATE ts = VALUES(ts)",
+				mysqlconv.QuoteIdent(p.table),
+			),
+			heartbeatID,
+			ts.Format("2006-01-02 15:04:05.999999"),
+		)
+	default:
+		return time.Time{}, errors.AssertionFailedf("unknown conn type: %T", conn)
+	}
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "error writing heartbeat")
+	}
+	return ts, nil
+}
+
+// read returns the heartbeat replicated to the target, which is zero if none
+// has been replicated.
+func (p *heartbeatLagProbe) read(ctx context.Context) (time.Time, error) {
+	var ts time.Time
+	var err error
+	switch conn := p.conns[1].(type) {
+	case *dbconn.PGConn:
+		err = conn.QueryRow(
+			ctx,
+			fmt.Sprintf("SELECT ts FROM %s WHERE id = $1", pgQuoteIdent(p.table)),
+			heartbeatID,
+		).Scan(&ts)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, nil
+		}
+	case *dbconn.MySQLConn:
+		var s string
+		err = conn.QueryRowContext(
+			ctx,
+			fmt.Sprintf("SELECT CAST(ts AS CHAR) FROM %s WHERE id = ?", mysqlconv.QuoteIdent(p.table)),
+			heartbeatID,
+		).Scan(&s)
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		if err == nil {
+			ts, err = time.Parse("2006-01-02 15:04:05.999999", s)
+		}
+	default:
+		return time.Time{}, errors.AssertionFailedf("unknown conn type: %T", conn)
+	}
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "error reading heartbeat")
+	}
+	return ts.UTC(), nil
+}
+
+func (p *heartbeatLagProbe) lag(ctx context.Context) (string, bool, error) {
+	if _, err := p.write(ctx); err != nil {
+		return "", false, err
+	}
+	ts, err := p.read(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	if ts.IsZero() {
+		return "no heartbeat replicated yet", false, nil
+	}
+	lag := p.now().Sub(ts)
+	return fmt.Sprintf("lag of %s (max %s)", lag.Round(time.Millisecond), p.maxLag), lag <= p.maxLag, nil
+}
+
+func (p *heartbeatLagProbe) mark(ctx context.Context) (string, error) {
+	ts, err := p.write(ctx)
+	if err != nil {
+		return "", err
+	}
+	return ts.Format(time.RFC3339Nano), nil
+}
+
+func (p *heartbeatLagProbe) drained(ctx context.Context, mark string) (bool, error) {
+	markTS, err := time.Parse(time.RFC3339Nano, mark)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid heartbeat mark %q", mark)
+	}
+	ts, err := p.read(ctx)
+	if err != nil {
+		return false, err
+	}
+	return !ts.Before(markTS), nil
+}
+
+func pgQuoteIdent(name string) string {
+	return tree.NameString(name)
+}
@@ -0,0 +1,102 @@
+package cutover
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+)
+
+// setReadOnly stops writes to the source. On PostgreSQL, transactions on the
+// database default to read-only, which only takes effect for new sessions, so
+// other sessions which started before are terminated. On MySQL, read_only is set first so that mark, which may write a heartbeat
+// as a privileged user, is taken once other writes have stopped, before
+// super_read_only stops all writes. It returns the result of mark and the
+// statement which reverts the source to read-write.
+func setReadOnly(
+	ctx context.Context, conn dbconn.Conn, mark func(ctx context.Context) (string, error),
+) (string, string, error) {
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		var db string
+		if err := conn.QueryRow(ctx, "SELECT current_database()").Scan(&db); err != nil {
+			return "", "", err
+		}
+		if _, err := conn.Exec(ctx, fmt.Sprintf(
+			"ALTER DATABASE %s SET default_transaction_read_only = on",
+			pgQuoteIdent(db),
+		)); err != nil {
+			return "", "", errors.Wrap(err, "error making database read-only")
+		}
+		revert := fmt.Sprintf("ALTER DATABASE %s RESET default_transaction_read_only", pgQuoteIdent(db))
+		if err := terminatePGSessions(ctx, conn); err != nil {
+			return "", revert, err
+		}
+		m, err := mark(ctx)
+		return m, revert, err
+	case *dbconn.MySQLConn:
+		if _, err := conn.ExecContext(ctx, "SET GLOBAL read_only = ON"); err != nil {
+			return "", "", errors.Wrap(err, "error setting read_only")
+		}
+		revert := "SET GLOBAL super_read_only = OFF; SET GLOBAL read_only = OFF"
+		m, err := mark(ctx)
+		if err != nil {
+			return "", revert, err
+		}
+		if _, err := conn.ExecContext(ctx, "SET GLOBAL super_read_only = ON"); err != nil {
+			return "", revert, errors.Wrap(err, "error setting super_read_only")
+		}
+		return m, revert, nil
+	}
+	return "", "", errors.AssertionFailedf("unknown conn type: %T", conn)
+}
+
+// pgSessionsTimeout is how long to wait for terminated sessions to end.
+const pgSessionsTimeout = 10 * time.Second
+
+// pgWritableSessions selects the other client sessions of the database which
+// started before $1, and may still write as they started before the database
+// was made read-only.
+const pgWritableSessions = `FROM pg_stat_activity
+WHERE datname = current_database() AND backend_type = 'client backend' AND pid <> pg_backend_pid() AND backend_start < $1`
+
+// terminatePGSessions terminates the sessions of the database which started
+// before it was made read-only, and waits until they have ended. Clients must
+// reconnect, and are then read-only.
+func terminatePGSessions(ctx context.Context, conn *dbconn.PGConn) error {
+	var readOnlyAt time.Time
+	if err := conn.QueryRow(ctx, "SELECT now()").Scan(&readOnlyAt); err != nil {
+		return err
+	}
+	if _, err := conn.Exec(
+		ctx,
+		"SELECT pg_terminate_backend(pid) "+pgWritableSessions,
+		readOnlyAt,
+	); err != nil {
+		return errors.Wrap(err, "error terminating sessions which may still write")
+	}
+	deadline := time.Now().Add(pgSessionsTimeout)
+	for {
+		var remaining int
+		if err := conn.QueryRow(
+			ctx,
+			"SELECT count(*) "+pgWritableSessions,
+			readOnlyAt,
+		).Scan(&remaining); err != nil {
+			return errors.Wrap(err, "error checking for sessions which may still write")
+		}
+		if remaining == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Newf("%d sessions which may still write have not ended after %s", remaining, pgSessionsTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -3,4 +3,16 @@ package dbconn
 type Config struct {
 	Source string
 	Target string
+	// SourcePasswordFrom and TargetPasswordFrom are where the passwords of
+	// the source and target are read from, as accepted by ReadPassword.
+	SourcePasswordFrom string
+	TargetPasswordFrom string
+	// SourcePool and TargetPool configure the connections to the source and
+	// target.
+	SourcePool PoolSettings
+	TargetPool PoolSettings
+	// SourceTLS and TargetTLS override the TLS settings of the source and
+	// target connection strings.
+	SourceTLS TLSSettings
+	TargetTLS TLSSettings
 }
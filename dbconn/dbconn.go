@@ -22,9 +22,9 @@ type OrderedConns [2]Conn
 func RegisterTelemetry(conns OrderedConns) error {
 	cockroachFound := false
 	for _, conn := range conns {
-		if conn.IsCockroach() {
+		if conn, ok := conn.(*PGConn); ok && conn.IsCockroach() {
 			cockroachFound = true
-			molttelemetry.RegisterConnString(conn.ConnStr())
+			molttelemetry.RegisterConnConfig(conn.Config())
 			break
 		}
 	}
@@ -44,11 +44,36 @@ type Conn interface {
 	TypeMap() *pgtype.Map
 
 	IsCockroach() bool
+	// ConnStr returns the connection string with secrets redacted.
 	ConnStr() string
 	Dialect() string
 }
 
-func Connect(ctx context.Context, preferredID ID, connStr string) (Conn, error) {
+// ConnectOpt is an option for connecting to a database.
+type ConnectOpt func(*connectOpts)
+
+type connectOpts struct {
+	tls TLSSettings
+}
+
+// WithTLS sets TLS settings which override those of the connection string.
+func WithTLS(settings TLSSettings) ConnectOpt {
+	return func(o *connectOpts) {
+		o.tls = settings
+	}
+}
+
+func makeConnectOpts(opts []ConnectOpt) connectOpts {
+	var ret connectOpts
+	for _, opt := range opts {
+		opt(&ret)
+	}
+	return ret
+}
+
+func Connect(
+	ctx context.Context, preferredID ID, connStr string, opts ...ConnectOpt,
+) (Conn, error) {
 	id := preferredID
 	if len(connStr) == 0 {
 		return nil, errors.Newf("empty connection string")
@@ -56,23 +81,43 @@ func Connect(ctx context.Context, preferredID ID, connStr string) (Conn, error)
 
 	before := strings.SplitN(connStr, "://", 2)
 
+	var conn Conn
+	var err error
 	switch {
 	case strings.Contains(before[0], "postgres"):
-		u, err := url.Parse(connStr)
-		if err != nil {
-			return nil, errors.Wrapf(err, "unable to parse url: %s", connStr)
+		u, parseErr := url.Parse(connStr)
+		if parseErr != nil {
+			return nil, errors.Wrapf(redactedURLError(parseErr), "unable to parse url: %s", RedactConnStr(connStr))
 		}
 
 		if id == "" {
 			id = ID(u.Hostname() + ":" + u.Port())
 		}
-		return ConnectPG(ctx, id, connStr)
+		conn, err = ConnectPG(ctx, id, connStr, opts...)
 	case strings.Contains(before[0], "mysql"):
-		return ConnectMySQL(ctx, id, connStr)
+		conn, err = ConnectMySQL(ctx, id, connStr, opts...)
 	case strings.Contains(before[0], "oracle"):
-		return ConnectOracle(ctx, id, connStr)
+		conn, err = ConnectOracle(ctx, id, connStr, opts...)
+	default:
+		return nil, errors.Newf("unrecognised scheme %s from %s", before[0], RedactConnStr(connStr))
+	}
+	if err != nil {
+		return nil, redactedConnectError(err, connStr)
+	}
+	return conn, nil
+}
+
+// redactedConnectError wraps an error connecting with the redacted
+// connection string, removing the password if the driver included it in the
+// error.
+func redactedConnectError(err error, connStr string) error {
+	if err == nil {
+		return nil
+	}
+	if pw := connStrPassword(connStr); pw != "" && strings.Contains(err.Error(), pw) {
+		err = errors.Newf("%s", strings.ReplaceAll(err.Error(), pw, redacted))
 	}
-	return nil, errors.Newf("unrecognised scheme %s from %s", before[0], connStr)
+	return errors.Wrapf(err, "error connecting to %s", RedactConnStr(connStr))
 }
 
 // TestOnlyCleanDatabase returns a connection to a clean database.
@@ -13,13 +13,19 @@ type MySQLConn struct {
 	url string
 	*sql.DB
 	typeMap *pgtype.Map
+	pool    *pool
 }
 
-func ConnectMySQL(ctx context.Context, id ID, connStr string) (*MySQLConn, error) {
+func ConnectMySQL(
+	ctx context.Context, id ID, connStr string, opts ...ConnectOpt,
+) (*MySQLConn, error) {
 	cfg, err := mysqlurl.Parse(connStr)
 	if err != nil {
 		return nil, err
 	}
+	if err := applyMySQLTLS(id, cfg, makeConnectOpts(opts).tls); err != nil {
+		return nil, err
+	}
 	u := cfg.FormatDSN()
 	db, err := sql.Open("mysql", u)
 	if err != nil {
@@ -34,18 +40,44 @@ func (c *MySQLConn) ID() ID {
 }
 
 func (c *MySQLConn) Close(ctx context.Context) error {
+	if c.pool != nil {
+		return c.pool.release(ctx, c)
+	}
 	return c.DB.Close()
 }
 
 func (c *MySQLConn) Clone(ctx context.Context) (Conn, error) {
+	if c.pool != nil {
+		return c.pool.acquire(ctx, c)
+	}
+	return c.open(ctx)
+}
+
+func (c *MySQLConn) open(ctx context.Context) (poolableConn, error) {
 	ret, err := ConnectMySQL(ctx, c.id, c.url)
 	if err != nil {
 		return nil, err
 	}
+	// A clone is a single session, as with other dialects, so that session
+	// state such as transactions applies to all its queries.
+	ret.SetMaxOpenConns(1)
 	ret.typeMap = c.typeMap
+	ret.pool = c.pool
 	return ret, nil
 }
 
+func (c *MySQLConn) closeConn(ctx context.Context) error {
+	return c.DB.Close()
+}
+
+func (c *MySQLConn) healthCheck(ctx context.Context) error {
+	return c.PingContext(ctx)
+}
+
+func (c *MySQLConn) reset(ctx context.Context) error {
+	return nil
+}
+
 func (c *MySQLConn) TypeMap() *pgtype.Map {
 	return c.typeMap
 }
@@ -55,7 +87,7 @@ func (c *MySQLConn) IsCockroach() bool {
 }
 
 func (c *MySQLConn) ConnStr() string {
-	return c.url
+	return RedactConnStr(c.url)
 }
 
 var _ Conn = (*MySQLConn)(nil)
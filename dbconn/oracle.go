@@ -13,11 +13,18 @@ type OracleConn struct {
 	connStr string
 	typeMap *pgtype.Map
 	*sql.DB
+	pool *pool
 }
 
 var _ Conn = (*OracleConn)(nil)
 
-func ConnectOracle(ctx context.Context, id ID, connStr string) (*OracleConn, error) {
+func ConnectOracle(
+	ctx context.Context, id ID, connStr string, opts ...ConnectOpt,
+) (*OracleConn, error) {
+	connStr, err := applyOracleTLS(connStr, makeConnectOpts(opts).tls)
+	if err != nil {
+		return nil, err
+	}
 	db, err := sql.Open("oracle", connStr)
 	if err != nil {
 		return nil, err
@@ -31,6 +38,9 @@ func ConnectOracle(ctx context.Context, id ID, connStr string) (*OracleConn, err
 }
 
 func (c *OracleConn) Close(ctx context.Context) error {
+	if c.pool != nil {
+		return c.pool.release(ctx, c)
+	}
 	return c.DB.Close()
 }
 
@@ -43,16 +53,36 @@ func (c *OracleConn) IsCockroach() bool {
 }
 
 func (c *OracleConn) Clone(ctx context.Context) (Conn, error) {
+	if c.pool != nil {
+		return c.pool.acquire(ctx, c)
+	}
+	return c.open(ctx)
+}
+
+func (c *OracleConn) open(ctx context.Context) (poolableConn, error) {
 	ret, err := ConnectOracle(ctx, c.id, c.connStr)
 	if err != nil {
 		return nil, err
 	}
 	ret.typeMap = c.typeMap
+	ret.pool = c.pool
 	return ret, nil
 }
 
+func (c *OracleConn) closeConn(ctx context.Context) error {
+	return c.DB.Close()
+}
+
+func (c *OracleConn) healthCheck(ctx context.Context) error {
+	return c.PingContext(ctx)
+}
+
+func (c *OracleConn) reset(ctx context.Context) error {
+	return nil
+}
+
 func (c *OracleConn) ConnStr() string {
-	return c.connStr
+	return RedactConnStr(c.connStr)
 }
 
 func (c *OracleConn) Dialect() string {
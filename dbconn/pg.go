@@ -16,15 +16,19 @@ type PGConn struct {
 	version     string
 	connStr     string
 	isCockroach bool
+	pool        *pool
 }
 
 var _ Conn = (*PGConn)(nil)
 
-func ConnectPG(ctx context.Context, id ID, connStr string) (*PGConn, error) {
+func ConnectPG(ctx context.Context, id ID, connStr string, opts ...ConnectOpt) (*PGConn, error) {
 	cfg, err := pgx.ParseConfig(connStr)
 	if err != nil {
 		return nil, err
 	}
+	if err := applyPGTLS(cfg, makeConnectOpts(opts).tls); err != nil {
+		return nil, err
+	}
 	return ConnectPGConfig(ctx, id, cfg)
 }
 
@@ -55,11 +59,49 @@ func (c *PGConn) IsCockroach() bool {
 }
 
 func (c *PGConn) Clone(ctx context.Context) (Conn, error) {
+	if c.pool != nil {
+		return c.pool.acquire(ctx, c)
+	}
 	return ConnectPGConfig(ctx, c.id, c.Conn.Config())
 }
 
+// Close closes the connection, or returns it to its pool.
+func (c *PGConn) Close(ctx context.Context) error {
+	if c.pool != nil {
+		return c.pool.release(ctx, c)
+	}
+	return c.Conn.Close(ctx)
+}
+
+func (c *PGConn) open(ctx context.Context) (poolableConn, error) {
+	ret, err := ConnectPGConfig(ctx, c.id, c.Conn.Config())
+	if err != nil {
+		return nil, err
+	}
+	ret.pool = c.pool
+	return ret, nil
+}
+
+func (c *PGConn) closeConn(ctx context.Context) error {
+	return c.Conn.Close(ctx)
+}
+
+func (c *PGConn) healthCheck(ctx context.Context) error {
+	return c.Ping(ctx)
+}
+
+// reset resets session variables to those set when connecting. Connections
+// left in a transaction are not reused.
+func (c *PGConn) reset(ctx context.Context) error {
+	if status := c.PgConn().TxStatus(); status != 'I' {
+		return errors.Newf("connection has transaction status %c", status)
+	}
+	_, err := c.Exec(ctx, "RESET ALL")
+	return err
+}
+
 func (c *PGConn) ConnStr() string {
-	return c.connStr
+	return RedactConnStr(c.connStr)
 }
 
 func init() {
@@ -0,0 +1,305 @@
+package dbconn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	poolMaxConns = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "molt",
+		Subsystem: "dbconn",
+		Name:      "pool_max_conns",
+		Help:      "Maximum number of connections open to the database, or 0 if unlimited.",
+	}, []string{"conn"})
+	poolOpenConns = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "molt",
+		Subsystem: "dbconn",
+		Name:      "pool_open_conns",
+		Help:      "Number of connections open to the database, including idle connections.",
+	}, []string{"conn"})
+	poolIdleConns = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "molt",
+		Subsystem: "dbconn",
+		Name:      "pool_idle_conns",
+		Help:      "Number of idle connections kept open for reuse.",
+	}, []string{"conn"})
+	poolAcquireWaitSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "molt",
+		Subsystem: "dbconn",
+		Name:      "pool_acquire_wait_seconds",
+		Help:      "Time spent waiting for a connection as the maximum number of connections were open.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"conn"})
+	poolHealthCheckFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "molt",
+		Subsystem: "dbconn",
+		Name:      "pool_health_check_failures",
+		Help:      "Number of idle connections discarded as they failed a health check.",
+	}, []string{"conn"})
+)
+
+// PoolSettings configures the connections opened to a database.
+type PoolSettings struct {
+	// MaxConns is the maximum number of connections open to the database at
+	// once, including idle connections. Cloning a connection waits until a
+	// connection is available. Zero is unlimited, and it must otherwise be at
+	// least 2 as the connection the pool is created with stays open.
+	MaxConns int
+	// StatementTimeout aborts statements which run for longer than it. It
+	// applies to all statements on PostgreSQL and CockroachDB, and to SELECT
+	// statements on MySQL. Zero disables the timeout.
+	StatementTimeout time.Duration
+	// AcquireTimeout is how long cloning a connection waits for one to be
+	// available once MaxConns are open before failing. Zero waits
+	// indefinitely.
+	AcquireTimeout time.Duration
+}
+
+// poolableConn is a connection which can be kept in a pool.
+type poolableConn interface {
+	Conn
+	// open opens a new connection with the same arguments, which belongs to
+	// the same pool.
+	open(ctx context.Context) (poolableConn, error)
+	// closeConn closes the underlying connection.
+	closeConn(ctx context.Context) error
+	// healthCheck returns an error if an idle connection cannot be reused.
+	healthCheck(ctx context.Context) error
+	// reset resets the session state of a connection before it is idle.
+	reset(ctx context.Context) error
+}
+
+// pool limits the number of connections open to a database, and keeps
+// closed clones open to be reused. Clone on a pooled connection acquires a
+// connection from the pool, and Close releases it. Closing the connection
+// the pool was created with closes the pool.
+type pool struct {
+	id             ID
+	root           poolableConn
+	acquireTimeout time.Duration
+	// sem has a slot taken by the connection the pool was created with and
+	// by each connection in use, or is nil if the number of connections is
+	// unlimited. Idle connections do not take a slot, as a connection is
+	// only opened once there are no idle connections to reuse.
+	sem chan struct{}
+	mu  struct {
+		sync.Mutex
+		idle   []poolableConn
+		closed bool
+	}
+}
+
+// ConnectPool connects to a database, returning a connection whose clones
+// share a pool of connections configured by settings.
+func ConnectPool(
+	ctx context.Context, preferredID ID, connStr string, settings PoolSettings, opts ...ConnectOpt,
+) (Conn, error) {
+	if settings.MaxConns < 0 {
+		return nil, errors.Newf("maximum number of connections must not be negative")
+	}
+	if settings.MaxConns == 1 {
+		// The connection the pool is created with stays open, so a clone
+		// could never be acquired.
+		return nil, errors.Newf("maximum number of connections must be at least 2 to open a connection besides the first")
+	}
+	connStr, err := withStatementTimeout(connStr, settings.StatementTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := Connect(ctx, preferredID, connStr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := conn.(poolableConn)
+	if !ok {
+		return conn, nil
+	}
+	p := &pool{id: conn.ID(), root: root, acquireTimeout: settings.AcquireTimeout}
+	if settings.MaxConns > 0 {
+		p.sem = make(chan struct{}, settings.MaxConns)
+		// The connection the pool is created with takes a slot.
+		p.sem <- struct{}{}
+	}
+	switch c := conn.(type) {
+	case *PGConn:
+		c.pool = p
+	case *MySQLConn:
+		c.pool = p
+		if settings.MaxConns > 0 {
+			// Each MySQLConn is a database/sql pool, which would otherwise open
+			// connections beyond the limit.
+			c.SetMaxOpenConns(1)
+		}
+	case *OracleConn:
+		c.pool = p
+	}
+	poolMaxConns.WithLabelValues(string(p.id)).Set(float64(settings.MaxConns))
+	poolOpenConns.WithLabelValues(string(p.id)).Inc()
+	return conn, nil
+}
+
+// withStatementTimeout adds the session variable setting the statement
+// timeout to the connection string.
+func withStatementTimeout(connStr string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return connStr, nil
+	}
+	scheme := strings.SplitN(connStr, "://", 2)[0]
+	var param string
+	switch {
+	case strings.Contains(scheme, "postgres"):
+		param = "statement_timeout"
+	case strings.Contains(scheme, "mysql"):
+		param = "max_execution_time"
+	default:
+		return "", errors.Newf("statement timeouts are not supported for %s", RedactConnStr(connStr))
+	}
+	sep := "?"
+	if strings.Contains(connStr, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%s%s=%d", connStr, sep, param, timeout.Milliseconds()), nil
+}
+
+// acquire returns an idle connection which passes a health check, or opens
+// a new connection from the given one. It waits until a connection is
+// available if the maximum number of connections are in use, failing after
+// the acquire timeout if set.
+func (p *pool) acquire(ctx context.Context, from poolableConn) (Conn, error) {
+	if p.sem != nil {
+		start := time.Now()
+		var timeoutCh <-chan time.Time
+		if p.acquireTimeout > 0 {
+			timer := time.NewTimer(p.acquireTimeout)
+			defer timer.Stop()
+			timeoutCh = timer.C
+		}
+		select {
+		case p.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, errors.Wrapf(ctx.Err(), "error waiting for a connection to %s", p.id)
+		case <-timeoutCh:
+			return nil, errors.Newf(
+				"timed out after %s waiting for a connection to %s, as all %d connections are in use; increase the maximum number of connections",
+				p.acquireTimeout,
+				p.id,
+				cap(p.sem),
+			)
+		}
+		poolAcquireWaitSeconds.WithLabelValues(string(p.id)).Observe(time.Since(start).Seconds())
+	}
+	for {
+		c, ok := p.popIdle()
+		if !ok {
+			break
+		}
+		if err := c.healthCheck(ctx); err != nil {
+			poolHealthCheckFailures.WithLabelValues(string(p.id)).Inc()
+			_ = p.closeConn(ctx, c)
+			continue
+		}
+		return c, nil
+	}
+	c, err := from.open(ctx)
+	if err != nil {
+		p.releaseSlot()
+		return nil, err
+	}
+	poolOpenConns.WithLabelValues(string(p.id)).Inc()
+	return c, nil
+}
+
+// AvailableConns returns how many more connections can be cloned from conn
+// without waiting for a connection to be closed, or false if the number of
+// connections is unlimited.
+func AvailableConns(conn Conn) (int, bool) {
+	var p *pool
+	switch conn := conn.(type) {
+	case *PGConn:
+		p = conn.pool
+	case *MySQLConn:
+		p = conn.pool
+	case *OracleConn:
+		p = conn.pool
+	}
+	if p == nil || p.sem == nil {
+		return 0, false
+	}
+	return p.available(), true
+}
+
+func (p *pool) available() int {
+	return cap(p.sem) - len(p.sem)
+}
+
+func (p *pool) popIdle() (poolableConn, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.mu.idle) == 0 {
+		return nil, false
+	}
+	c := p.mu.idle[len(p.mu.idle)-1]
+	p.mu.idle = p.mu.idle[:len(p.mu.idle)-1]
+	poolIdleConns.WithLabelValues(string(p.id)).Dec()
+	return c, true
+}
+
+// release returns a connection in use to the pool to be reused, giving up
+// its slot. Releasing the connection the pool was created with closes the
+// pool.
+func (p *pool) release(ctx context.Context, c poolableConn) error {
+	if c == p.root {
+		return p.close(ctx)
+	}
+	defer p.releaseSlot()
+	if err := c.reset(ctx); err != nil {
+		_ = p.closeConn(ctx, c)
+		return nil
+	}
+	p.mu.Lock()
+	if p.mu.closed {
+		p.mu.Unlock()
+		return p.closeConn(ctx, c)
+	}
+	p.mu.idle = append(p.mu.idle, c)
+	poolIdleConns.WithLabelValues(string(p.id)).Inc()
+	p.mu.Unlock()
+	return nil
+}
+
+// close closes the idle connections and the connection the pool was created
+// with. Connections released afterwards are closed.
+func (p *pool) close(ctx context.Context) error {
+	p.mu.Lock()
+	idle := p.mu.idle
+	p.mu.idle = nil
+	p.mu.closed = true
+	p.mu.Unlock()
+	var err error
+	for _, c := range idle {
+		poolIdleConns.WithLabelValues(string(p.id)).Dec()
+		err = errors.CombineErrors(err, p.closeConn(ctx, c))
+	}
+	return errors.CombineErrors(err, p.closeConn(ctx, p.root))
+}
+
+// closeConn closes a connection. It does not give up a slot, which is done
+// by the caller if the connection was in use.
+func (p *pool) closeConn(ctx context.Context, c poolableConn) error {
+	poolOpenConns.WithLabelValues(string(p.id)).Dec()
+	return c.closeConn(ctx)
+}
+
+func (p *pool) releaseSlot() {
+	if p.sem != nil {
+		<-p.sem
+	}
+}
@@ -0,0 +1,141 @@
+package dbconn
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/go-sql-driver/mysql"
+)
+
+const redacted = "redacted"
+
+// sensitiveParams are connection string parameters which hold secrets.
+var sensitiveParams = map[string]struct{}{
+	"password":    {},
+	"sslpassword": {},
+}
+
+var (
+	// userinfoPattern matches the password of a connection string with
+	// userinfo which is not a valid URL, such as a MySQL DSN.
+	userinfoPattern = regexp.MustCompile(`^((?:[a-z0-9+.-]*://)?[^:@/]*):.*@`)
+	// paramPattern matches sensitive parameters of a connection string in
+	// the keyword/value form.
+	paramPattern = regexp.MustCompile(`(?i)\b(password|sslpassword)\s*=\s*('(?:[^'\\]|\\.)*'|\S+)`)
+)
+
+// RedactConnStr returns the connection string with its password and any
+// other secrets replaced, so that it can be logged or used in errors.
+func RedactConnStr(connStr string) string {
+	if u, err := url.Parse(connStr); err == nil && u.Scheme != "" && u.Host != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		q := u.Query()
+		for k := range q {
+			if _, ok := sensitiveParams[strings.ToLower(k)]; ok {
+				q.Set(k, redacted)
+			}
+		}
+		if len(q) > 0 {
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	connStr = userinfoPattern.ReplaceAllString(connStr, "$1:"+redacted+"@")
+	return paramPattern.ReplaceAllString(connStr, "$1="+redacted)
+}
+
+// redactedURLError returns the error of parsing a URL without the URL, which
+// may contain a password.
+func redactedURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// connStrPassword returns the password of the connection string, if any.
+func connStrPassword(connStr string) string {
+	if u, err := url.Parse(connStr); err == nil && u.Scheme != "" && u.Host != "" {
+		pw, _ := u.User.Password()
+		return pw
+	}
+	byProtocol := strings.SplitN(connStr, "://", 2)
+	if cfg, err := mysql.ParseDSN(byProtocol[len(byProtocol)-1]); err == nil {
+		return cfg.Passwd
+	}
+	return ""
+}
+
+// Password source prefixes, in the form <prefix>:<value>.
+const (
+	passwordFromEnv     = "env"
+	passwordFromFile    = "file"
+	passwordFromCommand = "command"
+)
+
+// ReadPassword reads a password from the given source, which is one of
+// env:<variable>, file:<path> or command:<shell command>. Trailing newlines
+// are removed from files and command output.
+func ReadPassword(ctx context.Context, from string) (string, error) {
+	kind, val, ok := strings.Cut(from, ":")
+	if !ok || val == "" {
+		// The source is not included in case a password was passed instead.
+		return "", errors.Newf("expected password source to be env:<variable>, file:<path> or command:<command>")
+	}
+	switch kind {
+	case passwordFromEnv:
+		pw, ok := os.LookupEnv(val)
+		if !ok {
+			return "", errors.Newf("environment variable %s is not set", val)
+		}
+		return pw, nil
+	case passwordFromFile:
+		b, err := os.ReadFile(val)
+		if err != nil {
+			return "", errors.Wrap(err, "error reading password file")
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	case passwordFromCommand:
+		cmd := exec.CommandContext(ctx, "sh", "-c", val)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			// The command is not included as it may contain secrets.
+			return "", errors.Wrapf(err, "error running password command: %s", strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimRight(string(out), "\r\n"), nil
+	}
+	return "", errors.Newf("unknown password source %q, expected env, file or command", kind)
+}
+
+// WithPassword returns the connection string with its password set.
+func WithPassword(connStr string, password string) (string, error) {
+	if u, err := url.Parse(connStr); err == nil && u.Scheme != "" && u.Host != "" {
+		if u.User == nil || u.User.Username() == "" {
+			return "", errors.Newf("connection string %s must have a user to set a password for", RedactConnStr(connStr))
+		}
+		u.User = url.UserPassword(u.User.Username(), password)
+		return u.String(), nil
+	}
+	// Connection strings which are not URLs can only be MySQL DSNs.
+	byProtocol := strings.SplitN(connStr, "://", 2)
+	if len(byProtocol) == 2 && !strings.Contains(byProtocol[0], "mysql") {
+		return "", errors.Newf("unable to set password of %s", RedactConnStr(connStr))
+	}
+	cfg, err := mysql.ParseDSN(byProtocol[len(byProtocol)-1])
+	if err != nil {
+		return "", errors.Newf("unable to set password of %s", RedactConnStr(connStr))
+	}
+	cfg.Passwd = password
+	return "mysql://" + cfg.FormatDSN(), nil
+}
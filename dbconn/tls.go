@@ -0,0 +1,224 @@
+package dbconn
+
+import (
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/thediveo/enumflag/v2"
+)
+
+// TLSMode is how a connection uses TLS, following the sslmode of PostgreSQL.
+type TLSMode enumflag.Flag
+
+const (
+	// TLSModeDefault uses the TLS settings of the connection string, unless
+	// a CA, certificate, key or server name is set, in which case it is
+	// TLSModeVerifyFull.
+	TLSModeDefault TLSMode = iota
+	// TLSModeDisable does not use TLS.
+	TLSModeDisable
+	// TLSModeRequire uses TLS without verifying the server certificate.
+	TLSModeRequire
+	// TLSModeVerifyCA uses TLS and verifies the server certificate is signed
+	// by a trusted CA.
+	TLSModeVerifyCA
+	// TLSModeVerifyFull uses TLS and verifies the server certificate is
+	// signed by a trusted CA and matches the server name.
+	TLSModeVerifyFull
+)
+
+var TLSModeStringRepresentations = map[TLSMode][]string{
+	TLSModeDefault:    {"default"},
+	TLSModeDisable:    {"disable"},
+	TLSModeRequire:    {"require"},
+	TLSModeVerifyCA:   {"verify-ca"},
+	TLSModeVerifyFull: {"verify-full"},
+}
+
+// TLSSettings configures TLS for a connection, overriding any TLS settings
+// of the connection string.
+type TLSSettings struct {
+	Mode TLSMode
+	// CAFile is a PEM file of the CAs trusted to sign the server
+	// certificate. The system CAs are trusted if unset.
+	CAFile string
+	// CertFile and KeyFile are PEM files of the client certificate and its
+	// key, if the server requires one.
+	CertFile string
+	KeyFile  string
+	// ServerName is the name the server certificate is verified against.
+	// It defaults to the host connected to.
+	ServerName string
+}
+
+// mode returns the mode the settings apply, which is TLSModeDefault if the
+// settings of the connection string are used.
+func (s TLSSettings) mode() TLSMode {
+	if s.Mode == TLSModeDefault && (s.CAFile != "" || s.CertFile != "" || s.KeyFile != "" || s.ServerName != "") {
+		return TLSModeVerifyFull
+	}
+	return s.Mode
+}
+
+// Config returns the TLS config for connecting to host, or nil if TLS is
+// disabled.
+func (s TLSSettings) Config(host string) (*tls.Config, error) {
+	mode := s.mode()
+	if mode == TLSModeDisable || mode == TLSModeDefault {
+		return nil, nil
+	}
+	if (s.CertFile == "") != (s.KeyFile == "") {
+		return nil, errors.Newf("a TLS certificate and key must be set together")
+	}
+	ret := &tls.Config{
+		ServerName: s.ServerName,
+	}
+	if ret.ServerName == "" {
+		ret.ServerName = host
+	}
+	if s.CAFile != "" {
+		ca, err := os.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading TLS CA")
+		}
+		ret.RootCAs = x509.NewCertPool()
+		if !ret.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.Newf("no certificates found in TLS CA %s", s.CAFile)
+		}
+	}
+	if s.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading TLS certificate")
+		}
+		ret.Certificates = []tls.Certificate{cert}
+	}
+	switch mode {
+	case TLSModeRequire:
+		ret.InsecureSkipVerify = true
+	case TLSModeVerifyCA:
+		// Verify the chain without the server name, as done by PostgreSQL.
+		ret.InsecureSkipVerify = true
+		roots := ret.RootCAs
+		ret.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(rawCerts, roots)
+		}
+	}
+	return ret, nil
+}
+
+func verifyChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("server did not present a certificate")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return errors.Wrap(err, "error parsing server certificate")
+		}
+		certs[i] = cert
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// applyPGTLS sets the TLS config of a PostgreSQL connection.
+func applyPGTLS(cfg *pgx.ConnConfig, s TLSSettings) error {
+	if s.mode() == TLSModeDefault {
+		return nil
+	}
+	tlsConfig, err := s.Config(cfg.Host)
+	if err != nil {
+		return err
+	}
+	cfg.TLSConfig = tlsConfig
+	// Fallbacks are only used by sslmode=prefer and allow, which are
+	// overridden.
+	cfg.Fallbacks = nil
+	return nil
+}
+
+// applyMySQLTLS sets the TLS config of a MySQL connection. The config is
+// registered with the driver under a name set in the DSN, so that it is
+// kept when the connection is cloned from its DSN.
+func applyMySQLTLS(id ID, cfg *mysql.Config, s TLSSettings) error {
+	switch s.mode() {
+	case TLSModeDefault:
+		return nil
+	case TLSModeDisable:
+		cfg.TLS = nil
+		cfg.TLSConfig = "false"
+		return nil
+	}
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		host = cfg.Addr
+	}
+	tlsConfig, err := s.Config(host)
+	if err != nil {
+		return err
+	}
+	hasher := sha1.New()
+	fmt.Fprintf(hasher, "%s\x00%s\x00%+v", id, cfg.Addr, s)
+	cfg.TLSConfig = "molt_" + hex.EncodeToString(hasher.Sum(nil))
+	cfg.TLS = tlsConfig
+	return mysql.RegisterTLSConfig(cfg.TLSConfig, tlsConfig)
+}
+
+// applyOracleTLS sets the TLS options of an Oracle connection string. The
+// driver only reads certificates from an Oracle wallet, set with the WALLET
+// option of the connection string, and always verifies the server name.
+func applyOracleTLS(connStr string, s TLSSettings) (string, error) {
+	mode := s.mode()
+	if mode == TLSModeDefault {
+		return connStr, nil
+	}
+	if s.CAFile != "" || s.CertFile != "" || s.KeyFile != "" || s.ServerName != "" {
+		return "", errors.Newf(
+			"TLS CAs, certificates, keys and server names are not supported for Oracle; set the WALLET option of the connection string to an Oracle wallet instead",
+		)
+	}
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return "", errors.Wrapf(redactedURLError(err), "unable to parse url: %s", RedactConnStr(connStr))
+	}
+	q := u.Query()
+	// The driver reads options case-insensitively.
+	for k := range q {
+		if upper := strings.ToUpper(k); upper == "SSL" || upper == "SSL VERIFY" {
+			q.Del(k)
+		}
+	}
+	switch mode {
+	case TLSModeDisable:
+		q.Set("SSL", "false")
+	case TLSModeRequire:
+		q.Set("SSL", "true")
+		q.Set("SSL VERIFY", "false")
+	case TLSModeVerifyCA:
+		return "", errors.Newf("TLS mode verify-ca is not supported for Oracle, which always verifies the server name")
+	case TLSModeVerifyFull:
+		q.Set("SSL", "true")
+		q.Set("SSL VERIFY", "true")
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,76 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/molt/dbconn"
+)
+
+// checkCollations checks strings are ordered and compared the same way on
+// the source and target. verify walks both tables in primary key order, so
+// string primary keys which sort differently are reported as missing and
+// extraneous rows.
+func checkCollations(ctx context.Context, r *Report, conns dbconn.OrderedConns) {
+	const name = "collation"
+	var collations [2]string
+	for i, conn := range conns {
+		c, err := databaseCollation(ctx, conn)
+		if err != nil {
+			r.fail(name, fmt.Sprintf("%s: %s", conn.ID(), err.Error()), "ensure the user can read the database collation")
+			return
+		}
+		collations[i] = c
+	}
+	if collations[0] == collations[1] ||
+		(isBinaryCollation(conns[0], collations[0]) && isBinaryCollation(conns[1], collations[1])) {
+		r.pass(name, "source collation %s matches target collation %s", collations[0], collations[1])
+		return
+	}
+	r.warn(
+		name,
+		fmt.Sprintf(
+			"source collation %s does not match target collation %s, so strings may sort and compare differently",
+			collations[0],
+			collations[1],
+		),
+		"use a binary collation (e.g. C on PostgreSQL, utf8mb4_bin on MySQL) for string primary keys, or expect verify to report inconsistencies on them",
+	)
+}
+
+func databaseCollation(ctx context.Context, conn dbconn.Conn) (string, error) {
+	var collation string
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		if conn.IsCockroach() {
+			// CockroachDB orders strings by their bytes unless a column has a
+			// COLLATE type.
+			return "C", nil
+		}
+		err := conn.QueryRow(
+			ctx,
+			"SELECT datcollate FROM pg_database WHERE datname = current_database()",
+		).Scan(&collation)
+		return collation, err
+	case *dbconn.MySQLConn:
+		err := conn.QueryRowContext(ctx, "SELECT @@collation_database").Scan(&collation)
+		return collation, err
+	}
+	return "", nil
+}
+
+// isBinaryCollation returns whether the collation orders strings by their
+// bytes.
+func isBinaryCollation(conn dbconn.Conn, collation string) bool {
+	switch conn.(type) {
+	case *dbconn.PGConn:
+		switch strings.ToLower(collation) {
+		case "c", "posix", "c.utf8", "c.utf-8", "ucs_basic":
+			return true
+		}
+	case *dbconn.MySQLConn:
+		return strings.HasSuffix(strings.ToLower(collation), "_bin")
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+// Package doctor checks that the source, target and store are set up for
+// fetch and verify before a migration is run.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/datablobstorage"
+	"github.com/cockroachdb/molt/molttelemetry"
+	"github.com/cockroachdb/molt/verify/dbverify"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/rs/zerolog"
+)
+
+// Config configures the checks.
+type Config struct {
+	// Store is the store fetch exports data to. Store checks are skipped if
+	// it is nil.
+	Store datablobstorage.Store
+	// PGReplicationSlot is the replication slot fetch creates, which
+	// requires logical replication to be set up on PostgreSQL sources.
+	PGReplicationSlot string
+	// Live is whether fetch loads data with the tables queryable, in which
+	// case CockroachDB reads data through molt rather than from the store.
+	Live bool
+}
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	// StatusWarn is the status of checks which found something which may
+	// cause problems, but does not stop fetch or verify from running.
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	// StatusSkip is the status of checks which do not apply.
+	StatusSkip Status = "skip"
+)
+
+// Check is the result of a check.
+type Check struct {
+	Name   string
+	Status Status
+	Detail string
+	// Fix is how to resolve a failing or warning check.
+	Fix string
+}
+
+// Report is the result of all checks.
+type Report struct {
+	Checks []Check
+}
+
+func (r *Report) add(c Check) {
+	r.Checks = append(r.Checks, c)
+}
+
+func (r *Report) pass(name string, detail string, args ...interface{}) {
+	r.add(Check{Name: name, Status: StatusPass, Detail: fmt.Sprintf(detail, args...)})
+}
+
+func (r *Report) skip(name string, detail string, args ...interface{}) {
+	r.add(Check{Name: name, Status: StatusSkip, Detail: fmt.Sprintf(detail, args...)})
+}
+
+func (r *Report) fail(name string, detail string, fix string) {
+	r.add(Check{Name: name, Status: StatusFail, Detail: detail, Fix: fix})
+}
+
+func (r *Report) warn(name string, detail string, fix string) {
+	r.add(Check{Name: name, Status: StatusWarn, Detail: detail, Fix: fix})
+}
+
+// Passed returns whether no checks failed.
+func (r Report) Passed() bool {
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Write writes the report in a human readable form, followed by how to fix
+// each failing or warning check.
+func (r Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tDETAIL")
+	var fails, warns int
+	for _, c := range r.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, c.Status, c.Detail)
+		switch c.Status {
+		case StatusFail:
+			fails++
+		case StatusWarn:
+			warns++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, c := range r.Checks {
+		if c.Fix != "" && (c.Status == StatusFail || c.Status == StatusWarn) {
+			fmt.Fprintf(&sb, "  %s (%s): %s\n", c.Name, c.Status, c.Fix)
+		}
+	}
+	if sb.Len() > 0 {
+		if _, err := fmt.Fprintf(w, "\nto fix:\n%s", sb.String()); err != nil {
+			return err
+		}
+	}
+	result := "PASS"
+	if fails > 0 {
+		result = "FAIL"
+	}
+	_, err := fmt.Fprintf(w, "\n%s: %d checks, %d failed, %d warnings\n", result, len(r.Checks), fails, warns)
+	return err
+}
+
+// Doctor runs all checks against the source, target and store. Errors from
+// individual checks are reported as failing checks rather than returned.
+func Doctor(
+	ctx context.Context,
+	cfg Config,
+	logger zerolog.Logger,
+	conns dbconn.OrderedConns,
+	tableFilter dbverify.FilterConfig,
+) (Report, error) {
+	if conns[0].IsCockroach() || conns[1].IsCockroach() {
+		if err := dbconn.RegisterTelemetry(conns); err != nil {
+			return Report{}, err
+		}
+		molttelemetry.ReportTelemetryAsync(logger, "molt_doctor_dialect_"+conns[0].Dialect())
+	}
+
+	var r Report
+	logger.Info().Msgf("checking source settings")
+	checkSourceSettings(ctx, &r, cfg, conns[0])
+
+	logger.Info().Msgf("checking tables")
+	tables := checkTables(ctx, &r, conns, tableFilter)
+
+	logger.Info().Msgf("checking privileges")
+	checkPrivileges(ctx, &r, conns, tables)
+
+	logger.Info().Msgf("checking collations")
+	checkCollations(ctx, &r, conns)
+
+	logger.Info().Msgf("checking store")
+	checkStore(ctx, &r, cfg, conns[1])
+	return r, nil
+}
+
+// checkTables checks that the tables on the source and target can be fetched
+// and verified, returning the tables on both.
+func checkTables(
+	ctx context.Context, r *Report, conns dbconn.OrderedConns, tableFilter dbverify.FilterConfig,
+) []dbtable.VerifiedTable {
+	const name = "tables"
+	dbTables, err := dbverify.Verify(ctx, conns)
+	if err == nil {
+		dbTables, err = dbverify.FilterResult(tableFilter, dbTables)
+	}
+	if err != nil {
+		r.fail(name, err.Error(), "check the --table-filter and --schema-filter flags")
+		return nil
+	}
+	for _, tbl := range dbTables.MissingTables {
+		r.warn(
+			name+": "+tbl.SafeString(),
+			"table is missing on the target",
+			"create the table on the target, or exclude it with --table-filter",
+		)
+	}
+	for _, tbl := range dbTables.ExtraneousTables {
+		r.warn(
+			name+": "+tbl.SafeString(),
+			"table is missing on the source",
+			"exclude the table with --table-filter if it is not being migrated",
+		)
+	}
+	results, err := tableverify.VerifyCommonTables(ctx, conns, dbTables.Verified, tableverify.ColumnFilter{})
+	if err != nil {
+		r.fail(name, err.Error(), "ensure both databases can be read from")
+		return nil
+	}
+	if len(results) == 0 {
+		r.fail(name, "no tables found on both the source and target", "create the tables on the target before running fetch")
+		return nil
+	}
+	var ret []dbtable.VerifiedTable
+	var compatible int
+	for _, res := range results {
+		ret = append(ret, res.VerifiedTable)
+		var infos []string
+		for _, m := range res.MismatchingTableDefinitions {
+			infos = append(infos, m.Info)
+		}
+		switch {
+		case !res.RowVerifiable:
+			r.fail(
+				name+": "+res.SafeString(),
+				strings.Join(infos, "; "),
+				"alter the table on the target to match the source",
+			)
+		case len(infos) > 0:
+			r.warn(
+				name+": "+res.SafeString(),
+				strings.Join(infos, "; "),
+				"alter the table on the target to match the source, or exclude the columns from verify",
+			)
+		default:
+			compatible++
+		}
+	}
+	if compatible == len(results) {
+		r.pass(name, "all %d tables are compatible", len(results))
+	}
+	return ret
+}
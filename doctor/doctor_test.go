@@ -0,0 +1,72 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/fetch/datablobstorage"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportWrite(t *testing.T) {
+	var r Report
+	r.pass("wal_level", "wal_level is logical")
+	r.fail("gtid_mode", "gtid_mode is OFF", "set gtid_mode = ON")
+	r.warn("collation", "collations differ", "use a binary collation")
+	r.skip("store round-trip", "no store is configured")
+	require.False(t, r.Passed())
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf))
+	require.Equal(t, `CHECK             STATUS  DETAIL
+wal_level         pass    wal_level is logical
+gtid_mode         fail    gtid_mode is OFF
+collation         warn    collations differ
+store round-trip  skip    no store is configured
+
+to fix:
+  gtid_mode (fail): set gtid_mode = ON
+  collation (warn): use a binary collation
+
+FAIL: 4 checks, 1 failed, 1 warnings
+`, buf.String())
+}
+
+func TestCheckStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := datablobstorage.NewLocalStore(zerolog.Nop(), dir, "", "")
+	require.NoError(t, err)
+
+	var r Report
+	checkStore(context.Background(), &r, Config{Store: store}, &dbconn.MySQLConn{})
+	require.True(t, r.Passed())
+	require.Equal(t, []Status{StatusSkip, StatusPass}, []Status{r.Checks[0].Status, r.Checks[1].Status})
+
+	// The probe is deleted.
+	entries, err := os.ReadDir(filepath.Join(dir, probeTable.SafeString()))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestIsBinaryCollation(t *testing.T) {
+	for _, tc := range []struct {
+		conn      dbconn.Conn
+		collation string
+		expected  bool
+	}{
+		{conn: &dbconn.PGConn{}, collation: "C", expected: true},
+		{conn: &dbconn.PGConn{}, collation: "C.UTF-8", expected: true},
+		{conn: &dbconn.PGConn{}, collation: "en_US.UTF-8", expected: false},
+		{conn: &dbconn.MySQLConn{}, collation: "utf8mb4_bin", expected: true},
+		{conn: &dbconn.MySQLConn{}, collation: "utf8mb4_0900_ai_ci", expected: false},
+	} {
+		t.Run(tc.collation, func(t *testing.T) {
+			require.Equal(t, tc.expected, isBinaryCollation(tc.conn, tc.collation))
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/mysqlconv"
+	"github.com/go-sql-driver/mysql"
+)
+
+// checkPrivileges checks the source user can read and the target user can
+// write to each table.
+func checkPrivileges(
+	ctx context.Context, r *Report, conns dbconn.OrderedConns, tables []dbtable.VerifiedTable,
+) {
+	if len(tables) == 0 {
+		r.skip("privileges", "no tables to check")
+		return
+	}
+	for i, check := range []struct {
+		privilege string
+		fix       string
+	}{
+		{privilege: "SELECT", fix: "run GRANT SELECT ON <tables> TO <user> on the source"},
+		{privilege: "INSERT", fix: "run GRANT INSERT ON <tables> TO <user> on the target"},
+	} {
+		conn := conns[i]
+		name := fmt.Sprintf("%s %s privilege", conn.ID(), check.privilege)
+		var missing []string
+		for _, table := range tables {
+			ok, err := hasPrivilege(ctx, conn, table, check.privilege)
+			if err != nil {
+				r.fail(name, fmt.Sprintf("%s: %s", table.SafeString(), err.Error()), check.fix)
+				return
+			}
+			if !ok {
+				missing = append(missing, table.SafeString())
+			}
+		}
+		if len(missing) > 0 {
+			r.fail(
+				name,
+				fmt.Sprintf("missing on %d tables: %s", len(missing), strings.Join(missing, ", ")),
+				check.fix,
+			)
+			continue
+		}
+		r.pass(name, "granted on all %d tables", len(tables))
+	}
+}
+
+// hasPrivilege returns whether the user of conn has the SELECT or INSERT
+// privilege on table.
+func hasPrivilege(
+	ctx context.Context, conn dbconn.Conn, table dbtable.VerifiedTable, privilege string,
+) (bool, error) {
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		var ok bool
+		err := conn.QueryRow(
+			ctx,
+			"SELECT has_table_privilege($1, $2)",
+			tree.AsString(table.NewTableName()),
+			privilege,
+		).Scan(&ok)
+		return ok, err
+	case *dbconn.MySQLConn:
+		// MySQL has no function to check privileges which accounts for every
+		// level they can be granted at, so run a statement which touches no
+		// rows instead.
+		tableName := mysqlconv.QuoteIdent(string(table.Table))
+		switch privilege {
+		case "SELECT":
+			rows, err := conn.QueryContext(ctx, "SELECT 1 FROM "+tableName+" LIMIT 0")
+			if err != nil {
+				return isMySQLAccessDenied(err)
+			}
+			return true, rows.Close()
+		case "INSERT":
+			cols := make([]string, len(table.Columns))
+			for i, col := range table.Columns {
+				cols[i] = mysqlconv.QuoteIdent(string(col))
+			}
+			colList := strings.Join(cols, ", ")
+			tx, err := conn.BeginTx(ctx, nil)
+			if err != nil {
+				return false, err
+			}
+			_, err = tx.ExecContext(
+				ctx,
+				fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE 1 = 0", tableName, colList, colList, tableName),
+			)
+			if rollbackErr := tx.Rollback(); rollbackErr != nil && err == nil {
+				return false, rollbackErr
+			}
+			if err != nil {
+				return isMySQLAccessDenied(err)
+			}
+			return true, nil
+		}
+		return false, errors.AssertionFailedf("unknown privilege %s", privilege)
+	}
+	return false, errors.AssertionFailedf("unknown conn type: %T", conn)
+}
+
+// isMySQLAccessDenied returns false if err is an error denying access to a
+// table, and err otherwise.
+func isMySQLAccessDenied(err error) (bool, error) {
+	// ER_TABLEACCESS_DENIED_ERROR and ER_COLUMNACCESS_DENIED_ERROR.
+	if mysqlErr := (*mysql.MySQLError)(nil); errors.As(err, &mysqlErr) &&
+		(mysqlErr.Number == 1142 || mysqlErr.Number == 1143) {
+		return false, nil
+	}
+	return false, err
+}
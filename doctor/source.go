@@ -0,0 +1,147 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/molt/dbconn"
+)
+
+// checkSourceSettings checks the source is set up for fetch to record a CDC
+// cursor which replication can start from.
+func checkSourceSettings(ctx context.Context, r *Report, cfg Config, conn dbconn.Conn) {
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		if conn.IsCockroach() {
+			r.skip("source settings", "no settings are required for a CockroachDB source")
+			return
+		}
+		checkPGSourceSettings(ctx, r, cfg, conn)
+	case *dbconn.MySQLConn:
+		checkMySQLSourceSettings(ctx, r, conn)
+	}
+}
+
+func checkPGSourceSettings(ctx context.Context, r *Report, cfg Config, conn *dbconn.PGConn) {
+	// Replication needs logical decoding, but fetch can run without it unless
+	// it creates a replication slot.
+	failOrWarn := r.warn
+	if cfg.PGReplicationSlot != "" {
+		failOrWarn = r.fail
+	}
+
+	var walLevel string
+	if err := conn.QueryRow(ctx, "SHOW wal_level").Scan(&walLevel); err != nil {
+		r.fail("wal_level", err.Error(), "ensure the source user can read settings")
+	} else if walLevel != "logical" {
+		failOrWarn(
+			"wal_level",
+			fmt.Sprintf("wal_level is %s, logical replication requires logical", walLevel),
+			"set wal_level = logical in postgresql.conf and restart PostgreSQL",
+		)
+	} else {
+		r.pass("wal_level", "wal_level is logical")
+	}
+
+	var canReplicate bool
+	if err := conn.QueryRow(
+		ctx,
+		"SELECT rolreplication OR rolsuper FROM pg_roles WHERE rolname = current_user",
+	).Scan(&canReplicate); err != nil {
+		r.fail("replication privilege", err.Error(), "ensure the source user can read pg_roles")
+	} else if !canReplicate {
+		failOrWarn(
+			"replication privilege",
+			"the source user cannot call pg_create_logical_replication_slot",
+			"run ALTER ROLE <user> WITH REPLICATION on the source",
+		)
+	} else {
+		r.pass("replication privilege", "the source user has the REPLICATION privilege")
+	}
+
+	if cfg.PGReplicationSlot == "" {
+		return
+	}
+	var free int
+	if err := conn.QueryRow(
+		ctx,
+		"SELECT current_setting('max_replication_slots')::INT8 - (SELECT count(*) FROM pg_replication_slots)",
+	).Scan(&free); err != nil {
+		r.fail("replication slots", err.Error(), "ensure the source user can read pg_replication_slots")
+		return
+	}
+	var exists bool
+	if err := conn.QueryRow(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1)",
+		cfg.PGReplicationSlot,
+	).Scan(&exists); err != nil {
+		r.fail("replication slots", err.Error(), "ensure the source user can read pg_replication_slots")
+		return
+	}
+	switch {
+	case exists:
+		r.warn(
+			"replication slots",
+			fmt.Sprintf("replication slot %q already exists", cfg.PGReplicationSlot),
+			"use a different --pg-logical-replication-slot-name, or set --pg-logical-replication-slot-drop-if-exists",
+		)
+	case free <= 0:
+		r.fail(
+			"replication slots",
+			"all replication slots are in use",
+			"increase max_replication_slots, or drop unused replication slots",
+		)
+	default:
+		r.pass("replication slots", "%d replication slots are free", free)
+	}
+}
+
+func checkMySQLSourceSettings(ctx context.Context, r *Report, conn *dbconn.MySQLConn) {
+	var gtidMode, binlogFormat string
+	if err := conn.QueryRowContext(
+		ctx,
+		"SELECT @@GLOBAL.gtid_mode, @@GLOBAL.binlog_format",
+	).Scan(&gtidMode, &binlogFormat); err != nil {
+		r.fail("gtid_mode", err.Error(), "ensure the source user can read global variables")
+		return
+	}
+	if !strings.EqualFold(gtidMode, "ON") {
+		r.fail(
+			"gtid_mode",
+			fmt.Sprintf("gtid_mode is %s, fetch records a GTID as the CDC cursor", gtidMode),
+			"set gtid_mode = ON and enforce_gtid_consistency = ON on the source",
+		)
+	} else {
+		r.pass("gtid_mode", "gtid_mode is ON")
+	}
+	if !strings.EqualFold(binlogFormat, "ROW") {
+		r.fail(
+			"binlog_format",
+			fmt.Sprintf("binlog_format is %s, replication requires ROW", binlogFormat),
+			"set binlog_format = ROW on the source",
+		)
+	} else {
+		r.pass("binlog_format", "binlog_format is ROW")
+	}
+
+	// fetch reads the CDC cursor from mysql.gtid_executed, which is only
+	// written to once a transaction has been logged with GTIDs.
+	var numGTIDs int
+	if err := conn.QueryRowContext(ctx, "SELECT count(*) FROM mysql.gtid_executed").Scan(&numGTIDs); err != nil {
+		r.fail(
+			"gtid_executed",
+			err.Error(),
+			"run GRANT SELECT ON mysql.gtid_executed TO <user> on the source",
+		)
+	} else if numGTIDs == 0 {
+		r.fail(
+			"gtid_executed",
+			"mysql.gtid_executed is empty, so fetch cannot record a CDC cursor",
+			"run a write on the source after enabling gtid_mode, or FLUSH BINARY LOGS to persist gtid_executed",
+		)
+	} else {
+		r.pass("gtid_executed", "mysql.gtid_executed has %d entries", numGTIDs)
+	}
+}
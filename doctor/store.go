@@ -0,0 +1,126 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/datablobstorage"
+)
+
+// probeTable is the table the store probe is written as, and which the
+// target imports the probe into.
+var probeTable = dbtable.VerifiedTable{
+	Name:              dbtable.Name{Schema: "public", Table: "_molt_doctor_probe"},
+	PrimaryKeyColumns: []tree.Name{"id"},
+	Columns:           []tree.Name{"id", "v"},
+}
+
+const probeData = "1,molt doctor\n"
+
+// checkStore checks the store can be written to, read from and deleted
+// from, and that the target can read from it.
+func checkStore(ctx context.Context, r *Report, cfg Config, target dbconn.Conn) {
+	const roundTrip = "store round-trip"
+	const reachability = "target to store"
+	if cfg.Store == nil {
+		r.skip(roundTrip, "no store is configured (--s3-bucket, --gcp-bucket, --local-path or --direct-copy)")
+		r.skip(reachability, "no store is configured")
+		return
+	}
+	if !cfg.Store.CanBeTarget() {
+		r.skip(roundTrip, "direct copy writes data to the target without a store")
+		r.skip(reachability, "direct copy writes data to the target without a store")
+		return
+	}
+
+	const fix = "ensure the credentials used by molt can write, read and delete objects in the store"
+	resource, err := cfg.Store.CreateFromReader(ctx, strings.NewReader(probeData), probeTable, 0, "csv")
+	if err != nil {
+		r.fail(roundTrip, "error writing: "+err.Error(), fix)
+		r.skip(reachability, "the store cannot be written to")
+		return
+	}
+	readErr := readProbe(ctx, resource)
+
+	pgConn, ok := target.(*dbconn.PGConn)
+	switch {
+	case !ok || !pgConn.IsCockroach():
+		r.skip(reachability, "data is loaded into %s through molt", target.Dialect())
+	case cfg.Live:
+		r.skip(reachability, "data is loaded with COPY through molt in live mode")
+	default:
+		if err := importProbe(ctx, pgConn, resource); err != nil {
+			r.fail(
+				reachability,
+				err.Error(),
+				"ensure CockroachDB can reach the store; for --local-path, set --local-path-listen-addr and "+
+					"--local-path-crdb-access-addr to an address every CockroachDB node can connect to",
+			)
+		} else {
+			r.pass(reachability, "imported %s into the target", probeTable.SafeString())
+		}
+	}
+
+	switch err := resource.MarkForCleanup(ctx); {
+	case readErr != nil:
+		r.fail(roundTrip, "error reading: "+readErr.Error(), fix)
+	case err != nil:
+		r.fail(roundTrip, "error deleting: "+err.Error(), fix)
+	default:
+		r.pass(roundTrip, "wrote, read and deleted a file for %s", probeTable.SafeString())
+	}
+}
+
+func readProbe(ctx context.Context, resource datablobstorage.Resource) error {
+	rc, err := resource.Reader(ctx)
+	if err != nil {
+		return err
+	}
+	b, err := io.ReadAll(rc)
+	if err := errors.CombineErrors(err, rc.Close()); err != nil {
+		return err
+	}
+	if string(b) != probeData {
+		return errors.Newf("read %q, expected %q", b, probeData)
+	}
+	return nil
+}
+
+// importProbe imports the resource into a table on the target, which is
+// dropped afterwards.
+func importProbe(
+	ctx context.Context, conn *dbconn.PGConn, resource datablobstorage.Resource,
+) error {
+	u, err := resource.ImportURL()
+	if err != nil {
+		return err
+	}
+	tableName := tree.AsString(probeTable.NewTableName())
+	if _, err := conn.Exec(
+		ctx,
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id INT8 PRIMARY KEY, v STRING)", tableName),
+	); err != nil {
+		return errors.Wrap(err, "error creating probe table on target")
+	}
+	importInto := &tree.Import{
+		Into:       true,
+		Table:      probeTable.NewTableName(),
+		FileFormat: "CSV",
+		IntoCols:   probeTable.Columns,
+		Files:      tree.Exprs{tree.NewStrVal(u)},
+	}
+	_, err = conn.Exec(ctx, tree.AsString(importInto))
+	if err != nil {
+		err = errors.Wrapf(err, "error importing from %s", u)
+	}
+	if _, dropErr := conn.Exec(ctx, "DROP TABLE IF EXISTS "+tableName); dropErr != nil {
+		err = errors.CombineErrors(err, errors.Wrap(dropErr, "error dropping probe table on target"))
+	}
+	return err
+}
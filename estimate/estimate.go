@@ -0,0 +1,334 @@
+// Package estimate estimates the size and duration of fetching a database.
+package estimate
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/dataexport"
+	"github.com/cockroachdb/molt/molttelemetry"
+	"github.com/cockroachdb/molt/verify/dbverify"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/rs/zerolog"
+)
+
+// Config configures the estimate.
+type Config struct {
+	// Concurrency is the number of tables fetched at a time.
+	Concurrency int
+	// SampleRows is the number of rows of each table exported to benchmark
+	// the export. The benchmark is skipped if it is zero.
+	SampleRows int
+}
+
+// TableEstimate is the estimate for a single table.
+type TableEstimate struct {
+	dbtable.Name
+	// Rows is the number of rows, or -1 if unknown.
+	Rows int64
+	// DiskBytes is the size of the table including indexes on the source,
+	// or -1 if unknown.
+	DiskBytes int64
+	// ExportBytes is the estimated size of the exported CSV, or -1 if
+	// unknown.
+	ExportBytes int64
+	// CompressedBytes is the estimated size of the exported CSV once
+	// compressed with gzip, or -1 if unknown. It is only estimated by the
+	// benchmark.
+	CompressedBytes int64
+	// ExportDuration is the estimated time to export the table, or zero if
+	// unknown. It is only estimated by the benchmark.
+	ExportDuration time.Duration
+	// Sampled is whether the estimate is based on the benchmark.
+	Sampled bool
+}
+
+// Result is the estimate for all tables.
+type Result struct {
+	Tables      []TableEstimate
+	Concurrency int
+	// Duration is the estimated time to fetch all tables, or zero if the
+	// benchmark was skipped.
+	Duration time.Duration
+}
+
+// Estimate estimates the size of exporting each table found on both the
+// source and target, using catalog statistics of the source and optionally
+// a sampled export of each table.
+func Estimate(
+	ctx context.Context,
+	cfg Config,
+	logger zerolog.Logger,
+	conns dbconn.OrderedConns,
+	tableFilter dbverify.FilterConfig,
+) (Result, error) {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if conns[0].IsCockroach() || conns[1].IsCockroach() {
+		if err := dbconn.RegisterTelemetry(conns); err != nil {
+			return Result{}, err
+		}
+		molttelemetry.ReportTelemetryAsync(logger, "molt_estimate_dialect_"+conns[0].Dialect())
+	}
+	dbTables, err := dbverify.Verify(ctx, conns)
+	if err != nil {
+		return Result{}, err
+	}
+	if dbTables, err = dbverify.FilterResult(tableFilter, dbTables); err != nil {
+		return Result{}, err
+	}
+	for _, tbl := range dbTables.ExtraneousTables {
+		logger.Warn().
+			Str("table", tbl.SafeString()).
+			Msgf("ignoring table as it is missing a definition on the source")
+	}
+	for _, tbl := range dbTables.MissingTables {
+		logger.Warn().
+			Str("table", tbl.SafeString()).
+			Msgf("ignoring table as it is missing a definition on the target")
+	}
+	tables, err := tableverify.VerifyCommonTables(ctx, conns, dbTables.Verified, tableverify.ColumnFilter{})
+	if err != nil {
+		return Result{}, err
+	}
+
+	ret := Result{Concurrency: cfg.Concurrency}
+	for _, table := range tables {
+		stats, err := catalogStats(ctx, conns[0], table.Name)
+		if err != nil {
+			return Result{}, err
+		}
+		var s *sample
+		if cfg.SampleRows > 0 {
+			logger.Info().
+				Str("table", table.SafeString()).
+				Int("num_rows", cfg.SampleRows).
+				Msgf("sampling export")
+			if s, err = sampleExport(ctx, conns[0], table.VerifiedTable, cfg.SampleRows); err != nil {
+				return Result{}, err
+			}
+		}
+		est := estimateTable(table.Name, stats, s)
+		logger.Info().
+			Str("table", table.SafeString()).
+			Int64("rows", est.Rows).
+			Int64("export_bytes", est.ExportBytes).
+			Msgf("estimated table")
+		ret.Tables = append(ret.Tables, est)
+	}
+	if cfg.SampleRows > 0 {
+		durations := make([]time.Duration, len(ret.Tables))
+		for i, t := range ret.Tables {
+			durations[i] = t.ExportDuration
+		}
+		ret.Duration = scheduleDuration(durations, cfg.Concurrency)
+	}
+	return ret, nil
+}
+
+// sample is the result of exporting a sample of a table.
+type sample struct {
+	rows            int64
+	exportBytes     int64
+	compressedBytes int64
+	elapsed         time.Duration
+	// complete is whether the sample contains every row of the table.
+	complete bool
+}
+
+func sampleExport(
+	ctx context.Context, conn dbconn.Conn, table dbtable.VerifiedTable, numRows int,
+) (*sample, error) {
+	var exported, compressed countingWriter
+	gw := gzip.NewWriter(&compressed)
+	start := time.Now()
+	n, err := dataexport.ExportSample(ctx, conn, io.MultiWriter(&exported, gw), table, numRows)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error sampling %s", table.SafeString())
+	}
+	elapsed := time.Since(start)
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return &sample{
+		rows:            int64(n),
+		exportBytes:     exported.n,
+		compressedBytes: compressed.n,
+		elapsed:         elapsed,
+		complete:        n < numRows,
+	}, nil
+}
+
+// estimateTable extrapolates the estimate of a table from its catalog
+// statistics and sample. Without a sample, the size of the table's rows on
+// disk is used as the export size.
+func estimateTable(name dbtable.Name, stats tableStats, s *sample) TableEstimate {
+	ret := TableEstimate{
+		Name:            name,
+		Rows:            stats.Rows,
+		DiskBytes:       stats.TotalBytes,
+		ExportBytes:     stats.DataBytes,
+		CompressedBytes: -1,
+	}
+	if s == nil {
+		return ret
+	}
+	ret.Sampled = true
+	if s.complete {
+		ret.Rows = s.rows
+		ret.ExportBytes = s.exportBytes
+		ret.CompressedBytes = s.compressedBytes
+		ret.ExportDuration = s.elapsed
+		return ret
+	}
+	bytesPerRow := float64(s.exportBytes) / float64(s.rows)
+	if ret.Rows < 0 {
+		if stats.DataBytes < 0 {
+			ret.ExportBytes = -1
+			return ret
+		}
+		// Without a row count, assume rows take as much space on disk as
+		// they do exported.
+		ret.Rows = int64(float64(stats.DataBytes) / bytesPerRow)
+	}
+	ratio := float64(ret.Rows) / float64(s.rows)
+	ret.ExportBytes = int64(float64(s.exportBytes) * ratio)
+	ret.CompressedBytes = int64(float64(s.compressedBytes) * ratio)
+	ret.ExportDuration = time.Duration(float64(s.elapsed) * ratio)
+	return ret
+}
+
+// scheduleDuration returns how long it takes to run tasks of the given
+// durations with the given concurrency, with each task assigned to the
+// least busy worker in descending order of duration, as a table is fetched
+// by a single worker.
+func scheduleDuration(durations []time.Duration, concurrency int) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+	workers := make([]time.Duration, concurrency)
+	for _, d := range sorted {
+		minIdx := 0
+		for i := range workers {
+			if workers[i] < workers[minIdx] {
+				minIdx = i
+			}
+		}
+		workers[minIdx] += d
+	}
+	var ret time.Duration
+	for _, w := range workers {
+		if w > ret {
+			ret = w
+		}
+	}
+	return ret
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// Write writes the estimate in a human readable form.
+func (r Result) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tROWS\tDISK SIZE\tEXPORT SIZE\tCOMPRESSED SIZE\tEXPORT TIME")
+	total := TableEstimate{}
+	var unknownRows, unknownExport, unknownCompressed bool
+	for _, t := range r.Tables {
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.SafeString(),
+			formatCount(t.Rows),
+			formatBytes(t.DiskBytes),
+			formatBytes(t.ExportBytes),
+			formatBytes(t.CompressedBytes),
+			formatDuration(t.ExportDuration, t.Sampled && t.ExportBytes >= 0),
+		)
+		total.Rows, unknownRows = addKnown(total.Rows, t.Rows, unknownRows)
+		total.DiskBytes, _ = addKnown(total.DiskBytes, t.DiskBytes, false)
+		total.ExportBytes, unknownExport = addKnown(total.ExportBytes, t.ExportBytes, unknownExport)
+		total.CompressedBytes, unknownCompressed = addKnown(total.CompressedBytes, t.CompressedBytes, unknownCompressed)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n%d tables\n", len(r.Tables))
+	fmt.Fprintf(w, "rows: %s%s\n", formatCount(total.Rows), partial(unknownRows))
+	fmt.Fprintf(w, "export size: %s%s\n", formatBytes(total.ExportBytes), partial(unknownExport))
+	if len(r.Tables) > 0 && r.Tables[0].Sampled {
+		fmt.Fprintf(w, "compressed size (gzip): %s%s\n", formatBytes(total.CompressedBytes), partial(unknownCompressed))
+		_, err := fmt.Fprintf(
+			w,
+			"fetch duration with concurrency %d: %s (export only, excluding loading into the target)\n",
+			r.Concurrency,
+			formatDuration(r.Duration, true),
+		)
+		return err
+	}
+	_, err := fmt.Fprintln(w, "compressed size and fetch duration are only estimated with a sampled export (--sample-rows)")
+	return err
+}
+
+// addKnown adds v to total if v is known, returning whether any value so far
+// was unknown.
+func addKnown(total int64, v int64, unknown bool) (int64, bool) {
+	if v < 0 {
+		return total, true
+	}
+	return total + v, unknown
+}
+
+func partial(unknown bool) string {
+	if unknown {
+		return " (excluding tables without statistics)"
+	}
+	return ""
+}
+
+func formatCount(n int64) string {
+	if n < 0 {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func formatBytes(n int64) string {
+	if n < 0 {
+		return "unknown"
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatDuration(d time.Duration, known bool) string {
+	if !known {
+		return "unknown"
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,123 @@
+package estimate
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateTable(t *testing.T) {
+	name := dbtable.Name{Schema: "public", Table: "t"}
+	for _, tc := range []struct {
+		desc     string
+		stats    tableStats
+		sample   *sample
+		expected TableEstimate
+	}{
+		{
+			desc:  "no sample",
+			stats: tableStats{Rows: 1000, DataBytes: 8192, TotalBytes: 16384},
+			expected: TableEstimate{
+				Name:            name,
+				Rows:            1000,
+				DiskBytes:       16384,
+				ExportBytes:     8192,
+				CompressedBytes: -1,
+			},
+		},
+		{
+			desc:   "extrapolated sample",
+			stats:  tableStats{Rows: 1000, DataBytes: 8192, TotalBytes: 16384},
+			sample: &sample{rows: 100, exportBytes: 500, compressedBytes: 100, elapsed: time.Second},
+			expected: TableEstimate{
+				Name:            name,
+				Rows:            1000,
+				DiskBytes:       16384,
+				ExportBytes:     5000,
+				CompressedBytes: 1000,
+				ExportDuration:  10 * time.Second,
+				Sampled:         true,
+			},
+		},
+		{
+			desc:   "complete sample",
+			stats:  tableStats{Rows: 1000, DataBytes: 8192, TotalBytes: 16384},
+			sample: &sample{rows: 10, exportBytes: 50, compressedBytes: 30, elapsed: time.Millisecond, complete: true},
+			expected: TableEstimate{
+				Name:            name,
+				Rows:            10,
+				DiskBytes:       16384,
+				ExportBytes:     50,
+				CompressedBytes: 30,
+				ExportDuration:  time.Millisecond,
+				Sampled:         true,
+			},
+		},
+		{
+			desc:   "unknown row count",
+			stats:  tableStats{Rows: -1, DataBytes: 10000, TotalBytes: -1},
+			sample: &sample{rows: 100, exportBytes: 1000, compressedBytes: 200, elapsed: time.Second},
+			expected: TableEstimate{
+				Name:            name,
+				Rows:            1000,
+				DiskBytes:       -1,
+				ExportBytes:     10000,
+				CompressedBytes: 2000,
+				ExportDuration:  10 * time.Second,
+				Sampled:         true,
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, estimateTable(name, tc.stats, tc.sample))
+		})
+	}
+}
+
+func TestScheduleDuration(t *testing.T) {
+	durations := []time.Duration{1 * time.Minute, 5 * time.Minute, 2 * time.Minute, 2 * time.Minute}
+	require.Equal(t, 10*time.Minute, scheduleDuration(durations, 1))
+	require.Equal(t, 5*time.Minute, scheduleDuration(durations, 2))
+	require.Equal(t, 5*time.Minute, scheduleDuration(durations, 8))
+	require.Equal(t, time.Duration(0), scheduleDuration(nil, 4))
+}
+
+func TestResultWrite(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Result{
+		Tables: []TableEstimate{
+			{
+				Name:            dbtable.Name{Schema: "public", Table: "a"},
+				Rows:            1000,
+				DiskBytes:       3 << 20,
+				ExportBytes:     1536,
+				CompressedBytes: 512,
+				ExportDuration:  1500 * time.Millisecond,
+				Sampled:         true,
+			},
+			{
+				Name:            dbtable.Name{Schema: "public", Table: "b"},
+				Rows:            -1,
+				DiskBytes:       -1,
+				ExportBytes:     -1,
+				CompressedBytes: -1,
+				Sampled:         true,
+			},
+		},
+		Concurrency: 4,
+		Duration:    1500 * time.Millisecond,
+	}.Write(&buf))
+	require.Equal(t, `TABLE     ROWS     DISK SIZE  EXPORT SIZE  COMPRESSED SIZE  EXPORT TIME
+public.a  1000     3.0 MiB    1.5 KiB      512 B            2s
+public.b  unknown  unknown    unknown      unknown          unknown
+
+2 tables
+rows: 1000 (excluding tables without statistics)
+export size: 1.5 KiB (excluding tables without statistics)
+compressed size (gzip): 512 B (excluding tables without statistics)
+fetch duration with concurrency 4: 2s (export only, excluding loading into the target)
+`, buf.String())
+}
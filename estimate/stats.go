@@ -0,0 +1,100 @@
+package estimate
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+)
+
+// tableStats are the statistics of a table kept by the database catalog.
+type tableStats struct {
+	// Rows is the estimated number of rows, or -1 if unknown.
+	Rows int64
+	// DataBytes is the size of the table's rows on disk, excluding indexes,
+	// or -1 if unknown.
+	DataBytes int64
+	// TotalBytes is the size of the table on disk including indexes, or -1
+	// if unknown.
+	TotalBytes int64
+}
+
+// catalogStats returns the catalog statistics of the table. Row counts are
+// estimates, which are only as recent as the last time the table was
+// analyzed.
+func catalogStats(ctx context.Context, conn dbconn.Conn, table dbtable.Name) (tableStats, error) {
+	ret := tableStats{Rows: -1, DataBytes: -1, TotalBytes: -1}
+	var rows, dataBytes, totalBytes sql.NullInt64
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		if conn.IsCockroach() {
+			// CockroachDB does not track the size of tables in the catalog.
+			if err := conn.QueryRow(
+				ctx,
+				"SELECT estimated_row_count FROM crdb_internal.table_row_statistics WHERE table_id = $1::REGCLASS::INT8",
+				tree.AsString(table.NewTableName()),
+			).Scan(&rows); err != nil {
+				return ret, errors.Wrapf(err, "error fetching statistics for %s", table.SafeString())
+			}
+			break
+		}
+		// reltuples is -1 if the table has never been analyzed.
+		if err := conn.QueryRow(
+			ctx,
+			`SELECT NULLIF(c.reltuples, -1)::INT8, pg_table_size(c.oid), pg_total_relation_size(c.oid)
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = $1 AND c.relname = $2`,
+			string(table.Schema),
+			string(table.Table),
+		).Scan(&rows, &dataBytes, &totalBytes); err != nil {
+			return ret, errors.Wrapf(err, "error fetching statistics for %s", table.SafeString())
+		}
+	case *dbconn.MySQLConn:
+		if err := conn.QueryRowContext(
+			ctx,
+			`SELECT table_rows, data_length, data_length + index_length
+FROM information_schema.tables
+WHERE table_schema = DATABASE() AND table_name = ?`,
+			string(table.Table),
+		).Scan(&rows, &dataBytes, &totalBytes); err != nil {
+			return ret, errors.Wrapf(err, "error fetching statistics for %s", table.SafeString())
+		}
+	case *dbconn.OracleConn:
+		if err := conn.QueryRowContext(
+			ctx,
+			`SELECT
+	(SELECT num_rows FROM all_tables WHERE tablespace_name = :1 AND table_name = :2),
+	(SELECT SUM(bytes) FROM dba_segments WHERE tablespace_name = :3 AND segment_name = :4 AND segment_type LIKE 'TABLE%'),
+	(SELECT SUM(bytes) FROM dba_segments WHERE tablespace_name = :5 AND (
+		segment_name = :6 OR segment_name IN (SELECT index_name FROM all_indexes WHERE table_name = :7)
+	))
+FROM dual`,
+			// Bind variables are bound by position, so are repeated.
+			string(table.Schema),
+			string(table.Table),
+			string(table.Schema),
+			string(table.Table),
+			string(table.Schema),
+			string(table.Table),
+			string(table.Table),
+		).Scan(&rows, &dataBytes, &totalBytes); err != nil {
+			return ret, errors.Wrapf(err, "error fetching statistics for %s", table.SafeString())
+		}
+	default:
+		return ret, errors.AssertionFailedf("unknown conn type: %T", conn)
+	}
+	if rows.Valid {
+		ret.Rows = rows.Int64
+	}
+	if dataBytes.Valid {
+		ret.DataBytes = dataBytes.Int64
+	}
+	if totalBytes.Valid {
+		ret.TotalBytes = totalBytes.Int64
+	}
+	return ret, nil
+}
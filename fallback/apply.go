@@ -0,0 +1,518 @@
+package fallback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	crdbjson "github.com/cockroachdb/cockroachdb-parser/pkg/util/json"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/mysqlconv"
+	"github.com/cockroachdb/molt/parsectx"
+	"github.com/cockroachdb/molt/pgconv"
+	"github.com/lib/pq/oid"
+)
+
+// changefeedRow is a row change emitted by a changefeed in the JSON format.
+type changefeedRow struct {
+	// After is the row after the change, which is nil if it was deleted.
+	After map[string]json.RawMessage `json:"after"`
+	// Key is the primary key of the row.
+	Key   []json.RawMessage `json:"key"`
+	Topic string            `json:"topic"`
+	// Updated is the HLC timestamp of the change.
+	Updated string `json:"updated"`
+}
+
+// fallbackTable is a table changes are applied to, with the statements used
+// to apply them to the source.
+type fallbackTable struct {
+	dbtable.VerifiedTable
+	upsertStmt string
+	deleteStmt string
+	// pkIdxs are the indexes of the primary key columns in Columns.
+	pkIdxs []int
+}
+
+// applier applies changes to the source. It is not safe for concurrent use.
+type applier struct {
+	conn   dbconn.Conn
+	tables map[dbtable.Name]*fallbackTable
+	// toValue converts a datum into a value of the source's column type.
+	toValue func(d tree.Datum, typOID oid.Oid) (any, error)
+
+	// Changefeeds emit changes at least once, and may resend an older change
+	// to a row after a newer one, so the versions applied are tracked to skip
+	// older changes.
+	//
+	// resolved is the latest resolved timestamp, before which all changes
+	// have been applied.
+	resolved hlcTimestamp
+	// latest is the timestamp of the latest change applied to each row, keyed
+	// by rowKey, since resolved.
+	latest map[string]hlcTimestamp
+}
+
+func newApplier(conn dbconn.Conn, tables []dbtable.VerifiedTable) (*applier, error) {
+	a := &applier{
+		conn:   conn,
+		tables: make(map[dbtable.Name]*fallbackTable, len(tables)),
+		latest: make(map[string]hlcTimestamp),
+	}
+	var upsertStmt, deleteStmt func(dbtable.VerifiedTable) string
+	switch conn.(type) {
+	case *dbconn.PGConn:
+		a.toValue = pgconv.ConvertDatumToValue
+		upsertStmt, deleteStmt = pgUpsertStmt, pgDeleteStmt
+	case *dbconn.MySQLConn:
+		a.toValue = mysqlconv.ConvertDatumToValue
+		upsertStmt, deleteStmt = mysqlUpsertStmt, mysqlDeleteStmt
+	default:
+		return nil, errors.AssertionFailedf("unknown conn type: %T", conn)
+	}
+	for _, table := range tables {
+		t := &fallbackTable{
+			VerifiedTable: table,
+			upsertStmt:    upsertStmt(table),
+			deleteStmt:    deleteStmt(table),
+		}
+		for _, pkCol := range table.PrimaryKeyColumns {
+			idx := columnIdx(table.Columns, pkCol)
+			if idx == -1 {
+				return nil, errors.AssertionFailedf("primary key column %s not found on %s", pkCol, table.SafeString())
+			}
+			t.pkIdxs = append(t.pkIdxs, idx)
+		}
+		a.tables[table.Name] = t
+	}
+	return a, nil
+}
+
+func columnIdx(cols []tree.Name, col tree.Name) int {
+	for i, c := range cols {
+		if c == col {
+			return i
+		}
+	}
+	return -1
+}
+
+// tableForTopic returns the table of a topic, which is the fully qualified
+// name of the table as the changefeed is created with full_table_name.
+func (a *applier) tableForTopic(topic string) (*fallbackTable, error) {
+	parts := strings.Split(topic, ".")
+	if len(parts) < 2 {
+		return nil, errors.Newf("unexpected topic %q", topic)
+	}
+	name := dbtable.Name{
+		Schema: tree.Name(parts[len(parts)-2]),
+		Table:  tree.Name(parts[len(parts)-1]),
+	}
+	t, ok := a.tables[name]
+	if !ok {
+		return nil, errors.Newf("received change for unknown table %s", name.SafeString())
+	}
+	return t, nil
+}
+
+// stmt returns the statement and arguments applying a row change.
+func (a *applier) stmt(row changefeedRow) (*fallbackTable, string, []any, error) {
+	t, err := a.tableForTopic(row.Topic)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if row.After == nil {
+		if len(row.Key) != len(t.pkIdxs) {
+			return nil, "", nil, errors.Newf(
+				"expected %d primary key values for %s, got %d",
+				len(t.pkIdxs),
+				t.SafeString(),
+				len(row.Key),
+			)
+		}
+		args := make([]any, len(t.pkIdxs))
+		for i, colIdx := range t.pkIdxs {
+			if args[i], err = a.convert(t, colIdx, row.Key[i]); err != nil {
+				return nil, "", nil, err
+			}
+		}
+		return t, t.deleteStmt, args, nil
+	}
+	args := make([]any, len(t.Columns))
+	for i, col := range t.Columns {
+		raw, ok := row.After[string(col)]
+		if !ok {
+			return nil, "", nil, errors.Newf("column %s of %s missing from change", col, t.SafeString())
+		}
+		if args[i], err = a.convert(t, i, raw); err != nil {
+			return nil, "", nil, err
+		}
+	}
+	return t, t.upsertStmt, args, nil
+}
+
+// convert converts a value of the column at colIdx of a change into a value
+// for the source.
+func (a *applier) convert(t *fallbackTable, colIdx int, raw json.RawMessage) (any, error) {
+	d, err := parseChangeValue(raw, t.ColumnOIDs[1][colIdx])
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing column %s of %s", t.Columns[colIdx], t.SafeString())
+	}
+	v, err := a.toValue(d, t.ColumnOIDs[0][colIdx])
+	if err != nil {
+		return nil, errors.Wrapf(err, "error converting column %s of %s", t.Columns[colIdx], t.SafeString())
+	}
+	return v, nil
+}
+
+// apply applies changes to the source in a single transaction, returning the
+// number of changes applied to each table and the number of changes skipped
+// as a newer change to the row was already applied.
+func (a *applier) apply(
+	ctx context.Context, rows []changefeedRow,
+) (map[dbtable.Name]int, int, error) {
+	newer, versions, err := a.newerChanges(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	skipped := len(rows) - len(newer)
+	type stmt struct {
+		query string
+		args  []any
+	}
+	stmts := make([]stmt, len(newer))
+	counts := make(map[dbtable.Name]int)
+	for i, row := range newer {
+		t, query, args, err := a.stmt(row)
+		if err != nil {
+			return nil, 0, err
+		}
+		stmts[i] = stmt{query: query, args: args}
+		counts[t.Name]++
+	}
+	if len(stmts) == 0 {
+		return counts, skipped, nil
+	}
+	if err := func() error {
+		switch conn := a.conn.(type) {
+		case *dbconn.PGConn:
+			tx, err := conn.Begin(ctx)
+			if err != nil {
+				return err
+			}
+			for _, s := range stmts {
+				if _, err := tx.Exec(ctx, s.query, s.args...); err != nil {
+					return errors.CombineErrors(err, tx.Rollback(ctx))
+				}
+			}
+			return tx.Commit(ctx)
+		case *dbconn.MySQLConn:
+			tx, err := conn.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			for _, s := range stmts {
+				if _, err := tx.ExecContext(ctx, s.query, s.args...); err != nil {
+					return errors.CombineErrors(err, tx.Rollback())
+				}
+			}
+			return tx.Commit()
+		}
+		return errors.AssertionFailedf("unknown conn type: %T", a.conn)
+	}(); err != nil {
+		return nil, 0, err
+	}
+	for k, v := range versions {
+		a.latest[k] = v
+	}
+	return counts, skipped, nil
+}
+
+// newerChanges returns the changes which are newer than the latest change
+// applied to their row, and the timestamps of the rows they change.
+func (a *applier) newerChanges(
+	rows []changefeedRow,
+) ([]changefeedRow, map[string]hlcTimestamp, error) {
+	var ret []changefeedRow
+	versions := make(map[string]hlcTimestamp)
+	for _, row := range rows {
+		t, err := a.tableForTopic(row.Topic)
+		if err != nil {
+			return nil, nil, err
+		}
+		if row.Updated == "" {
+			return nil, nil, errors.Newf("change to %s is missing its updated timestamp", t.SafeString())
+		}
+		updated, err := parseHLC(row.Updated)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Changes before the resolved timestamp were applied before it was
+		// checkpointed.
+		if updated.less(a.resolved) {
+			continue
+		}
+		key, err := rowKey(t, row.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		latest, ok := versions[key]
+		if !ok {
+			latest, ok = a.latest[key]
+		}
+		if ok && !latest.less(updated) {
+			continue
+		}
+		versions[key] = updated
+		ret = append(ret, row)
+	}
+	return ret, versions, nil
+}
+
+// resolve records a resolved timestamp, forgetting the versions of rows
+// changed before it.
+func (a *applier) resolve(resolved hlcTimestamp) {
+	if !a.resolved.less(resolved) {
+		return
+	}
+	a.resolved = resolved
+	for k, v := range a.latest {
+		if v.less(resolved) {
+			delete(a.latest, k)
+		}
+	}
+}
+
+// rowKey identifies a row of a table by the primary key of a change.
+func rowKey(t *fallbackTable, key []json.RawMessage) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString(t.SafeString())
+	for _, k := range key {
+		buf.WriteByte(0)
+		if err := json.Compact(&buf, k); err != nil {
+			return "", errors.Wrapf(err, "error parsing primary key of %s", t.SafeString())
+		}
+	}
+	return buf.String(), nil
+}
+
+// hlcTimestamp is a CockroachDB HLC timestamp.
+type hlcTimestamp struct {
+	// wallTime is nanoseconds since the Unix epoch.
+	wallTime int64
+	logical  int64
+}
+
+// parseHLC parses an HLC timestamp, which is formatted as nanoseconds since
+// the Unix epoch followed by a logical component.
+func parseHLC(hlc string) (hlcTimestamp, error) {
+	wall, logical, hasLogical := strings.Cut(hlc, ".")
+	var ret hlcTimestamp
+	var err error
+	if ret.wallTime, err = strconv.ParseInt(wall, 10, 64); err != nil {
+		return hlcTimestamp{}, errors.Wrapf(err, "invalid HLC timestamp %q", hlc)
+	}
+	if hasLogical {
+		if ret.logical, err = strconv.ParseInt(logical, 10, 64); err != nil {
+			return hlcTimestamp{}, errors.Wrapf(err, "invalid HLC timestamp %q", hlc)
+		}
+	}
+	return ret, nil
+}
+
+func (t hlcTimestamp) less(o hlcTimestamp) bool {
+	if t.wallTime != o.wallTime {
+		return t.wallTime < o.wallTime
+	}
+	return t.logical < o.logical
+}
+
+// parseChangeValue parses a value of a changefeed row in the JSON format into
+// a datum of the CockroachDB column type.
+func parseChangeValue(raw json.RawMessage, typOID oid.Oid) (tree.Datum, error) {
+	typ, ok := types.OidToType[typOID]
+	if !ok {
+		// User defined types, i.e. enums, are given as their label.
+		typ = types.String
+	}
+	if typ.Family() == types.JsonFamily {
+		if string(raw) == "null" {
+			return tree.DNull, nil
+		}
+		j, err := crdbjson.ParseJSON(string(raw))
+		if err != nil {
+			return nil, err
+		}
+		return tree.NewDJSON(j), nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return tree.DNull, nil
+	}
+	var s string
+	if arr, ok := v.([]any); ok {
+		if typ.Family() != types.ArrayFamily {
+			return nil, errors.Newf("unexpected array for type %s", typ.SQLString())
+		}
+		var err error
+		if s, err = arrayLiteral(arr); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if s, err = scalarText(v); err != nil {
+			return nil, err
+		}
+	}
+	d, _, err := tree.ParseAndRequireString(typ, s, parsectx.ParseContext)
+	return d, err
+}
+
+func scalarText(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", errors.Newf("unexpected value %v", v)
+}
+
+// arrayLiteral returns the text of a one dimensional array.
+func arrayLiteral(arr []any) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, elem := range arr {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		if elem == nil {
+			sb.WriteString("NULL")
+			continue
+		}
+		s, err := scalarText(elem)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(`"`)
+		sb.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s))
+		sb.WriteString(`"`)
+	}
+	sb.WriteString("}")
+	return sb.String(), nil
+}
+
+func pgUpsertStmt(table dbtable.VerifiedTable) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT INTO %s (", tree.AsString(table.NewTableName()))
+	for i, col := range table.Columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(col.String())
+	}
+	sb.WriteString(") VALUES (")
+	for i := range table.Columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "$%d", i+1)
+	}
+	sb.WriteString(") ON CONFLICT (")
+	for i, col := range table.PrimaryKeyColumns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(col.String())
+	}
+	sb.WriteString(")")
+	updateCols := nonPrimaryKeyColumns(table)
+	if len(updateCols) == 0 {
+		sb.WriteString(" DO NOTHING")
+		return sb.String()
+	}
+	sb.WriteString(" DO UPDATE SET ")
+	for i, col := range updateCols {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s = excluded.%s", col.String(), col.String())
+	}
+	return sb.String()
+}
+
+func pgDeleteStmt(table dbtable.VerifiedTable) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "DELETE FROM %s WHERE ", tree.AsString(table.NewTableName()))
+	for i, col := range table.PrimaryKeyColumns {
+		if i > 0 {
+			sb.WriteString(" AND ")
+		}
+		fmt.Fprintf(&sb, "%s = $%d", col.String(), i+1)
+	}
+	return sb.String()
+}
+
+// mysqlUpsertStmt returns an upsert into a table on MySQL. Tables on MySQL
+// are given a fake public schema, so only the table name is used.
+func mysqlUpsertStmt(table dbtable.VerifiedTable) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT INTO %s (", mysqlconv.QuoteIdent(string(table.Table)))
+	for i, col := range table.Columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(mysqlconv.QuoteIdent(string(col)))
+	}
+	sb.WriteString(") VALUES (")
+	sb.WriteString(strings.TrimSuffix(strings.Repeat("?, ", len(table.Columns)), ", "))
+	sb.WriteString(") ON DUPLICATE KEY UPDATE ")
+	updateCols := nonPrimaryKeyColumns(table)
+	if len(updateCols) == 0 {
+		// Updating a primary key column to itself does nothing.
+		updateCols = table.PrimaryKeyColumns[:1]
+	}
+	for i, col := range updateCols {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s = VALUES(%s)", mysqlconv.QuoteIdent(string(col)), mysqlconv.QuoteIdent(string(col)))
+	}
+	return sb.String()
+}
+
+func mysqlDeleteStmt(table dbtable.VerifiedTable) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "DELETE FROM %s WHERE ", mysqlconv.QuoteIdent(string(table.Table)))
+	for i, col := range table.PrimaryKeyColumns {
+		if i > 0 {
+			sb.WriteString(" AND ")
+		}
+		fmt.Fprintf(&sb, "%s = ?", mysqlconv.QuoteIdent(string(col)))
+	}
+	return sb.String()
+}
+
+func nonPrimaryKeyColumns(table dbtable.VerifiedTable) []tree.Name {
+	var ret []tree.Name
+	for _, col := range table.Columns {
+		if columnIdx(table.PrimaryKeyColumns, col) == -1 {
+			ret = append(ret, col)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,177 @@
+package fallback
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/lib/pq/oid"
+	"github.com/stretchr/testify/require"
+)
+
+var applyTestTable = dbtable.VerifiedTable{
+	Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+	PrimaryKeyColumns: []tree.Name{"id"},
+	Columns:           []tree.Name{"id", "txt", "ts"},
+	ColumnOIDs: [2][]oid.Oid{
+		{oid.T_int4, oid.T_text, oid.T_timestamp},
+		{oid.T_int8, oid.T_text, oid.T_timestamptz},
+	},
+}
+
+func TestStatements(t *testing.T) {
+	require.Equal(
+		t,
+		`INSERT INTO public.tbl (id, txt, ts) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET txt = excluded.txt, ts = excluded.ts`,
+		pgUpsertStmt(applyTestTable),
+	)
+	require.Equal(t, `DELETE FROM public.tbl WHERE id = $1`, pgDeleteStmt(applyTestTable))
+	require.Equal(
+		t,
+		"INSERT INTO `tbl` (`id`, `txt`, `ts`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `txt` = VALUES(`txt`), `ts` = VALUES(`ts`)",
+		mysqlUpsertStmt(applyTestTable),
+	)
+	require.Equal(t, "DELETE FROM `tbl` WHERE `id` = ?", mysqlDeleteStmt(applyTestTable))
+
+	pkOnly := dbtable.VerifiedTable{
+		Name:              dbtable.Name{Schema: "public", Table: "pk only"},
+		PrimaryKeyColumns: []tree.Name{"a", "b"},
+		Columns:           []tree.Name{"a", "b"},
+	}
+	require.Equal(
+		t,
+		`INSERT INTO public."pk only" (a, b) VALUES ($1, $2) ON CONFLICT (a, b) DO NOTHING`,
+		pgUpsertStmt(pkOnly),
+	)
+	require.Equal(
+		t,
+		"INSERT INTO `pk only` (`a`, `b`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `a` = VALUES(`a`)",
+		mysqlUpsertStmt(pkOnly),
+	)
+}
+
+func TestParseChangeValue(t *testing.T) {
+	for _, tc := range []struct {
+		raw      string
+		typOID   oid.Oid
+		expected string
+	}{
+		{raw: `null`, typOID: oid.T_int8, expected: `NULL`},
+		{raw: `123`, typOID: oid.T_int8, expected: `123`},
+		{raw: `1.50`, typOID: oid.T_numeric, expected: `1.50`},
+		{raw: `"a'b"`, typOID: oid.T_text, expected: `e'a\'b'`},
+		{raw: `true`, typOID: oid.T_bool, expected: `true`},
+		{raw: `"\\x6869"`, typOID: oid.T_bytea, expected: `'\x6869'`},
+		{raw: `"2024-01-02T03:04:05.123456"`, typOID: oid.T_timestamp, expected: `'2024-01-02 03:04:05.123456'`},
+		{raw: `"2024-01-02T03:04:05Z"`, typOID: oid.T_timestamptz, expected: `'2024-01-02 03:04:05+00'`},
+		{raw: `{"a": [1, null]}`, typOID: oid.T_jsonb, expected: `'{"a": [1, null]}'`},
+		{raw: `null`, typOID: oid.T_jsonb, expected: `NULL`},
+		{raw: `["a", null, "b\"c"]`, typOID: oid.T__text, expected: `ARRAY['a',NULL,'b"c']`},
+		{raw: `"label"`, typOID: 100100, expected: `'label'`},
+	} {
+		t.Run(tc.raw, func(t *testing.T) {
+			d, err := parseChangeValue(json.RawMessage(tc.raw), tc.typOID)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, tree.AsString(d))
+		})
+	}
+
+	_, err := parseChangeValue(json.RawMessage(`[1]`), oid.T_int8)
+	require.Error(t, err)
+}
+
+func TestApplierStmt(t *testing.T) {
+	a, err := newApplier(&dbconn.MySQLConn{}, []dbtable.VerifiedTable{applyTestTable})
+	require.NoError(t, err)
+
+	_, query, args, err := a.stmt(changefeedRow{
+		Topic: "defaultdb.public.tbl",
+		Key:   []json.RawMessage{json.RawMessage(`1`)},
+		After: map[string]json.RawMessage{
+			"id":  json.RawMessage(`1`),
+			"txt": json.RawMessage(`null`),
+			"ts":  json.RawMessage(`"2024-01-02T03:04:05Z"`),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, mysqlUpsertStmt(applyTestTable), query)
+	require.Equal(t, []any{int64(1), nil, "2024-01-02 03:04:05+00:00"}, args)
+
+	_, query, args, err = a.stmt(changefeedRow{
+		Topic: "defaultdb.public.tbl",
+		Key:   []json.RawMessage{json.RawMessage(`2`)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, mysqlDeleteStmt(applyTestTable), query)
+	require.Equal(t, []any{int64(2)}, args)
+
+	_, _, _, err = a.stmt(changefeedRow{Topic: "defaultdb.public.other"})
+	require.Error(t, err)
+	_, _, _, err = a.stmt(changefeedRow{
+		Topic: "defaultdb.public.tbl",
+		After: map[string]json.RawMessage{"id": json.RawMessage(`1`)},
+	})
+	require.Error(t, err)
+}
+
+func TestApplierNewerChanges(t *testing.T) {
+	a, err := newApplier(&dbconn.MySQLConn{}, []dbtable.VerifiedTable{applyTestTable})
+	require.NoError(t, err)
+	change := func(id string, updated string) changefeedRow {
+		return changefeedRow{
+			Topic:   "defaultdb.public.tbl",
+			Key:     []json.RawMessage{json.RawMessage(id)},
+			Updated: updated,
+		}
+	}
+	updatedOf := func(rows []changefeedRow) []string {
+		var ret []string
+		for _, row := range rows {
+			ret = append(ret, string(row.Key[0])+"@"+row.Updated)
+		}
+		return ret
+	}
+	// apply records versions once changes are committed, which is done here
+	// without a database.
+	record := func(rows []changefeedRow) []string {
+		newer, versions, err := a.newerChanges(rows)
+		require.NoError(t, err)
+		for k, v := range versions {
+			a.latest[k] = v
+		}
+		return updatedOf(newer)
+	}
+
+	// Older changes to a row in the same batch are skipped.
+	require.Equal(
+		t,
+		[]string{"1@20.0000000000", "2@10.0000000000"},
+		record([]changefeedRow{change("1", "20.0000000000"), change("2", "10.0000000000"), change(" 1 ", "15.0000000000")}),
+	)
+	// Resent changes older than or equal to those applied are skipped.
+	require.Equal(
+		t,
+		[]string{"2@10.0000000001"},
+		record([]changefeedRow{change("1", "20.0000000000"), change("1", "19.0000000000"), change("2", "10.0000000001")}),
+	)
+
+	// Versions before the resolved timestamp are forgotten, and changes
+	// before it are skipped as they have been applied.
+	a.resolve(hlcTimestamp{wallTime: 15})
+	require.Len(t, a.latest, 1)
+	require.Equal(
+		t,
+		[]string{"3@15.0000000000", "1@21.0000000000"},
+		record([]changefeedRow{change("3", "14.0000000000"), change("3", "15.0000000000"), change("1", "21.0000000000")}),
+	)
+	// Earlier resolved timestamps are ignored.
+	a.resolve(hlcTimestamp{wallTime: 1})
+	require.Equal(t, hlcTimestamp{wallTime: 15}, a.resolved)
+
+	_, _, err = a.newerChanges([]changefeedRow{change("1", "")})
+	require.ErrorContains(t, err, "missing its updated timestamp")
+	_, _, err = a.newerChanges([]changefeedRow{change("1", "abc")})
+	require.ErrorContains(t, err, "invalid HLC timestamp")
+}
@@ -0,0 +1,61 @@
+package fallback
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+)
+
+// DefaultCheckpointPath is the default file the checkpoint is kept in.
+const DefaultCheckpointPath = "molt_fallback_checkpoint.json"
+
+// checkpoint records the latest resolved timestamp of the changefeed, before
+// which all changes have been applied to the source. Fallback resumes from
+// the checkpoint when restarted.
+type checkpoint struct {
+	Resolved  string    `json:"resolved"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// loadCheckpoint returns the checkpoint at path, if one exists.
+func loadCheckpoint(path string) (checkpoint, bool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if oserror.IsNotExist(err) {
+			return checkpoint{}, false, nil
+		}
+		return checkpoint{}, false, errors.Wrapf(err, "error reading checkpoint %s", path)
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return checkpoint{}, false, errors.Wrapf(err, "error decoding checkpoint %s", path)
+	}
+	return cp, true, nil
+}
+
+// saveCheckpoint writes the checkpoint to path, replacing the previous
+// checkpoint atomically.
+func saveCheckpoint(path string, cp checkpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "error writing checkpoint")
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return errors.Wrap(err, "error writing checkpoint")
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return errors.Wrap(err, "error writing checkpoint")
+	}
+	return errors.Wrap(os.Rename(f.Name(), path), "error writing checkpoint")
+}
@@ -0,0 +1,29 @@
+package fallback
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	_, ok, err := loadCheckpoint(path)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	for _, resolved := range []string{"1.0000000000", "2.0000000000"} {
+		cp := checkpoint{Resolved: resolved, UpdatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+		require.NoError(t, saveCheckpoint(path, cp))
+		loaded, ok, err := loadCheckpoint(path)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, cp, loaded)
+	}
+	// Temporary files are not left behind.
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*"))
+	require.NoError(t, err)
+	require.Equal(t, []string{path}, matches)
+}
@@ -0,0 +1,251 @@
+package fallback
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/molttelemetry"
+	"github.com/cockroachdb/molt/verify/dbverify"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/rs/zerolog"
+)
+
+// Config configures fallback.
+type Config struct {
+	// CutoverTimestamp is the CockroachDB HLC timestamp of the cutover, from
+	// which changes are applied to the source. It is only used if there is no
+	// checkpoint to resume from.
+	CutoverTimestamp string
+	// ListenAddr is the address the webhook sink is served on.
+	ListenAddr string
+	// SinkAddr is the address CockroachDB uses to reach the webhook sink.
+	// Defaults to ListenAddr.
+	SinkAddr string
+	// CheckpointPath is the file the latest resolved timestamp is kept in.
+	CheckpointPath string
+	// ResolvedInterval is how often the changefeed emits resolved timestamps,
+	// and so how often the checkpoint is updated.
+	ResolvedInterval time.Duration
+}
+
+var hlcTimestampRE = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
+// Fallback replicates changes made on CockroachDB (the target) since the
+// cutover back to the original PostgreSQL or MySQL database (the source),
+// using a changefeed on the migrated tables. It runs until ctx is cancelled.
+func Fallback(
+	ctx context.Context,
+	cfg Config,
+	logger zerolog.Logger,
+	conns dbconn.OrderedConns,
+	tableFilter dbverify.FilterConfig,
+) error {
+	if conns[0].IsCockroach() {
+		return errors.Newf("source must be PostgreSQL or MySQL")
+	}
+	crdbConn, ok := conns[1].(*dbconn.PGConn)
+	if !ok || !crdbConn.IsCockroach() {
+		return errors.Newf("target must be CockroachDB")
+	}
+	if err := dbconn.RegisterTelemetry(conns); err != nil {
+		return err
+	}
+	molttelemetry.ReportTelemetryAsync(logger, "molt_fallback_dialect_"+conns[0].Dialect())
+
+	cursor := cfg.CutoverTimestamp
+	cp, ok, err := loadCheckpoint(cfg.CheckpointPath)
+	if err != nil {
+		return err
+	}
+	if ok {
+		logger.Info().
+			Str("resolved", cp.Resolved).
+			Time("updated_at", cp.UpdatedAt).
+			Msgf("resuming from checkpoint")
+		cursor = cp.Resolved
+	}
+	if cursor == "" {
+		return errors.Newf("cutover timestamp must be set if there is no checkpoint")
+	}
+	if !hlcTimestampRE.MatchString(cursor) {
+		return errors.Newf("invalid timestamp %q, expected the output of cluster_logical_timestamp()", cursor)
+	}
+
+	tables, err := fallbackTables(ctx, logger, conns, tableFilter)
+	if err != nil {
+		return err
+	}
+
+	sourceConn, err := conns[0].Clone(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = sourceConn.Close(ctx) }()
+	a, err := newApplier(sourceConn, tables)
+	if err != nil {
+		return err
+	}
+	cursorTS, err := parseHLC(cursor)
+	if err != nil {
+		return err
+	}
+	a.resolve(cursorTS)
+
+	sinkAddr := cfg.SinkAddr
+	if sinkAddr == "" {
+		sinkAddr = cfg.ListenAddr
+	}
+	sinkHost, _, err := net.SplitHostPort(sinkAddr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid sink address %q", sinkAddr)
+	}
+	if ip := net.ParseIP(sinkHost); sinkHost == "" || (ip != nil && ip.IsUnspecified()) {
+		return errors.Newf("sink address must be set to an address CockroachDB can reach if listening on %s", cfg.ListenAddr)
+	}
+	cert, certPEM, err := generateCert(sinkHost)
+	if err != nil {
+		return errors.Wrap(err, "error generating certificate for webhook sink")
+	}
+	authHeader, err := newAuthHeader()
+	if err != nil {
+		return errors.Wrap(err, "error generating secret for webhook sink")
+	}
+	ln, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler: &sinkHandler{
+			applier:        a,
+			authHeader:     authHeader,
+			checkpointPath: cfg.CheckpointPath,
+			logger:         logger,
+			now:            time.Now,
+		},
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+	serverErrCh := make(chan error, 1)
+	go func() {
+		logger.Info().
+			Str("listen_addr", cfg.ListenAddr).
+			Str("sink_addr", sinkAddr).
+			Msgf("starting webhook sink")
+		serverErrCh <- server.ServeTLS(ln, "", "")
+	}()
+	defer func() {
+		if err := server.Shutdown(context.Background()); err != nil {
+			logger.Err(err).Msgf("error shutting down webhook sink")
+		}
+	}()
+
+	var jobID int64
+	if err := crdbConn.QueryRow(
+		ctx,
+		changefeedStmt(tables, sinkURL(sinkAddr, certPEM), authHeader, cursor, cfg.ResolvedInterval),
+	).Scan(&jobID); err != nil {
+		return errors.Wrap(err, "error creating changefeed")
+	}
+	logger.Info().
+		Int64("job_id", jobID).
+		Str("cursor", cursor).
+		Int("num_tables", len(tables)).
+		Msgf("created changefeed")
+	defer func() {
+		// The changefeed is recreated from the checkpoint when resumed.
+		if _, err := crdbConn.Exec(context.Background(), "CANCEL JOB $1", jobID); err != nil {
+			logger.Err(err).Int64("job_id", jobID).Msgf("error cancelling changefeed")
+			return
+		}
+		logger.Info().Int64("job_id", jobID).Msgf("cancelled changefeed")
+	}()
+
+	select {
+	case <-ctx.Done():
+		logger.Info().Msgf("stopping fallback")
+		return nil
+	case err := <-serverErrCh:
+		return errors.Wrap(err, "error serving webhook sink")
+	}
+}
+
+// fallbackTables returns the tables on both the source and target which
+// changes can be applied to, i.e. those with matching primary keys.
+func fallbackTables(
+	ctx context.Context,
+	logger zerolog.Logger,
+	conns dbconn.OrderedConns,
+	tableFilter dbverify.FilterConfig,
+) ([]dbtable.VerifiedTable, error) {
+	dbTables, err := dbverify.Verify(ctx, conns)
+	if err != nil {
+		return nil, err
+	}
+	if dbTables, err = dbverify.FilterResult(tableFilter, dbTables); err != nil {
+		return nil, err
+	}
+	for _, tbl := range dbTables.ExtraneousTables {
+		logger.Warn().
+			Str("table", tbl.SafeString()).
+			Msgf("ignoring table as it is missing a definition on the source")
+	}
+	for _, tbl := range dbTables.MissingTables {
+		logger.Warn().
+			Str("table", tbl.SafeString()).
+			Msgf("ignoring table as it is missing a definition on the target")
+	}
+	results, err := tableverify.VerifyCommonTables(ctx, conns, dbTables.Verified, tableverify.ColumnFilter{})
+	if err != nil {
+		return nil, err
+	}
+	var ret []dbtable.VerifiedTable
+	for _, res := range results {
+		for _, col := range res.MismatchingTableDefinitions {
+			logger.Warn().
+				Str("table", res.SafeString()).
+				Str("reason", col.Info).
+				Msgf("not replicating column %s as it mismatches", col.Name)
+		}
+		if !res.RowVerifiable || res.RowKey != tableverify.RowKeyPrimaryKey {
+			logger.Error().
+				Str("table", res.SafeString()).
+				Msgf("table does not have matching primary keys, cannot fall back")
+			continue
+		}
+		ret = append(ret, res.VerifiedTable)
+	}
+	if len(ret) == 0 {
+		return nil, errors.Newf("no tables to fall back")
+	}
+	return ret, nil
+}
+
+// changefeedStmt returns the statement creating a changefeed on the tables
+// which emits changes after cursor to the sink, authenticating with the given
+// Authorization header.
+func changefeedStmt(
+	tables []dbtable.VerifiedTable,
+	sink string,
+	authHeader string,
+	cursor string,
+	resolved time.Duration,
+) string {
+	names := make([]string, len(tables))
+	for i, t := range tables {
+		names[i] = tree.AsString(t.NewTableName())
+	}
+	return "CREATE CHANGEFEED FOR TABLE " + strings.Join(names, ", ") +
+		" INTO " + tree.AsString(tree.NewDString(sink)) +
+		" WITH updated, full_table_name" +
+		", webhook_auth_header = " + tree.AsString(tree.NewDString(authHeader)) +
+		", resolved = " + tree.AsString(tree.NewDString(resolved.String())) +
+		", cursor = " + tree.AsString(tree.NewDString(cursor))
+}
@@ -0,0 +1,17 @@
+package fallback
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangefeedStmt(t *testing.T) {
+	require.Equal(
+		t,
+		`CREATE CHANGEFEED FOR TABLE public.tbl INTO 'webhook-https://host:1/?ca_cert=abc' WITH updated, full_table_name, webhook_auth_header = 'Bearer secret', resolved = '10s', cursor = '1.0000000000'`,
+		changefeedStmt([]dbtable.VerifiedTable{applyTestTable}, "webhook-https://host:1/?ca_cert=abc", "Bearer secret", "1.0000000000", 10*time.Second),
+	)
+}
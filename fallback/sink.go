@@ -0,0 +1,198 @@
+package fallback
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+)
+
+var (
+	rowsAppliedMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "molt",
+		Subsystem: "fallback",
+		Name:      "rows_applied",
+		Help:      "Number of changes from the changefeed applied to each table on the source.",
+	}, []string{"table"})
+	rowsSkippedMetric = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "molt",
+		Subsystem: "fallback",
+		Name:      "rows_skipped",
+		Help:      "Number of changes resent by the changefeed which were skipped as a newer change to the row was already applied.",
+	})
+	resolvedTimestampMetric = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "molt",
+		Subsystem: "fallback",
+		Name:      "resolved_timestamp_seconds",
+		Help:      "Unix timestamp of the latest checkpoint, before which all changes have been applied to the source.",
+	})
+)
+
+// webhookMessage is a message posted by a changefeed webhook sink, which is
+// either a batch of row changes or a resolved timestamp.
+type webhookMessage struct {
+	Payload  []changefeedRow `json:"payload"`
+	Length   int             `json:"length"`
+	Resolved string          `json:"resolved"`
+}
+
+// sinkHandler serves the webhook sink of the changefeed, applying the changes
+// posted to the source. Changes are only acknowledged once they have been
+// applied, so the changefeed retries them otherwise.
+type sinkHandler struct {
+	applier *applier
+	// authHeader is the Authorization header the changefeed is created with,
+	// which requests must have so that only the changefeed can write to the
+	// source.
+	authHeader     string
+	checkpointPath string
+	logger         zerolog.Logger
+	now            func() time.Time
+
+	// mu serializes applying changes, so that changes are applied in the
+	// order they are emitted and resolved timestamps are only checkpointed
+	// after the changes before them.
+	mu sync.Mutex
+}
+
+func (h *sinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(h.authHeader)) != 1 {
+		h.logger.Warn().Str("remote_addr", r.RemoteAddr).Msgf("rejecting request without the changefeed's authorization header")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var msg webhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		h.logger.Err(err).Msgf("error decoding changefeed message")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.handle(r.Context(), msg); err != nil {
+		h.logger.Err(err).Msgf("error applying changefeed message")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *sinkHandler) handle(ctx context.Context, msg webhookMessage) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(msg.Payload) > 0 {
+		counts, skipped, err := h.applier.apply(ctx, msg.Payload)
+		if err != nil {
+			return err
+		}
+		for name, n := range counts {
+			rowsAppliedMetric.WithLabelValues(name.SafeString()).Add(float64(n))
+		}
+		rowsSkippedMetric.Add(float64(skipped))
+		h.logger.Debug().
+			Int("num_rows", len(msg.Payload)).
+			Int("num_skipped", skipped).
+			Msgf("applied changes")
+	}
+	if msg.Resolved != "" {
+		resolved, err := parseHLC(msg.Resolved)
+		if err != nil {
+			return err
+		}
+		if err := saveCheckpoint(h.checkpointPath, checkpoint{
+			Resolved:  msg.Resolved,
+			UpdatedAt: h.now().UTC(),
+		}); err != nil {
+			return err
+		}
+		h.applier.resolve(resolved)
+		resolvedTimestampMetric.Set(float64(resolved.wallTime) / float64(time.Second))
+		h.logger.Debug().Str("resolved", msg.Resolved).Msgf("checkpointed resolved timestamp")
+	}
+	return nil
+}
+
+// newAuthHeader returns a random Authorization header for the changefeed to
+// authenticate with the webhook sink.
+func newAuthHeader() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "Bearer " + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// sinkURL returns the URL of the webhook sink served at addr. The changefeed
+// verifies the certificate of the sink using certPEM.
+func sinkURL(addr string, certPEM []byte) string {
+	u := url.URL{
+		Scheme:   "webhook-https",
+		Host:     addr,
+		Path:     "/",
+		RawQuery: url.Values{"ca_cert": []string{base64.StdEncoding.EncodeToString(certPEM)}}.Encode(),
+	}
+	return u.String()
+}
+
+// generateCert generates a self-signed certificate for serving the webhook
+// sink at host, as changefeeds only support webhook sinks over HTTPS.
+func generateCert(host string) (tls.Certificate, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"MOLT"}, CommonName: host},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		tmpl.IPAddresses = []net.IP{ip}
+	} else {
+		tmpl.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	return cert, certPEM, nil
+}
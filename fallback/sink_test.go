@@ -0,0 +1,101 @@
+package fallback
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSinkHandler(t *testing.T) {
+	a, err := newApplier(&dbconn.MySQLConn{}, []dbtable.VerifiedTable{applyTestTable})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := &sinkHandler{
+		applier:        a,
+		authHeader:     "Bearer secret",
+		checkpointPath: path,
+		logger:         zerolog.Nop(),
+		now:            func() time.Time { return now },
+	}
+
+	postWithAuth := func(authHeader string, body string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if authHeader != "" {
+			r.Header.Set("Authorization", authHeader)
+		}
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	post := func(body string) int {
+		return postWithAuth("Bearer secret", body)
+	}
+
+	// Requests not from the changefeed are rejected.
+	require.Equal(t, http.StatusUnauthorized, postWithAuth("", `{"resolved":"1704164645000000000.0000000001"}`))
+	require.Equal(t, http.StatusUnauthorized, postWithAuth("Bearer other", `{"resolved":"1704164645000000000.0000000001"}`))
+	_, ok, err := loadCheckpoint(path)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.Equal(t, http.StatusOK, post(`{"resolved":"1704164645000000000.0000000001"}`))
+	require.Equal(t, hlcTimestamp{wallTime: 1704164645000000000, logical: 1}, a.resolved)
+	cp, ok, err := loadCheckpoint(path)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, checkpoint{Resolved: "1704164645000000000.0000000001", UpdatedAt: now}, cp)
+
+	// Changes which cannot be applied are not acknowledged, so that they are
+	// retried by the changefeed.
+	require.Equal(t, http.StatusInternalServerError, post(`{"payload":[{"topic":"defaultdb.public.other","key":[1]}],"length":1}`))
+	require.Equal(t, http.StatusBadRequest, post(`not json`))
+}
+
+func TestParseHLC(t *testing.T) {
+	ts, err := parseHLC("1704164645000000000.0000000001")
+	require.NoError(t, err)
+	require.Equal(t, hlcTimestamp{wallTime: 1704164645000000000, logical: 1}, ts)
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), time.Unix(0, ts.wallTime).UTC())
+	noLogical, err := parseHLC("1704164645000000000")
+	require.NoError(t, err)
+	require.True(t, noLogical.less(ts))
+	require.False(t, ts.less(noLogical))
+	_, err = parseHLC("abc")
+	require.Error(t, err)
+	_, err = parseHLC("1.abc")
+	require.Error(t, err)
+}
+
+func TestSinkCertificate(t *testing.T) {
+	cert, certPEM, err := generateCert("127.0.0.1")
+	require.NoError(t, err)
+
+	u, err := url.Parse(sinkURL("127.0.0.1:30004", certPEM))
+	require.NoError(t, err)
+	require.Equal(t, "webhook-https", u.Scheme)
+	require.Equal(t, "127.0.0.1:30004", u.Host)
+	decoded, err := base64.StdEncoding.DecodeString(u.Query().Get("ca_cert"))
+	require.NoError(t, err)
+	require.Equal(t, certPEM, decoded)
+
+	// The certificate is trusted for the sink's address given the CA
+	// certificate passed to the changefeed.
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(decoded))
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: "127.0.0.1", Roots: pool})
+	require.NoError(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/fetch/datablobstorage"
 	"github.com/cockroachdb/molt/fetch/internal/dataquery"
+	"github.com/cockroachdb/molt/moltlogger"
 	"github.com/rs/zerolog"
 )
 
@@ -31,15 +32,16 @@ func Copy(
 
 	for i, resource := range resources {
 		logger.Debug().
-			Int("idx", i+1).
+			Int(moltlogger.BatchKey, i+1).
 			Msgf("reading resource")
 		if err := func() error {
 			r, err := resource.Reader(ctx)
 			if err != nil {
 				return err
 			}
+			defer func() { _ = r.Close() }()
 			logger.Debug().
-				Int("idx", i+1).
+				Int(moltlogger.BatchKey, i+1).
 				Msgf("running copy from resource")
 			if _, err := conn.PgConn().CopyFrom(
 				ctx,
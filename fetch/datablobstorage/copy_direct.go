@@ -5,9 +5,10 @@ import (
 	"io"
 
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/fetch/internal/dataquery"
-	"github.com/jackc/pgx/v5"
+	"github.com/cockroachdb/molt/moltlogger"
 	"github.com/rs/zerolog"
 )
 
@@ -16,21 +17,21 @@ import (
 // This is only compatible with "COPY", and does not utilise IMPORT.
 type copyCRDBDirect struct {
 	logger zerolog.Logger
-	target *pgx.Conn
+	target *dbconn.PGConn
 }
 
 func (c *copyCRDBDirect) CreateFromReader(
 	ctx context.Context, r io.Reader, table dbtable.VerifiedTable, iteration int, fileExt string,
 ) (Resource, error) {
-	conn, err := pgx.ConnectConfig(ctx, c.target.Config())
+	conn, err := c.target.Clone(ctx)
 	if err != nil {
 		return nil, err
 	}
-	c.logger.Debug().Int("batch", iteration).Msgf("csv batch starting")
-	if _, err := conn.PgConn().CopyFrom(ctx, r, dataquery.CopyFrom(table)); err != nil {
+	c.logger.Debug().Int(moltlogger.BatchKey, iteration).Msgf("csv batch starting")
+	if _, err := conn.(*dbconn.PGConn).PgConn().CopyFrom(ctx, r, dataquery.CopyFrom(table)); err != nil {
 		return nil, errors.CombineErrors(err, conn.Close(ctx))
 	}
-	c.logger.Debug().Int("batch", iteration).Msgf("csv batch complete")
+	c.logger.Debug().Int(moltlogger.BatchKey, iteration).Msgf("csv batch complete")
 	return nil, conn.Close(ctx)
 }
 
@@ -50,7 +51,7 @@ func (c *copyCRDBDirect) TelemetryName() string {
 	return "copy_direct"
 }
 
-func NewCopyCRDBDirect(logger zerolog.Logger, target *pgx.Conn) *copyCRDBDirect {
+func NewCopyCRDBDirect(logger zerolog.Logger, target *dbconn.PGConn) *copyCRDBDirect {
 	return &copyCRDBDirect{
 		logger: logger,
 		target: target,
@@ -25,10 +25,19 @@ type SourceConn interface {
 
 type Settings struct {
 	RowBatchSize int
+	// TableRowBatchSizes overrides RowBatchSize for specific tables.
+	TableRowBatchSizes map[dbtable.Name]int
 
 	PG PGReplicationSlotSettings
 }
 
+func (s Settings) rowBatchSize(name dbtable.Name) int {
+	if n, ok := s.TableRowBatchSizes[name]; ok && n > 0 {
+		return n
+	}
+	return s.RowBatchSize
+}
+
 func InferExportSource(ctx context.Context, settings Settings, conn dbconn.Conn) (Source, error) {
 	switch conn := conn.(type) {
 	case *dbconn.PGConn:
@@ -49,19 +58,58 @@ func scanWithRowIterator(
 	writer io.Writer,
 	table rowiterator.ScanTable,
 ) error {
-	cw := csv.NewWriter(writer)
 	it, err := rowiterator.NewScanIterator(
 		ctx,
 		c,
 		table,
-		settings.RowBatchSize,
+		settings.rowBatchSize(table.Name),
 		nil,
 	)
 	if err != nil {
 		return err
 	}
-	strings := make([]string, 0, len(table.ColumnNames))
-	for it.HasNext(ctx) {
+	_, err = writeCSV(ctx, it, writer, 0)
+	return err
+}
+
+// ExportSample exports up to numRows rows of the table from conn, in the
+// same format as Export, returning the number of rows exported.
+func ExportSample(
+	ctx context.Context, conn dbconn.Conn, writer io.Writer, table dbtable.VerifiedTable, numRows int,
+) (int, error) {
+	// Tables without a primary key are streamed, which stops once ctx is
+	// cancelled.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	it, err := rowiterator.NewScanIterator(
+		ctx,
+		conn,
+		rowiterator.ScanTable{
+			Table: rowiterator.Table{
+				Name:              table.Name,
+				ColumnNames:       table.Columns,
+				ColumnOIDs:        table.ColumnOIDs[0],
+				PrimaryKeyColumns: table.PrimaryKeyColumns,
+			},
+		},
+		numRows,
+		nil,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return writeCSV(ctx, it, writer, numRows)
+}
+
+// writeCSV writes the rows of the iterator as CSV, stopping after limit
+// rows if limit is non-zero. It returns the number of rows written.
+func writeCSV(
+	ctx context.Context, it rowiterator.Iterator, writer io.Writer, limit int,
+) (int, error) {
+	cw := csv.NewWriter(writer)
+	var n int
+	var strings []string
+	for (limit == 0 || n < limit) && it.HasNext(ctx) {
 		strings = strings[:0]
 		datums := it.Next(ctx)
 		for _, d := range datums {
@@ -70,12 +118,13 @@ func scanWithRowIterator(
 			strings = append(strings, f.CloseAndGetString())
 		}
 		if err := cw.Write(strings); err != nil {
-			return err
+			return n, err
 		}
+		n++
 	}
 	if err := it.Error(); err != nil {
-		return err
+		return n, err
 	}
 	cw.Flush()
-	return nil
+	return n, cw.Error()
 }
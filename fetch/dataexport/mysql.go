@@ -2,7 +2,6 @@ package dataexport
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"io"
 
@@ -53,23 +52,24 @@ func (m *mysqlSource) Conn(ctx context.Context) (SourceConn, error) {
 	if err != nil {
 		return nil, err
 	}
-	tx, err := conn.(*dbconn.MySQLConn).BeginTx(ctx, &sql.TxOptions{
-		Isolation: sql.LevelRepeatableRead,
-		ReadOnly:  true,
-	})
-	if err != nil {
-		return nil, errors.CombineErrors(err, conn.Close(ctx))
+	// Clones are a single session, so rows are exported from the snapshot of
+	// the transaction.
+	for _, stmt := range []string{
+		"SET TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+		"START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY",
+	} {
+		if _, err := conn.(*dbconn.MySQLConn).ExecContext(ctx, stmt); err != nil {
+			return nil, errors.CombineErrors(errors.Wrap(err, "error starting export transaction"), conn.Close(ctx))
+		}
 	}
 	return &mysqlConn{
 		conn: conn,
-		tx:   tx,
 		src:  m,
 	}, nil
 }
 
 type mysqlConn struct {
 	conn dbconn.Conn
-	tx   *sql.Tx
 	src  *mysqlSource
 }
 
@@ -87,5 +87,6 @@ func (m *mysqlConn) Export(
 }
 
 func (m *mysqlConn) Close(ctx context.Context) error {
-	return m.conn.Close(ctx)
+	_, err := m.conn.(*dbconn.MySQLConn).ExecContext(ctx, "ROLLBACK")
+	return errors.CombineErrors(err, m.conn.Close(ctx))
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/fetch/datablobstorage"
 	"github.com/cockroachdb/molt/fetch/dataexport"
+	"github.com/cockroachdb/molt/moltlogger"
 	"github.com/rs/zerolog"
 	"golang.org/x/sync/errgroup"
 )
@@ -82,12 +83,14 @@ func exportTable(
 		go func() {
 			defer resourceWG.Done()
 			itNum++
+			logger := logger.With().Int(moltlogger.BatchKey, itNum).Logger()
 			if err := func() error {
 				resource, err := datasource.CreateFromReader(ctx, forwardRead, table, itNum, importFileExt)
 				if err != nil {
 					return err
 				}
 				ret.Resources = append(ret.Resources, resource)
+				logger.Debug().Msgf("batch written to data store")
 				return nil
 			}(); err != nil {
 				logger.Err(err).Msgf("error during data store write")
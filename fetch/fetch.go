@@ -11,6 +11,7 @@ import (
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/fetch/datablobstorage"
 	"github.com/cockroachdb/molt/fetch/dataexport"
+	"github.com/cockroachdb/molt/moltlogger"
 	"github.com/cockroachdb/molt/molttelemetry"
 	"github.com/cockroachdb/molt/verify/dbverify"
 	"github.com/cockroachdb/molt/verify/tableverify"
@@ -53,10 +54,18 @@ func Fetch(
 		}()
 	}
 
-	if err := dbconn.RegisterTelemetry(conns); err != nil {
+	t, err := newTarget(cfg, conns[0], conns[1], logger)
+	if err != nil {
 		return err
 	}
-	reportTelemetry(logger, cfg, conns, blobStore)
+	// Fetching between PostgreSQL or MySQL databases, e.g. to rehearse a
+	// migration, does not involve CockroachDB.
+	if conns[0].IsCockroach() || conns[1].IsCockroach() {
+		if err := dbconn.RegisterTelemetry(conns); err != nil {
+			return err
+		}
+		reportTelemetry(logger, conns, blobStore, t)
+	}
 
 	logger.Debug().
 		Int("flush_size", cfg.FlushSize).
@@ -90,7 +99,7 @@ func Fetch(
 	}
 
 	logger.Info().Msgf("verifying common tables")
-	tables, err := tableverify.VerifyCommonTables(ctx, conns, dbTables.Verified)
+	tables, err := tableverify.VerifyCommonTables(ctx, conns, dbTables.Verified, tableverify.ColumnFilter{})
 	if err != nil {
 		return err
 	}
@@ -166,7 +175,7 @@ func fetchTable(
 	table tableverify.Result,
 ) error {
 	tableStartTime := time.Now()
-	logger = logger.With().Str("table", table.SafeString()).Logger()
+	logger = logger.With().Str(moltlogger.TableKey, table.SafeString()).Logger()
 
 	for _, col := range table.MismatchingTableDefinitions {
 		logger.Warn().
@@ -177,10 +186,14 @@ func fetchTable(
 		logger.Error().Msgf("table %s do not have matching primary keys, cannot migrate", table.SafeString())
 		return nil
 	}
+	if table.RowKey == tableverify.RowKeyNone {
+		logger.Warn().Msgf("table %s has no primary key or unique index, so rows are exported in no particular order", table.SafeString())
+	}
 
-	logger.Info().Msgf("data extraction phase starting")
+	exportLogger := logger.With().Str(moltlogger.PhaseKey, moltlogger.PhaseExport).Logger()
+	exportLogger.Info().Msgf("data extraction phase starting")
 
-	e, err := exportTable(ctx, cfg, logger, sqlSrc, blobStore, table.VerifiedTable)
+	e, err := exportTable(ctx, cfg, exportLogger, sqlSrc, blobStore, table.VerifiedTable)
 	if err != nil {
 		return err
 	}
@@ -195,22 +208,26 @@ func fetchTable(
 		}()
 	}
 
-	logger.Info().
+	exportLogger.Info().
 		Int("num_rows", e.NumRows).
 		Dur("export_duration", e.EndTime.Sub(e.StartTime)).
 		Msgf("data extraction from source complete")
 
 	if blobStore.CanBeTarget() {
+		logger := logger.With().Str(moltlogger.PhaseKey, moltlogger.PhaseImport).Logger()
 		targetConn, err := conns[1].Clone(ctx)
 		if err != nil {
 			return err
 		}
 		var importDuration time.Duration
 		if err := func() error {
+			t, err := newTarget(cfg, conns[0], targetConn, logger)
+			if err != nil {
+				return err
+			}
 			if cfg.Truncate {
 				logger.Info().Msgf("truncating table")
-				_, err := targetConn.(*dbconn.PGConn).Conn.Exec(ctx, "TRUNCATE TABLE "+table.SafeString())
-				if err != nil {
+				if err := t.Truncate(ctx, table.VerifiedTable); err != nil {
 					return err
 				}
 			}
@@ -218,19 +235,11 @@ func fetchTable(
 			logger.Info().
 				Msgf("starting data import on target")
 
-			if !cfg.Live {
-				r, err := importTable(ctx, cfg, targetConn, logger, table.VerifiedTable, e.Resources)
-				if err != nil {
-					return err
-				}
-				importDuration = r.EndTime.Sub(r.StartTime)
-			} else {
-				r, err := Copy(ctx, targetConn, logger, table.VerifiedTable, e.Resources)
-				if err != nil {
-					return err
-				}
-				importDuration = r.EndTime.Sub(r.StartTime)
+			r, err := t.Load(ctx, table.VerifiedTable, e.Resources)
+			if err != nil {
+				return err
 			}
+			importDuration = r.EndTime.Sub(r.StartTime)
 			return nil
 		}(); err != nil {
 			return errors.CombineErrors(err, targetConn.Close(ctx))
@@ -249,7 +258,7 @@ func fetchTable(
 }
 
 func reportTelemetry(
-	logger zerolog.Logger, cfg Config, conns dbconn.OrderedConns, store datablobstorage.Store,
+	logger zerolog.Logger, conns dbconn.OrderedConns, store datablobstorage.Store, t target,
 ) {
 	dialect := "CockroachDB"
 	for _, conn := range conns {
@@ -258,14 +267,10 @@ func reportTelemetry(
 			break
 		}
 	}
-	ingestMethod := "import"
-	if cfg.Live {
-		ingestMethod = "copy"
-	}
 	molttelemetry.ReportTelemetryAsync(
 		logger,
 		"molt_fetch_dialect_"+dialect,
-		"molt_fetch_ingest_method_"+ingestMethod,
+		"molt_fetch_ingest_method_"+t.TelemetryName(),
 		"molt_fetch_blobstore_"+store.TelemetryName(),
 	)
 }
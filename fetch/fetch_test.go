@@ -85,7 +85,7 @@ func TestDataDriven(t *testing.T) {
 						require.NoError(t, err)
 						var src datablobstorage.Store
 						if direct {
-							src = datablobstorage.NewCopyCRDBDirect(logger, conns[1].(*dbconn.PGConn).Conn)
+							src = datablobstorage.NewCopyCRDBDirect(logger, conns[1].(*dbconn.PGConn))
 						} else {
 							src, err = datablobstorage.NewLocalStore(logger, dir, "localhost:4040", "localhost:4040")
 							require.NoError(t, err)
@@ -0,0 +1,221 @@
+package fetch
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/datablobstorage"
+	"github.com/cockroachdb/molt/moltlogger"
+	"github.com/cockroachdb/molt/mysqlconv"
+	"github.com/cockroachdb/molt/parsectx"
+	"github.com/rs/zerolog"
+)
+
+// mysqlInsertBatchRows is the maximum number of rows inserted by each
+// INSERT statement on MySQL.
+const mysqlInsertBatchRows = 1000
+
+// mysqlMaxPlaceholders is the maximum number of placeholders MySQL allows in
+// a prepared statement.
+const mysqlMaxPlaceholders = 65535
+
+type mysqlInsertTarget struct {
+	cfg    Config
+	conn   *dbconn.MySQLConn
+	logger zerolog.Logger
+	// pgSource is set if data is exported from PostgreSQL or CockroachDB,
+	// in which case values are converted from the text format of the
+	// source column type.
+	pgSource bool
+}
+
+func (t *mysqlInsertTarget) Truncate(ctx context.Context, table dbtable.VerifiedTable) error {
+	_, err := t.conn.ExecContext(ctx, "TRUNCATE TABLE "+mysqlconv.QuoteIdent(string(table.Table)))
+	return err
+}
+
+// Load inserts the rows of each resource in batches of multi-row INSERTs.
+// Each resource is inserted in a transaction. As with COPY FROM in CSV
+// format, empty values are inserted as NULL. Values exported from PostgreSQL
+// or CockroachDB (e.g. booleans as t/f and arrays as {...}) are converted to
+// MySQL values as done when falling back.
+func (t *mysqlInsertTarget) Load(
+	ctx context.Context, table dbtable.VerifiedTable, resources []datablobstorage.Resource,
+) (importResult, error) {
+	ret := importResult{
+		StartTime: time.Now(),
+	}
+	toValue := csvValue
+	if t.pgSource {
+		toValue = pgCSVValue(table)
+	}
+	for i, resource := range decompressResources(resources, t.cfg.Compression) {
+		t.logger.Debug().
+			Int(moltlogger.BatchKey, i+1).
+			Msgf("inserting rows from resource")
+		if err := func() error {
+			r, err := resource.Reader(ctx)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = r.Close() }()
+			tx, err := t.conn.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			if _, err := insertCSV(ctx, r, table, mysqlBatchRows(len(table.Columns)), toValue, func(query string, args []any) error {
+				_, err := tx.ExecContext(ctx, query, args...)
+				return err
+			}); err != nil {
+				return errors.CombineErrors(err, rollback(tx))
+			}
+			return tx.Commit()
+		}(); err != nil {
+			return ret, errors.Wrap(err, "error inserting data")
+		}
+	}
+	ret.EndTime = time.Now()
+	t.logger.Info().
+		Dur("duration", ret.EndTime.Sub(ret.StartTime)).
+		Msgf("table INSERT complete")
+	return ret, nil
+}
+
+func (t *mysqlInsertTarget) TelemetryName() string {
+	return "insert"
+}
+
+func rollback(tx *sql.Tx) error {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return err
+	}
+	return nil
+}
+
+// mysqlBatchRows returns the number of rows to insert at a time, such that
+// the placeholder limit is not exceeded.
+func mysqlBatchRows(numCols int) int {
+	if numCols == 0 || numCols*mysqlInsertBatchRows <= mysqlMaxPlaceholders {
+		return mysqlInsertBatchRows
+	}
+	if numCols > mysqlMaxPlaceholders {
+		return 1
+	}
+	return mysqlMaxPlaceholders / numCols
+}
+
+// csvValue inserts a CSV value as is.
+func csvValue(_ int, val string) (any, error) {
+	return val, nil
+}
+
+// pgCSVValue returns a function converting a CSV value exported from
+// PostgreSQL or CockroachDB, which is in the text format of the source column
+// type, into a value of the MySQL column type.
+func pgCSVValue(table dbtable.VerifiedTable) func(col int, val string) (any, error) {
+	return func(col int, val string) (any, error) {
+		typ, ok := types.OidToType[table.ColumnOIDs[0][col]]
+		if !ok {
+			// User defined types, i.e. enums, are exported as their label.
+			typ = types.String
+		}
+		d, _, err := tree.ParseAndRequireString(typ, val, parsectx.ParseContext)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing value of column %s", table.Columns[col])
+		}
+		return mysqlconv.ConvertDatumToValue(d, table.ColumnOIDs[1][col])
+	}
+}
+
+// insertCSV reads CSV rows from r, calling exec with a multi-row INSERT of at
+// most batchRows rows at a time, with each value converted by toValue. It
+// returns the number of rows inserted.
+func insertCSV(
+	ctx context.Context,
+	r io.Reader,
+	table dbtable.VerifiedTable,
+	batchRows int,
+	toValue func(col int, val string) (any, error),
+	exec func(query string, args []any) error,
+) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(table.Columns)
+	numRows := 0
+	args := make([]any, 0, batchRows*len(table.Columns))
+	flush := func(n int) error {
+		if n == 0 {
+			return nil
+		}
+		if err := exec(mysqlInsertStatement(table, n), args); err != nil {
+			return err
+		}
+		numRows += n
+		args = args[:0]
+		return nil
+	}
+	batched := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return numRows, err
+		}
+		record, err := cr.Read()
+		if err != nil {
+			if err == io.EOF {
+				return numRows, flush(batched)
+			}
+			return numRows, err
+		}
+		for i, val := range record {
+			if val == "" {
+				args = append(args, nil)
+				continue
+			}
+			v, err := toValue(i, val)
+			if err != nil {
+				return numRows, err
+			}
+			args = append(args, v)
+		}
+		batched++
+		if batched >= batchRows {
+			if err := flush(batched); err != nil {
+				return numRows, err
+			}
+			batched = 0
+		}
+	}
+}
+
+// mysqlInsertStatement returns an INSERT statement of numRows rows into table,
+// with a placeholder for each value. Tables on MySQL are given a fake public
+// schema, so only the table name is used.
+func mysqlInsertStatement(table dbtable.VerifiedTable, numRows int) string {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(mysqlconv.QuoteIdent(string(table.Table)))
+	sb.WriteString(" (")
+	for i, col := range table.Columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(mysqlconv.QuoteIdent(string(col)))
+	}
+	sb.WriteString(") VALUES ")
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(table.Columns)), ", ") + ")"
+	for i := 0; i < numRows; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(row)
+	}
+	return sb.String()
+}
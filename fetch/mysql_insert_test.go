@@ -0,0 +1,100 @@
+package fetch
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/lib/pq/oid"
+	"github.com/stretchr/testify/require"
+)
+
+var mysqlInsertTestTable = dbtable.VerifiedTable{
+	Name:    dbtable.Name{Schema: "public", Table: "tbl"},
+	Columns: []tree.Name{"id", "t`xt"},
+}
+
+func TestMySQLInsertStatement(t *testing.T) {
+	require.Equal(
+		t,
+		"INSERT INTO `tbl` (`id`, `t``xt`) VALUES (?, ?), (?, ?)",
+		mysqlInsertStatement(mysqlInsertTestTable, 2),
+	)
+}
+
+func TestMySQLBatchRows(t *testing.T) {
+	require.Equal(t, mysqlInsertBatchRows, mysqlBatchRows(2))
+	require.Equal(t, mysqlMaxPlaceholders/100, mysqlBatchRows(100))
+	require.Equal(t, 1, mysqlBatchRows(mysqlMaxPlaceholders+1))
+}
+
+func TestInsertCSV(t *testing.T) {
+	type insert struct {
+		query string
+		args  []any
+	}
+	var inserts []insert
+	n, err := insertCSV(
+		context.Background(),
+		strings.NewReader("1,a\n2,\n3,\"c,d\"\n"),
+		mysqlInsertTestTable,
+		2,
+		csvValue,
+		func(query string, args []any) error {
+			inserts = append(inserts, insert{query: query, args: append([]any(nil), args...)})
+			return nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []insert{
+		{
+			query: "INSERT INTO `tbl` (`id`, `t``xt`) VALUES (?, ?), (?, ?)",
+			args:  []any{"1", "a", "2", nil},
+		},
+		{
+			query: "INSERT INTO `tbl` (`id`, `t``xt`) VALUES (?, ?)",
+			args:  []any{"3", "c,d"},
+		},
+	}, inserts)
+
+	_, err = insertCSV(
+		context.Background(),
+		strings.NewReader("1,a,b\n"),
+		mysqlInsertTestTable,
+		2,
+		csvValue,
+		func(query string, args []any) error { return nil },
+	)
+	require.Error(t, err)
+}
+
+func TestPGCSVValue(t *testing.T) {
+	table := dbtable.VerifiedTable{
+		Name:    dbtable.Name{Schema: "public", Table: "tbl"},
+		Columns: []tree.Name{"b", "ts", "arr", "e", "i"},
+		ColumnOIDs: [2][]oid.Oid{
+			{oid.T_bool, oid.T_timestamptz, oid.T__int8, 100000, oid.T_int8},
+			{oid.T_int2, oid.T_timestamp, oid.T_json, oid.T_text, oid.T_int8},
+		},
+	}
+	toValue := pgCSVValue(table)
+	for i, tc := range []struct {
+		val      string
+		expected any
+	}{
+		{val: "t", expected: int64(1)},
+		{val: "2024-01-02 03:04:05.123456+00", expected: "2024-01-02 03:04:05.123456+00:00"},
+		{val: "{1,2}", expected: "[1, 2]"},
+		{val: "label", expected: "label"},
+		{val: "42", expected: int64(42)},
+	} {
+		v, err := toValue(i, tc.val)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, v, "column %s", table.Columns[i])
+	}
+	_, err := toValue(0, "maybe")
+	require.ErrorContains(t, err, "error parsing value of column b")
+}
@@ -0,0 +1,129 @@
+package fetch
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/compression"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/datablobstorage"
+	"github.com/rs/zerolog"
+)
+
+// target imports the data exported from the source into tables on the
+// target database.
+type target interface {
+	// Truncate deletes all rows of the table.
+	Truncate(ctx context.Context, table dbtable.VerifiedTable) error
+	// Load imports the rows of the resources into the table.
+	Load(ctx context.Context, table dbtable.VerifiedTable, resources []datablobstorage.Resource) (importResult, error)
+	// TelemetryName is the name of the ingest method reported to telemetry.
+	TelemetryName() string
+}
+
+// newTarget returns the target for importing data exported from source using
+// conn. CockroachDB uses IMPORT INTO unless the table must stay queryable
+// during the import, in which case COPY FROM is used as it is on PostgreSQL.
+// MySQL uses batched INSERTs.
+func newTarget(
+	cfg Config, source dbconn.Conn, conn dbconn.Conn, logger zerolog.Logger,
+) (target, error) {
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		if conn.IsCockroach() && !cfg.Live {
+			return &crdbImportTarget{cfg: cfg, conn: conn, logger: logger}, nil
+		}
+		return &pgCopyTarget{cfg: cfg, conn: conn, logger: logger}, nil
+	case *dbconn.MySQLConn:
+		_, pgSource := source.(*dbconn.PGConn)
+		return &mysqlInsertTarget{cfg: cfg, conn: conn, logger: logger, pgSource: pgSource}, nil
+	}
+	return nil, errors.AssertionFailedf("unknown conn type: %T", conn)
+}
+
+type crdbImportTarget struct {
+	cfg    Config
+	conn   *dbconn.PGConn
+	logger zerolog.Logger
+}
+
+func (t *crdbImportTarget) Truncate(ctx context.Context, table dbtable.VerifiedTable) error {
+	_, err := t.conn.Exec(ctx, "TRUNCATE TABLE "+table.SafeString())
+	return err
+}
+
+func (t *crdbImportTarget) Load(
+	ctx context.Context, table dbtable.VerifiedTable, resources []datablobstorage.Resource,
+) (importResult, error) {
+	return importTable(ctx, t.cfg, t.conn, t.logger, table, resources)
+}
+
+func (t *crdbImportTarget) TelemetryName() string {
+	return "import"
+}
+
+type pgCopyTarget struct {
+	cfg    Config
+	conn   *dbconn.PGConn
+	logger zerolog.Logger
+}
+
+func (t *pgCopyTarget) Truncate(ctx context.Context, table dbtable.VerifiedTable) error {
+	_, err := t.conn.Exec(ctx, "TRUNCATE TABLE "+table.SafeString())
+	return err
+}
+
+func (t *pgCopyTarget) Load(
+	ctx context.Context, table dbtable.VerifiedTable, resources []datablobstorage.Resource,
+) (importResult, error) {
+	r, err := Copy(ctx, t.conn, t.logger, table, decompressResources(resources, t.cfg.Compression))
+	return importResult(r), err
+}
+
+func (t *pgCopyTarget) TelemetryName() string {
+	return "copy"
+}
+
+// decompressResources wraps resources such that they are read uncompressed,
+// for targets which cannot decompress data themselves.
+func decompressResources(
+	resources []datablobstorage.Resource, compressionType compression.Flag,
+) []datablobstorage.Resource {
+	if compressionType != compression.GZIP {
+		return resources
+	}
+	ret := make([]datablobstorage.Resource, len(resources))
+	for i, r := range resources {
+		ret[i] = gzipResource{Resource: r}
+	}
+	return ret
+}
+
+// gzipResource is a resource which is decompressed when read.
+type gzipResource struct {
+	datablobstorage.Resource
+}
+
+func (r gzipResource) Reader(ctx context.Context) (io.ReadCloser, error) {
+	rc, err := r.Resource.Reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gr, err := gzip.NewReader(rc)
+	if err != nil {
+		return nil, errors.CombineErrors(errors.Wrap(err, "error decompressing resource"), rc.Close())
+	}
+	return &gzipReadCloser{Reader: gr, underlying: rc}, nil
+}
+
+type gzipReadCloser struct {
+	*gzip.Reader
+	underlying io.ReadCloser
+}
+
+func (r *gzipReadCloser) Close() error {
+	return errors.CombineErrors(r.Reader.Close(), r.underlying.Close())
+}
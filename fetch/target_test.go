@@ -0,0 +1,48 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/cockroachdb/molt/compression"
+	"github.com/cockroachdb/molt/fetch/datablobstorage"
+	"github.com/stretchr/testify/require"
+)
+
+type bytesResource struct {
+	b []byte
+}
+
+func (r bytesResource) ImportURL() (string, error) {
+	return "", nil
+}
+
+func (r bytesResource) MarkForCleanup(ctx context.Context) error {
+	return nil
+}
+
+func (r bytesResource) Reader(ctx context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(r.b)), nil
+}
+
+func TestDecompressResources(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte("1,a\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	res := decompressResources([]datablobstorage.Resource{bytesResource{b: buf.Bytes()}}, compression.GZIP)
+	r, err := res[0].Reader(context.Background())
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "1,a\n", string(b))
+
+	uncompressed := []datablobstorage.Resource{bytesResource{b: []byte("1,a\n")}}
+	require.Equal(t, uncompressed, decompressResources(uncompressed, compression.None))
+}
@@ -22,11 +22,14 @@ require (
 	github.com/rs/zerolog v1.29.1
 	github.com/sijms/go-ora/v2 v2.7.13
 	github.com/spf13/cobra v1.7.0
+	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.8.1
 	github.com/thediveo/enumflag/v2 v2.0.4
 	golang.org/x/oauth2 v0.8.0
 	golang.org/x/sync v0.2.0
 	golang.org/x/time v0.0.0-20201208040808-7e3f01d25324
+	gopkg.in/natefinch/lumberjack.v2 v2.0.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -90,7 +93,6 @@ require (
 	github.com/rogpeppe/go-internal v1.8.1 // indirect
 	github.com/sasha-s/go-deadlock v0.3.1 // indirect
 	github.com/shirou/gopsutil v3.21.2+incompatible // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/tikv/client-go/v2 v2.0.0-alpha.0.20211029104011-2fd3841894de // indirect
 	github.com/tikv/pd v1.1.0-beta.0.20211104095303-69c86d05d379 // indirect
 	github.com/twpayne/go-geom v1.4.1 // indirect
@@ -117,6 +119,4 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20230530153820-e85fd2cbaebc // indirect
 	google.golang.org/grpc v1.55.0 // indirect
 	google.golang.org/protobuf v1.30.0 // indirect
-	gopkg.in/natefinch/lumberjack.v2 v2.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,25 @@
+// Package moltlogger defines the structured log fields shared across
+// commands, so that events for the same run, table or shard can be
+// correlated.
+package moltlogger
+
+const (
+	// RunIDKey identifies a single invocation of a command.
+	RunIDKey = "run_id"
+	// PhaseKey is the phase of the command an event belongs to.
+	PhaseKey = "phase"
+	// TableKey is the table an event belongs to, as schema.table.
+	TableKey = "table"
+	// ShardKey is the shard of a table an event belongs to, as num/total.
+	ShardKey = "shard"
+	// BatchKey is the batch of rows of a table an event belongs to,
+	// numbered from 1.
+	BatchKey = "batch"
+)
+
+// Phases of fetch and verify.
+const (
+	PhaseExport = "export"
+	PhaseImport = "import"
+	PhaseVerify = "verify"
+)
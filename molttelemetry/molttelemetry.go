@@ -10,14 +10,16 @@ import (
 	"github.com/rs/zerolog"
 )
 
-var connStr string
+var connConfig *pgx.ConnConfig
 
-// RegisterConnString registers a connection string to Cockroach for telemetry,
-// returning a function which undoes the global state.
-func RegisterConnString(str string) func() {
-	old := connStr
-	connStr = str
-	return func() { connStr = old }
+// RegisterConnConfig registers the config of a connection to Cockroach for
+// telemetry, returning a function which undoes the global state. A config is
+// registered instead of a connection string so that the password is never
+// part of a string which could be logged.
+func RegisterConnConfig(cfg *pgx.ConnConfig) func() {
+	old := connConfig
+	connConfig = cfg.Copy()
+	return func() { connConfig = old }
 }
 
 // ReportTelemetryAsync reports telemetry asynchronously.
@@ -32,16 +34,16 @@ func ReportTelemetryAsync(logger zerolog.Logger, telemetry ...string) {
 }
 
 // ReportTelemetry reports telemetry using crdb_internal.increment_feature_counter.
-// RegisterConnString must be called beforehand.
+// RegisterConnConfig must be called beforehand.
 func ReportTelemetry(telemetry ...string) error {
 	if len(telemetry) == 0 {
 		return nil
 	}
-	if connStr == "" {
-		return errors.AssertionFailedf("telemetry called without a registered conn string")
+	if connConfig == nil {
+		return errors.AssertionFailedf("telemetry called without a registered conn config")
 	}
 	ctx := context.Background()
-	conn, err := pgx.Connect(ctx, connStr)
+	conn, err := pgx.ConnectConfig(ctx, connConfig)
 	if err != nil {
 		return err
 	}
@@ -26,7 +26,9 @@ func TestReportTelemetry(t *testing.T) {
 			require.NoError(t, err)
 
 			before := countTelemetry(t, conn)
-			defer molttelemetry.RegisterConnString(connStr)()
+			cfg, err := pgx.ParseConfig(connStr)
+			require.NoError(t, err)
+			defer molttelemetry.RegisterConnConfig(cfg)()
 			require.NoError(t, molttelemetry.ReportTelemetry(tc.telemetry...))
 			after := countTelemetry(t, conn)
 			for _, it := range tc.telemetry {
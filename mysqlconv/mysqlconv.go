@@ -2,6 +2,7 @@ package mysqlconv
 
 import (
 	"database/sql"
+	"strings"
 
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -41,3 +42,8 @@ func ScanRowDynamicTypes(
 	}
 	return ConvertRowValues(typMap, vals, typOIDs)
 }
+
+// QuoteIdent quotes an identifier for MySQL.
+func QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
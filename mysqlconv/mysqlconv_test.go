@@ -0,0 +1,13 @@
+package mysqlconv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	require.Equal(t, "`tbl`", QuoteIdent("tbl"))
+	require.Equal(t, "`pk only`", QuoteIdent("pk only"))
+	require.Equal(t, "`a``b`", QuoteIdent("a`b"))
+}
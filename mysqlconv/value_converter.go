@@ -0,0 +1,66 @@
+package mysqlconv
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sessiondatapb"
+	"github.com/cockroachdb/errors"
+	"github.com/lib/pq/oid"
+)
+
+// mysqlTimeFormat is the format of DATETIME and TIMESTAMP values on MySQL.
+const mysqlTimeFormat = "2006-01-02 15:04:05.999999"
+
+// ConvertDatumToValue converts a datum into a value which can be written to a
+// MySQL column of the given type. This is the reverse of ConvertRowValue.
+func ConvertDatumToValue(d tree.Datum, typOID oid.Oid) (any, error) {
+	if d == tree.DNull {
+		return nil, nil
+	}
+	switch d := d.(type) {
+	case *tree.DBool:
+		// MySQL has no boolean type, using TINYINT(1) instead.
+		if *d {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case *tree.DString:
+		return string(*d), nil
+	case *tree.DJSON:
+		return d.JSON.String(), nil
+	case *tree.DInt:
+		return int64(*d), nil
+	case *tree.DFloat:
+		return float64(*d), nil
+	case *tree.DBytes:
+		return []byte(*d), nil
+	case *tree.DTimestamp:
+		return d.Time.Format(mysqlTimeFormat), nil
+	case *tree.DTimestampTZ:
+		// The offset is given so the value does not depend on the session
+		// time zone, which is supported from MySQL 8.0.19.
+		return d.Time.UTC().Format(mysqlTimeFormat) + "+00:00", nil
+	case *tree.DArray:
+		// MySQL has no arrays, so they are written as JSON arrays.
+		j, err := tree.AsJSON(d, sessiondatapb.DataConversionConfig{}, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		return j.String(), nil
+	case *tree.DBitArray:
+		// BIT columns are written as the integer of their bits.
+		if typOID == oid.T_varbit || typOID == oid.T_bit {
+			s := d.BitArray.String()
+			if len(s) > 64 {
+				return nil, errors.Newf("bit array of length %d is too long for MySQL", len(s))
+			}
+			if s == "" {
+				return uint64(0), nil
+			}
+			return strconv.ParseUint(s, 2, 64)
+		}
+	}
+	return tree.AsStringWithFlags(d, tree.FmtBareStrings), nil
+}
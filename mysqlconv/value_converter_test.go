@@ -0,0 +1,53 @@
+package mysqlconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/json"
+	"github.com/lib/pq/oid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertDatumToValue(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	bits, err := tree.ParseDBitArray("101")
+	require.NoError(t, err)
+	dec, err := tree.ParseDDecimal("1.50")
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		desc     string
+		d        tree.Datum
+		typOID   oid.Oid
+		expected any
+	}{
+		{desc: "null", d: tree.DNull, typOID: oid.T_int4, expected: nil},
+		{desc: "bool", d: tree.DBoolTrue, typOID: oid.T_int2, expected: int64(1)},
+		{desc: "int", d: tree.NewDInt(12), typOID: oid.T_int4, expected: int64(12)},
+		{desc: "decimal", d: dec, typOID: oid.T_numeric, expected: "1.50"},
+		{desc: "string", d: tree.NewDString("a'b"), typOID: oid.T_text, expected: "a'b"},
+		{desc: "json", d: tree.NewDJSON(func() json.JSON {
+			j, err := json.ParseJSON(`{"a": "b'c"}`)
+			require.NoError(t, err)
+			return j
+		}()), typOID: oid.T_jsonb, expected: `{"a": "b'c"}`},
+		{desc: "bytes", d: tree.NewDBytes("hi"), typOID: oid.T_bytea, expected: []byte("hi")},
+		{desc: "array", d: func() tree.Datum {
+			arr := tree.NewDArray(types.Int)
+			require.NoError(t, arr.Append(tree.NewDInt(1)))
+			require.NoError(t, arr.Append(tree.DNull))
+			return arr
+		}(), typOID: oid.T_json, expected: "[1, null]"},
+		{desc: "bits", d: bits, typOID: oid.T_varbit, expected: uint64(5)},
+		{desc: "timestamp", d: tree.MustMakeDTimestamp(ts, time.Microsecond), typOID: oid.T_timestamp, expected: "2024-01-02 03:04:05.123456"},
+		{desc: "timestamptz", d: tree.MustMakeDTimestampTZ(ts, time.Microsecond), typOID: oid.T_timestamptz, expected: "2024-01-02 03:04:05.123456+00:00"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			v, err := ConvertDatumToValue(tc.d, tc.typOID)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+}
@@ -36,7 +36,7 @@ func ParseDSN(connStr string) (*mysqldriver.Config, error) {
 	byProtocol := strings.SplitN(connStr, "://", 2)
 	cfg, err := mysqldriver.ParseDSN(byProtocol[len(byProtocol)-1])
 	if err != nil {
-		return nil, errors.Wrapf(err, "error parsing DSN for %q", connStr)
+		return nil, errors.Wrap(err, "error parsing DSN")
 	}
 	return cfg, nil
 }
@@ -45,7 +45,7 @@ func ParseConnStr(connStr string) (*mysqldriver.Config, error) {
 	cfg := mysqldriver.NewConfig()
 	url, err := url.Parse(connStr)
 	if err != nil {
-		return nil, errors.Wrapf(err, "error parsing conn str for %q", connStr)
+		return nil, errors.Wrap(withoutURL(err), "error parsing conn str")
 	}
 	cfg.Net = "tcp" // By default the go-sql-driver uses tcp
 	cfg.Addr = url.Host
@@ -54,13 +54,13 @@ func ParseConnStr(connStr string) (*mysqldriver.Config, error) {
 	cfg.DBName = url.EscapedPath()[1:] // Slice from after the '/'
 	params := url.Query()
 	if err = parseDSNParams(cfg, params); err != nil {
-		return nil, errors.Wrapf(err, "error parsing conn str for %q", connStr)
+		return nil, errors.Wrap(err, "error parsing conn str")
 	}
 	cfgNew := cfg.FormatDSN()
 	// We reparse it with the driver to normalize any fields
 	cfg, err = mysqldriver.ParseDSN(cfgNew)
 	if err != nil {
-		return nil, errors.Wrapf(err, "error parsing conn str for %q", cfgNew)
+		return nil, errors.Wrap(err, "error parsing conn str")
 	}
 
 	if params.Has("sslmode") {
@@ -89,6 +89,16 @@ func ParseConnStr(connStr string) (*mysqldriver.Config, error) {
 	return cfg, nil
 }
 
+// withoutURL returns the error of parsing a URL without the URL, which may
+// contain a password.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
 func CfgToConnStr(cfg *mysqldriver.Config, queryEscape bool) string {
 	u := url.URL{
 		Scheme: "mysql",
@@ -0,0 +1,22 @@
+package pgconv
+
+import (
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lib/pq/oid"
+)
+
+// ConvertDatumToValue converts a datum into a value which can be written to a
+// PostgreSQL column of the given type. This is the reverse of ConvertRowValue.
+// Values are given in the text format, which pgx sends as is for any type.
+func ConvertDatumToValue(d tree.Datum, typOID oid.Oid) (any, error) {
+	if d == tree.DNull {
+		return nil, nil
+	}
+	if d, ok := d.(*tree.DTimestampTZ); ok && typOID == pgtype.TimestampOID {
+		// Timestamps without a time zone would otherwise ignore the offset,
+		// so convert them to UTC.
+		return d.Time.UTC().Format("2006-01-02 15:04:05.999999"), nil
+	}
+	return tree.AsStringWithFlags(d, tree.FmtPgwireText), nil
+}
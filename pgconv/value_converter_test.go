@@ -0,0 +1,45 @@
+package pgconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/json"
+	"github.com/lib/pq/oid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertDatumToValue(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	for _, tc := range []struct {
+		desc     string
+		d        tree.Datum
+		typOID   oid.Oid
+		expected any
+	}{
+		{desc: "null", d: tree.DNull, typOID: oid.T_int4, expected: nil},
+		{desc: "int", d: tree.NewDInt(12), typOID: oid.T_int4, expected: "12"},
+		{desc: "string", d: tree.NewDString("a'b"), typOID: oid.T_text, expected: "a'b"},
+		{desc: "json", d: tree.NewDJSON(func() json.JSON {
+			j, err := json.ParseJSON(`{"a": "b'c"}`)
+			require.NoError(t, err)
+			return j
+		}()), typOID: oid.T_jsonb, expected: `{"a": "b'c"}`},
+		{desc: "bytes", d: tree.NewDBytes("hi"), typOID: oid.T_bytea, expected: `\x6869`},
+		{desc: "array", d: func() tree.Datum {
+			arr := tree.NewDArray(tree.NewDString("").ResolvedType())
+			require.NoError(t, arr.Append(tree.NewDString("a b")))
+			require.NoError(t, arr.Append(tree.DNull))
+			return arr
+		}(), typOID: oid.T__text, expected: `{"a b",NULL}`},
+		{desc: "timestamptz", d: tree.MustMakeDTimestampTZ(ts, time.Microsecond), typOID: oid.T_timestamptz, expected: "2024-01-02 03:04:05.123456+00"},
+		{desc: "timestamptz to timestamp", d: tree.MustMakeDTimestampTZ(ts, time.Microsecond), typOID: oid.T_timestamp, expected: "2024-01-02 03:04:05.123456"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			v, err := ConvertDatumToValue(tc.d, tc.typOID)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+}
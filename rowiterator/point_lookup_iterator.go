@@ -23,15 +23,19 @@ type pointLookupIterator struct {
 	cacheCursor int
 	ran         bool
 
-	err error
+	err  error
+	opts iteratorOpts
 }
 
 // NewPointLookupIterator returns a row iterator does a point lookup.
-func NewPointLookupIterator(conn dbconn.Conn, table Table, pks []tree.Datums) Iterator {
+func NewPointLookupIterator(
+	conn dbconn.Conn, table Table, pks []tree.Datums, opts ...IteratorOpt,
+) Iterator {
 	it := &pointLookupIterator{
 		conn:  conn,
 		table: table,
 		pks:   pks,
+		opts:  makeIteratorOpts(opts),
 	}
 	return it
 }
@@ -44,6 +48,8 @@ func (it *pointLookupIterator) HasNext(ctx context.Context) bool {
 	if !it.ran {
 		it.ran = true
 		if err := func() error {
+			it.opts.lockConn()
+			defer it.opts.unlockConn()
 			var currRows rows
 			q, err := it.genQuery()
 			if err != nil {
@@ -3,6 +3,7 @@ package rowiterator
 import (
 	"context"
 	"database/sql"
+	"sync"
 	"time"
 
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
@@ -23,6 +24,43 @@ type Iterator interface {
 	Next(ctx context.Context) tree.Datums
 }
 
+// IteratorOpt configures a row iterator.
+type IteratorOpt func(*iteratorOpts)
+
+type iteratorOpts struct {
+	// connMu is held while the iterator uses its connection, if set.
+	connMu sync.Locker
+}
+
+// WithConnLock holds mu while the iterator queries its connection and reads
+// the rows returned, so the connection can be shared with other iterators
+// holding the same lock.
+func WithConnLock(mu sync.Locker) IteratorOpt {
+	return func(o *iteratorOpts) {
+		o.connMu = mu
+	}
+}
+
+func makeIteratorOpts(inOpts []IteratorOpt) iteratorOpts {
+	var opts iteratorOpts
+	for _, applyOpt := range inOpts {
+		applyOpt(&opts)
+	}
+	return opts
+}
+
+func (o iteratorOpts) lockConn() {
+	if o.connMu != nil {
+		o.connMu.Lock()
+	}
+}
+
+func (o iteratorOpts) unlockConn() {
+	if o.connMu != nil {
+		o.connMu.Unlock()
+	}
+}
+
 type Table struct {
 	dbtable.Name
 	ColumnNames       []tree.Name
@@ -35,6 +73,9 @@ type ScanTable struct {
 	AOST        *time.Time
 	StartPKVals []tree.Datum
 	EndPKVals   []tree.Datum
+	// ResumeAfterPKVals, if set, starts the scan after the given primary key
+	// instead of at StartPKVals.
+	ResumeAfterPKVals []tree.Datum
 }
 
 type rows interface {
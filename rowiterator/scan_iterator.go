@@ -31,6 +31,7 @@ type scanIterator struct {
 	err           error
 	scanQuery     scanQuery
 	rateLimiter   *rate.Limiter
+	opts          iteratorOpts
 }
 
 type scanIteratorResult struct {
@@ -45,12 +46,21 @@ func NewScanIterator(
 	table ScanTable,
 	rowBatchSize int,
 	rateLimiter *rate.Limiter,
+	inOpts ...IteratorOpt,
 ) (Iterator, error) {
+	opts := makeIteratorOpts(inOpts)
 	// Initialize the type map on the connection.
-	for _, typOID := range table.ColumnOIDs {
-		if _, err := dbconn.GetDataType(ctx, conn, typOID); err != nil {
-			return nil, errors.Wrapf(err, "Error initializing type oid %d", typOID)
+	if err := func() error {
+		opts.lockConn()
+		defer opts.unlockConn()
+		for _, typOID := range table.ColumnOIDs {
+			if _, err := dbconn.GetDataType(ctx, conn, typOID); err != nil {
+				return errors.Wrapf(err, "Error initializing type oid %d", typOID)
+			}
 		}
+		return nil
+	}(); err != nil {
+		return nil, err
 	}
 	it := &scanIterator{
 		conn:          conn,
@@ -59,18 +69,34 @@ func NewScanIterator(
 		currCacheSize: rowBatchSize,
 		waitCh:        make(chan scanIteratorResult, 1),
 		rateLimiter:   rateLimiter,
+		pkCursor:      table.ResumeAfterPKVals,
+		opts:          opts,
+	}
+	// Without a primary key there is no cursor to page with, so the whole
+	// table is streamed from a single query without a limit instead.
+	keyless := len(table.PrimaryKeyColumns) == 0
+	queryLimit := rowBatchSize
+	if keyless {
+		if len(table.StartPKVals) > 0 || len(table.EndPKVals) > 0 || len(table.ResumeAfterPKVals) > 0 {
+			return nil, errors.AssertionFailedf("tables without a primary key cannot be scanned in ranges")
+		}
+		queryLimit = 0
 	}
 	switch conn := conn.(type) {
 	case *dbconn.PGConn:
-		it.scanQuery = newPGScanQuery(table, rowBatchSize)
+		it.scanQuery = newPGScanQuery(table, queryLimit)
 	case *dbconn.MySQLConn:
-		it.scanQuery = newMySQLScanQuery(table, rowBatchSize)
+		it.scanQuery = newMySQLScanQuery(table, queryLimit)
 	case *dbconn.OracleConn:
-		it.scanQuery = newOracleScanQuery(table, rowBatchSize)
+		it.scanQuery = newOracleScanQuery(table, queryLimit)
 	default:
 		return nil, errors.Newf("unsupported conn type %T", conn)
 	}
-	it.nextPage(ctx)
+	if keyless {
+		it.streamAll(ctx)
+	} else {
+		it.nextPage(ctx)
+	}
 	return it, nil
 }
 
@@ -103,8 +129,9 @@ func (it *scanIterator) HasNext(ctx context.Context) bool {
 		it.cache = res.r
 		it.currCacheSize = len(it.cache)
 
-		// Queue the next page immediately.
-		if it.currCacheSize == it.rowBatchSize {
+		// Queue the next page immediately, unless the whole table is
+		// already being streamed.
+		if it.currCacheSize == it.rowBatchSize && len(it.table.PrimaryKeyColumns) > 0 {
 			it.nextPage(ctx)
 		}
 	}
@@ -114,50 +141,12 @@ func (it *scanIterator) HasNext(ctx context.Context) bool {
 func (it *scanIterator) nextPage(ctx context.Context) {
 	go func() {
 		datums, err := func() ([]tree.Datums, error) {
-			var currRows rows
-			q, args, err := it.scanQuery.generate(it.pkCursor)
+			it.opts.lockConn()
+			defer it.opts.unlockConn()
+			currRows, err := it.query(ctx)
 			if err != nil {
 				return nil, err
 			}
-			if it.rateLimiter != nil {
-				if err := it.rateLimiter.Wait(ctx); err != nil {
-					return nil, err
-				}
-			}
-			switch conn := it.conn.(type) {
-			case *dbconn.PGConn:
-				newRows, err := conn.Query(ctx, q, args...)
-				if err != nil {
-					return nil, errors.Wrapf(err, "error getting rows for table %s.%s from %s", it.table.Schema, it.table.Table.Name, it.conn.ID)
-				}
-				currRows = &pgRows{
-					Rows:    newRows,
-					typMap:  it.conn.TypeMap(),
-					typOIDs: it.table.ColumnOIDs,
-				}
-			case *dbconn.MySQLConn:
-				newRows, err := conn.QueryContext(ctx, q, args...)
-				if err != nil {
-					return nil, errors.Wrapf(err, "error getting rows for table %s in %s", it.table.Table.Name, it.conn.ID())
-				}
-				currRows = &mysqlRows{
-					Rows:    newRows,
-					typMap:  it.conn.TypeMap(),
-					typOIDs: it.table.ColumnOIDs,
-				}
-			case *dbconn.OracleConn:
-				newRows, err := conn.QueryContext(ctx, q, args...)
-				if err != nil {
-					return nil, errors.Wrapf(err, "error getting rows for table %s in %s", it.table.Table.Name, it.conn.ID())
-				}
-				currRows = &oracleRows{
-					Rows:    newRows,
-					typMap:  it.conn.TypeMap(),
-					typOIDs: it.table.ColumnOIDs,
-				}
-			default:
-				return nil, errors.AssertionFailedf("unhandled conn type: %T", conn)
-			}
 			defer func() { currRows.Close() }()
 
 			datums := make([]tree.Datums, 0, it.rowBatchSize)
@@ -175,6 +164,92 @@ func (it *scanIterator) nextPage(ctx context.Context) {
 	}()
 }
 
+// streamAll asynchronously fetches every row of a table without a primary key
+// from a single query, sending them in batches of rowBatchSize. The final
+// batch is always smaller than rowBatchSize.
+func (it *scanIterator) streamAll(ctx context.Context) {
+	send := func(res scanIteratorResult) bool {
+		select {
+		case it.waitCh <- res:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	go func() {
+		// The connection is in use until every row has been streamed.
+		it.opts.lockConn()
+		defer it.opts.unlockConn()
+		currRows, err := it.query(ctx)
+		if err != nil {
+			send(scanIteratorResult{err: err})
+			return
+		}
+		defer func() { currRows.Close() }()
+		datums := make([]tree.Datums, 0, it.rowBatchSize)
+		for currRows.Next() {
+			d, err := currRows.Datums()
+			if err != nil {
+				send(scanIteratorResult{err: errors.Wrapf(err, "error getting datums")})
+				return
+			}
+			datums = append(datums, d)
+			if len(datums) == it.rowBatchSize {
+				if !send(scanIteratorResult{r: datums}) {
+					return
+				}
+				datums = make([]tree.Datums, 0, it.rowBatchSize)
+			}
+		}
+		send(scanIteratorResult{r: datums, err: currRows.Err()})
+	}()
+}
+
+func (it *scanIterator) query(ctx context.Context) (rows, error) {
+	q, args, err := it.scanQuery.generate(it.pkCursor)
+	if err != nil {
+		return nil, err
+	}
+	if it.rateLimiter != nil {
+		if err := it.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	switch conn := it.conn.(type) {
+	case *dbconn.PGConn:
+		newRows, err := conn.Query(ctx, q, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting rows for table %s.%s from %s", it.table.Schema, it.table.Table.Name, it.conn.ID)
+		}
+		return &pgRows{
+			Rows:    newRows,
+			typMap:  it.conn.TypeMap(),
+			typOIDs: it.table.ColumnOIDs,
+		}, nil
+	case *dbconn.MySQLConn:
+		newRows, err := conn.QueryContext(ctx, q, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting rows for table %s in %s", it.table.Table.Name, it.conn.ID())
+		}
+		return &mysqlRows{
+			Rows:    newRows,
+			typMap:  it.conn.TypeMap(),
+			typOIDs: it.table.ColumnOIDs,
+		}, nil
+	case *dbconn.OracleConn:
+		newRows, err := conn.QueryContext(ctx, q, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting rows for table %s in %s", it.table.Table.Name, it.conn.ID())
+		}
+		return &oracleRows{
+			Rows:    newRows,
+			typMap:  it.conn.TypeMap(),
+			typOIDs: it.table.ColumnOIDs,
+		}, nil
+	}
+	return nil, errors.AssertionFailedf("unhandled conn type: %T", it.conn)
+}
+
 func (it *scanIterator) Peek(ctx context.Context) tree.Datums {
 	if it.HasNext(ctx) {
 		return it.cache[0]
@@ -202,7 +277,9 @@ type scanQuery struct {
 
 func newPGScanQuery(table ScanTable, rowBatchSize int) scanQuery {
 	baseSelectExpr := NewPGBaseSelectClause(table.Table)
-	baseSelectExpr.Limit = &tree.Limit{Count: tree.NewNumVal(constant.MakeUint64(uint64(rowBatchSize)), "", false)}
+	if rowBatchSize > 0 {
+		baseSelectExpr.Limit = &tree.Limit{Count: tree.NewNumVal(constant.MakeUint64(uint64(rowBatchSize)), "", false)}
+	}
 	if table.AOST != nil {
 		var err error
 		baseSelectExpr.Select.(*tree.SelectClause).From.AsOf.Expr, err = tree.MakeDTimestamp(*table.AOST, time.Microsecond)
@@ -264,7 +341,9 @@ func newOracleScanQuery(table ScanTable, rowBatchSize int) scanQuery {
 
 func newMySQLScanQuery(table ScanTable, rowBatchSize int) scanQuery {
 	stmt := newMySQLBaseSelectClause(table.Table)
-	stmt.Limit = &ast.Limit{Count: ast.NewValueExpr(rowBatchSize, "", "")}
+	if rowBatchSize > 0 {
+		stmt.Limit = &ast.Limit{Count: ast.NewValueExpr(rowBatchSize, "", "")}
+	}
 	return scanQuery{
 		base:  stmt,
 		table: table,
@@ -280,12 +359,15 @@ func newMySQLBaseSelectClause(table Table) *ast.SelectStmt {
 			Expr: mysqlconv.MySQLASTColumnField(col),
 		}
 	}
-	orderBy := &ast.OrderByClause{
-		Items: make([]*ast.ByItem, len(table.PrimaryKeyColumns)),
-	}
-	for i, pkCol := range table.PrimaryKeyColumns {
-		orderBy.Items[i] = &ast.ByItem{
-			Expr: mysqlconv.MySQLASTColumnField(pkCol),
+	var orderBy *ast.OrderByClause
+	if len(table.PrimaryKeyColumns) > 0 {
+		orderBy = &ast.OrderByClause{
+			Items: make([]*ast.ByItem, len(table.PrimaryKeyColumns)),
+		}
+		for i, pkCol := range table.PrimaryKeyColumns {
+			orderBy.Items[i] = &ast.ByItem{
+				Expr: mysqlconv.MySQLASTColumnField(pkCol),
+			}
 		}
 	}
 	return &ast.SelectStmt{
@@ -308,31 +390,7 @@ func newMySQLBaseSelectClause(table Table) *ast.SelectStmt {
 func (sq *scanQuery) generate(pkCursor tree.Datums) (string, []any, error) {
 	switch stmt := sq.base.(type) {
 	case *tree.Select:
-		andClause := &tree.AndExpr{
-			Left:  tree.DBoolTrue,
-			Right: tree.DBoolTrue,
-		}
-		// Use the cursor if available, otherwise not.
-		if len(pkCursor) > 0 {
-			andClause.Left = makePGCompareExpr(
-				treecmp.MakeComparisonOperator(treecmp.GT),
-				sq.table.ColumnNames,
-				pkCursor,
-			)
-		} else if len(sq.table.StartPKVals) > 0 {
-			andClause.Left = makePGCompareExpr(
-				treecmp.MakeComparisonOperator(treecmp.GE),
-				sq.table.ColumnNames,
-				sq.table.StartPKVals,
-			)
-		}
-		if len(sq.table.EndPKVals) > 0 {
-			andClause.Right = makePGCompareExpr(
-				treecmp.MakeComparisonOperator(treecmp.LT),
-				sq.table.ColumnNames,
-				sq.table.EndPKVals,
-			)
-		}
+		andClause := pgBoundsExpr(sq.table, pkCursor)
 		stmt.Select.(*tree.SelectClause).Where = &tree.Where{
 			Type: tree.AstWhere,
 			Expr: andClause,
@@ -365,42 +423,21 @@ func (sq *scanQuery) generate(pkCursor tree.Datums) (string, []any, error) {
 			sb.WriteString(fmt.Sprintf("%s > '%s'", sq.table.PrimaryKeyColumns[0], f.CloseAndGetString()))
 		}
 
-		sb.WriteString(" ORDER BY ")
-		for i := range sq.table.PrimaryKeyColumns {
-			if i > 0 {
-				sb.WriteString(", ")
+		if len(sq.table.PrimaryKeyColumns) > 0 {
+			sb.WriteString(" ORDER BY ")
+			for i := range sq.table.PrimaryKeyColumns {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				sb.WriteString(string(sq.table.PrimaryKeyColumns[i]))
 			}
-			sb.WriteString(string(sq.table.PrimaryKeyColumns[i]))
 		}
-		sb.WriteString(fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", stmt.rowBatchSize))
+		if stmt.rowBatchSize > 0 {
+			sb.WriteString(fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", stmt.rowBatchSize))
+		}
 		return sb.String(), nil, nil
 	case *ast.SelectStmt:
-		andClause := &ast.BinaryOperationExpr{
-			Op: opcode.LogicAnd,
-			L:  ast.NewValueExpr(1, "", ""),
-			R:  ast.NewValueExpr(1, "", ""),
-		}
-		// Use the cursor if available, otherwise not.
-		if len(pkCursor) > 0 {
-			andClause.L = makeMySQLCompareExpr(
-				opcode.GT,
-				sq.table.ColumnNames,
-				pkCursor,
-			)
-		} else if len(sq.table.StartPKVals) > 0 {
-			andClause.L = makeMySQLCompareExpr(
-				opcode.GE,
-				sq.table.ColumnNames,
-				sq.table.StartPKVals,
-			)
-		}
-		if len(sq.table.EndPKVals) > 0 {
-			andClause.R = makeMySQLCompareExpr(
-				opcode.LT,
-				sq.table.ColumnNames,
-				sq.table.EndPKVals,
-			)
-		}
+		andClause := mysqlBoundsExpr(sq.table, pkCursor)
 		stmt.Where = andClause
 		var sb strings.Builder
 		if err := stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
@@ -411,6 +448,77 @@ func (sq *scanQuery) generate(pkCursor tree.Datums) (string, []any, error) {
 	return "", nil, errors.AssertionFailedf("unknown scan query type: %T", sq.base)
 }
 
+// PGShardBoundsExpr returns an expression restricting a PG query to the
+// [StartPKVals, EndPKVals) range of the given table.
+func PGShardBoundsExpr(table ScanTable) tree.Expr {
+	return pgBoundsExpr(table, nil)
+}
+
+// MySQLShardBoundsExpr returns an expression restricting a MySQL query to the
+// [StartPKVals, EndPKVals) range of the given table.
+func MySQLShardBoundsExpr(table ScanTable) ast.ExprNode {
+	return mysqlBoundsExpr(table, nil)
+}
+
+func pgBoundsExpr(table ScanTable, pkCursor tree.Datums) *tree.AndExpr {
+	andClause := &tree.AndExpr{
+		Left:  tree.DBoolTrue,
+		Right: tree.DBoolTrue,
+	}
+	// Use the cursor if available, otherwise not.
+	if len(pkCursor) > 0 {
+		andClause.Left = makePGCompareExpr(
+			treecmp.MakeComparisonOperator(treecmp.GT),
+			table.ColumnNames,
+			pkCursor,
+		)
+	} else if len(table.StartPKVals) > 0 {
+		andClause.Left = makePGCompareExpr(
+			treecmp.MakeComparisonOperator(treecmp.GE),
+			table.ColumnNames,
+			table.StartPKVals,
+		)
+	}
+	if len(table.EndPKVals) > 0 {
+		andClause.Right = makePGCompareExpr(
+			treecmp.MakeComparisonOperator(treecmp.LT),
+			table.ColumnNames,
+			table.EndPKVals,
+		)
+	}
+	return andClause
+}
+
+func mysqlBoundsExpr(table ScanTable, pkCursor tree.Datums) *ast.BinaryOperationExpr {
+	andClause := &ast.BinaryOperationExpr{
+		Op: opcode.LogicAnd,
+		L:  ast.NewValueExpr(1, "", ""),
+		R:  ast.NewValueExpr(1, "", ""),
+	}
+	// Use the cursor if available, otherwise not.
+	if len(pkCursor) > 0 {
+		andClause.L = makeMySQLCompareExpr(
+			opcode.GT,
+			table.ColumnNames,
+			pkCursor,
+		)
+	} else if len(table.StartPKVals) > 0 {
+		andClause.L = makeMySQLCompareExpr(
+			opcode.GE,
+			table.ColumnNames,
+			table.StartPKVals,
+		)
+	}
+	if len(table.EndPKVals) > 0 {
+		andClause.R = makeMySQLCompareExpr(
+			opcode.LT,
+			table.ColumnNames,
+			table.EndPKVals,
+		)
+	}
+	return andClause
+}
+
 func makeMySQLCompareExpr(
 	op opcode.Op, cols []tree.Name, vals tree.Datums,
 ) *ast.BinaryOperationExpr {
@@ -24,6 +24,11 @@ func TestScanQuery(t *testing.T) {
 					Table: tableFromCreateTable(t, d.Input),
 				}
 				return ""
+			case "keyless_table":
+				table = ScanTable{
+					Table: parseCreateTable(t, d.Input),
+				}
+				return ""
 			case "start_pk":
 				table.StartPKVals = parseDatums(t, d.Input, "\n")
 				return ""
@@ -31,10 +36,18 @@ func TestScanQuery(t *testing.T) {
 				table.EndPKVals = parseDatums(t, d.Input, "\n")
 				return ""
 			case "mysql":
-				sq = newMySQLScanQuery(table, 10000)
+				limit := 10000
+				if d.HasArg("limit") {
+					d.ScanArgs(t, "limit", &limit)
+				}
+				sq = newMySQLScanQuery(table, limit)
 				return ""
 			case "pg":
-				sq = newPGScanQuery(table, 10000)
+				limit := 10000
+				if d.HasArg("limit") {
+					d.ScanArgs(t, "limit", &limit)
+				}
+				sq = newPGScanQuery(table, limit)
 				return ""
 			case "generate":
 				require.NotNil(t, sq.base)
@@ -56,6 +69,12 @@ func TestScanQuery(t *testing.T) {
 }
 
 func tableFromCreateTable(t *testing.T, input string) Table {
+	table := parseCreateTable(t, input)
+	require.True(t, len(table.PrimaryKeyColumns) > 0, "primary key constraint must be explicitly defined")
+	return table
+}
+
+func parseCreateTable(t *testing.T, input string) Table {
 	p, err := parser.ParseOne(input)
 	require.NoError(t, err)
 
@@ -79,7 +98,6 @@ func tableFromCreateTable(t *testing.T, input string) Table {
 			}
 		}
 	}
-	require.True(t, len(table.PrimaryKeyColumns) > 0, "primary key constraint must be explicitly defined")
 	return table
 }
 
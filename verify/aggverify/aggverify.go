@@ -0,0 +1,314 @@
+// Package aggverify is responsible for verifying row counts and column
+// aggregates match between two databases, without comparing every row.
+package aggverify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree/treebin"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/comparectx"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/mysqlconv"
+	"github.com/cockroachdb/molt/pgconv"
+	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/lib/pq/oid"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/format"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/opcode"
+)
+
+// Settings configures aggregate verification.
+type Settings struct {
+	// ColumnAggregates compares per-column aggregates in addition to the
+	// row count of each shard.
+	ColumnAggregates bool
+}
+
+type aggregateKind string
+
+const (
+	aggregateCount     aggregateKind = "count"
+	aggregateNullCount aggregateKind = "null_count"
+	aggregateSum       aggregateKind = "sum"
+	aggregateMin       aggregateKind = "min"
+	aggregateMax       aggregateKind = "max"
+)
+
+type aggregate struct {
+	kind   aggregateKind
+	column tree.Name
+	// resultOIDs is the type of the result on each connection.
+	resultOIDs [2]oid.Oid
+}
+
+// aggregatesForTable returns the aggregates to compute for the given table.
+// The row count is always the first aggregate.
+func aggregatesForTable(table rowverify.TableShard, settings Settings) []aggregate {
+	ret := []aggregate{
+		{kind: aggregateCount, resultOIDs: [2]oid.Oid{oid.T_int8, oid.T_int8}},
+	}
+	if !settings.ColumnAggregates {
+		return ret
+	}
+	for i, col := range table.Columns {
+		ret = append(ret, aggregate{
+			kind:       aggregateNullCount,
+			column:     col,
+			resultOIDs: [2]oid.Oid{oid.T_int8, oid.T_int8},
+		})
+		typ, ok := types.OidToType[table.ColumnOIDs[0][i]]
+		if !ok {
+			continue
+		}
+		// Floating point sums depend on the order of summation, and string
+		// minimums and maximums depend on collation, so only aggregate types
+		// which compare deterministically across databases.
+		switch typ.Family() {
+		case types.IntFamily, types.DecimalFamily:
+			ret = append(ret, aggregate{
+				kind:       aggregateSum,
+				column:     col,
+				resultOIDs: [2]oid.Oid{oid.T_numeric, oid.T_numeric},
+			})
+		}
+		switch typ.Family() {
+		case types.IntFamily, types.DecimalFamily, types.FloatFamily,
+			types.DateFamily, types.TimestampFamily, types.TimestampTZFamily:
+			colOIDs := [2]oid.Oid{table.ColumnOIDs[0][i], table.ColumnOIDs[1][i]}
+			ret = append(
+				ret,
+				aggregate{kind: aggregateMin, column: col, resultOIDs: colOIDs},
+				aggregate{kind: aggregateMax, column: col, resultOIDs: colOIDs},
+			)
+		}
+	}
+	return ret
+}
+
+// VerifyAggregatesOnShard compares the row count, and optionally column
+// aggregates, of a table shard between the given connections.
+func VerifyAggregatesOnShard(
+	ctx context.Context,
+	conns dbconn.OrderedConns,
+	table rowverify.TableShard,
+	settings Settings,
+	reporter inconsistency.Reporter,
+) error {
+	aggs := aggregatesForTable(table, settings)
+	var results [2]tree.Datums
+	for i, conn := range conns {
+		var err error
+		results[i], err = queryAggregates(ctx, conn, i, table, aggs)
+		if err != nil {
+			return errors.Wrapf(err, "error computing aggregates on %s", conn.ID())
+		}
+	}
+
+	numMismatching := 0
+	for i, agg := range aggs {
+		truthVal, targetVal := results[0][i], results[1][i]
+		if truthVal.Compare(comparectx.CompareContext, targetVal) == 0 {
+			continue
+		}
+		numMismatching++
+		if agg.kind == aggregateCount {
+			reporter.Report(inconsistency.MismatchingRowCount{
+				Name:        table.Name,
+				ShardNum:    table.ShardNum,
+				TotalShards: table.TotalShards,
+				TruthCount:  int64(tree.MustBeDInt(truthVal)),
+				TargetCount: int64(tree.MustBeDInt(targetVal)),
+			})
+			continue
+		}
+		reporter.Report(inconsistency.MismatchingAggregate{
+			Name:        table.Name,
+			ShardNum:    table.ShardNum,
+			TotalShards: table.TotalShards,
+			Column:      agg.column,
+			Aggregate:   string(agg.kind),
+			TruthVal:    truthVal,
+			TargetVal:   targetVal,
+		})
+	}
+	reporter.Report(inconsistency.StatusReport{
+		Info: fmt.Sprintf(
+			"finished aggregate verification on %s.%s (shard %d/%d): truth rows: %s, target rows: %s, aggregates compared: %d, mismatching: %d",
+			table.Schema,
+			table.Table,
+			table.ShardNum,
+			table.TotalShards,
+			results[0][0].String(),
+			results[1][0].String(),
+			len(aggs),
+			numMismatching,
+		),
+	})
+	return nil
+}
+
+func queryAggregates(
+	ctx context.Context, conn dbconn.Conn, connIdx int, table rowverify.TableShard, aggs []aggregate,
+) (tree.Datums, error) {
+	resultOIDs := make([]oid.Oid, len(aggs))
+	for i, agg := range aggs {
+		resultOIDs[i] = agg.resultOIDs[connIdx]
+	}
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		q, err := buildPGAggregateQuery(table, aggs, table.AOST(conn))
+		if err != nil {
+			return nil, err
+		}
+		rows, err := conn.Query(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		if !rows.Next() {
+			return nil, errors.CombineErrors(errors.New("no aggregate row returned"), rows.Err())
+		}
+		vals, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		return pgconv.ConvertRowValues(conn.TypeMap(), vals, resultOIDs)
+	case *dbconn.MySQLConn:
+		q, err := buildMySQLAggregateQuery(table, aggs)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := conn.QueryContext(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = rows.Close() }()
+		if !rows.Next() {
+			return nil, errors.CombineErrors(errors.New("no aggregate row returned"), rows.Err())
+		}
+		return mysqlconv.ScanRowDynamicTypes(rows, conn.TypeMap(), resultOIDs)
+	}
+	return nil, errors.AssertionFailedf("aggregate verification not supported for %T", conn)
+}
+
+func scanTable(table rowverify.TableShard) rowiterator.ScanTable {
+	return rowiterator.ScanTable{
+		Table: rowiterator.Table{
+			Name:              table.Name,
+			ColumnNames:       table.Columns,
+			PrimaryKeyColumns: table.PrimaryKeyColumns,
+		},
+		StartPKVals: table.StartPKVals,
+		EndPKVals:   table.EndPKVals,
+	}
+}
+
+func buildPGAggregateQuery(
+	table rowverify.TableShard, aggs []aggregate, aost *time.Time,
+) (string, error) {
+	tn := table.MakeTableName()
+	selectClause := &tree.SelectClause{
+		From: tree.From{
+			Tables: tree.TableExprs{&tn},
+		},
+		Where: &tree.Where{
+			Type: tree.AstWhere,
+			Expr: rowiterator.PGShardBoundsExpr(scanTable(table)),
+		},
+	}
+	for _, agg := range aggs {
+		selectClause.Exprs = append(selectClause.Exprs, tree.SelectExpr{Expr: pgAggregateExpr(agg)})
+	}
+	if aost != nil {
+		var err error
+		if selectClause.From.AsOf.Expr, err = tree.MakeDTimestamp(*aost, time.Microsecond); err != nil {
+			return "", err
+		}
+	}
+	f := tree.NewFmtCtx(tree.FmtParsableNumerics)
+	f.FormatNode(&tree.Select{Select: selectClause})
+	return f.CloseAndGetString(), nil
+}
+
+func pgAggregateExpr(agg aggregate) tree.Expr {
+	fn := func(name string, arg tree.Expr) *tree.FuncExpr {
+		return &tree.FuncExpr{Func: tree.WrapFunction(name), Exprs: tree.Exprs{arg}}
+	}
+	col := tree.NewUnresolvedName(string(agg.column))
+	switch agg.kind {
+	case aggregateCount:
+		return fn("count", tree.StarExpr())
+	case aggregateNullCount:
+		return &tree.BinaryExpr{
+			Operator: treebin.MakeBinaryOperator(treebin.Minus),
+			Left:     fn("count", tree.StarExpr()),
+			Right:    fn("count", col),
+		}
+	case aggregateSum:
+		return &tree.CastExpr{
+			Expr:       fn("sum", col),
+			Type:       types.Decimal,
+			SyntaxMode: tree.CastShort,
+		}
+	}
+	return fn(string(agg.kind), col)
+}
+
+func buildMySQLAggregateQuery(table rowverify.TableShard, aggs []aggregate) (string, error) {
+	fields := &ast.FieldList{
+		Fields: make([]*ast.SelectField, len(aggs)),
+	}
+	for i, agg := range aggs {
+		fields.Fields[i] = &ast.SelectField{Expr: mysqlAggregateExpr(agg)}
+	}
+	stmt := &ast.SelectStmt{
+		SelectStmtOpts: &ast.SelectStmtOpts{
+			SQLCache: true,
+		},
+		From: &ast.TableRefsClause{
+			TableRefs: &ast.Join{
+				Left: &ast.TableSource{
+					Source: &ast.TableName{Name: model.NewCIStr(string(table.Table))},
+				},
+			},
+		},
+		Fields: fields,
+		Kind:   ast.SelectStmtKindSelect,
+		Where:  rowiterator.MySQLShardBoundsExpr(scanTable(table)),
+	}
+	var sb strings.Builder
+	if err := stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return "", errors.Wrap(err, "error generating MySQL statement")
+	}
+	return sb.String(), nil
+}
+
+func mysqlAggregateExpr(agg aggregate) ast.ExprNode {
+	fn := func(name string, arg ast.ExprNode) *ast.AggregateFuncExpr {
+		return &ast.AggregateFuncExpr{F: name, Args: []ast.ExprNode{arg}}
+	}
+	countStar := func() *ast.AggregateFuncExpr {
+		return fn(ast.AggFuncCount, ast.NewValueExpr(1, "", ""))
+	}
+	col := mysqlconv.MySQLASTColumnField(agg.column)
+	switch agg.kind {
+	case aggregateCount:
+		return countStar()
+	case aggregateNullCount:
+		return &ast.BinaryOperationExpr{
+			Op: opcode.Minus,
+			L:  countStar(),
+			R:  fn(ast.AggFuncCount, col),
+		}
+	}
+	return fn(string(agg.kind), col)
+}
@@ -0,0 +1,102 @@
+package aggverify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/lib/pq/oid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateQueries(t *testing.T) {
+	table := rowverify.TableShard{
+		VerifiedTable: dbtable.VerifiedTable{
+			Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+			PrimaryKeyColumns: []tree.Name{"id"},
+			Columns:           []tree.Name{"id", "txt", "f"},
+			ColumnOIDs: [2][]oid.Oid{
+				{oid.T_int4, oid.T_text, oid.T_float4},
+				{oid.T_int8, oid.T_text, oid.T_float8},
+			},
+		},
+		ShardNum:    1,
+		TotalShards: 1,
+	}
+	aost := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		desc          string
+		settings      Settings
+		start         tree.Datums
+		end           tree.Datums
+		aost          *time.Time
+		expectedPG    string
+		expectedMySQL string
+	}{
+		{
+			desc:          "count only",
+			expectedPG:    `SELECT count(*) FROM public.tbl WHERE true AND true`,
+			expectedMySQL: "SELECT COUNT(1) FROM `tbl` WHERE 1 AND 1",
+		},
+		{
+			desc:          "count only with shard bounds",
+			start:         tree.Datums{tree.NewDInt(10)},
+			end:           tree.Datums{tree.NewDInt(20)},
+			expectedPG:    `SELECT count(*) FROM public.tbl WHERE (id >= 10) AND (id < 20)`,
+			expectedMySQL: "SELECT COUNT(1) FROM `tbl` WHERE `id`>='10' AND `id`<'20'",
+		},
+		{
+			desc:          "count only as of system time",
+			aost:          &aost,
+			expectedPG:    `SELECT count(*) FROM public.tbl AS OF SYSTEM TIME '2024-01-02 03:04:05' WHERE true AND true`,
+			expectedMySQL: "SELECT COUNT(1) FROM `tbl` WHERE 1 AND 1",
+		},
+		{
+			desc:       "column aggregates",
+			settings:   Settings{ColumnAggregates: true},
+			expectedPG: `SELECT count(*), count(*) - count(id), sum(id)::DECIMAL, min(id), max(id), count(*) - count(txt), count(*) - count(f), min(f), max(f) FROM public.tbl WHERE true AND true`,
+			expectedMySQL: "SELECT COUNT(1),COUNT(1)-COUNT(`id`),SUM(`id`),MIN(`id`),MAX(`id`),COUNT(1)-COUNT(`txt`),COUNT(1)-COUNT(`f`),MIN(`f`),MAX(`f`) " +
+				"FROM `tbl` WHERE 1 AND 1",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			shard := table
+			shard.StartPKVals = tc.start
+			shard.EndPKVals = tc.end
+			aggs := aggregatesForTable(shard, tc.settings)
+			require.Equal(t, aggregateCount, aggs[0].kind)
+
+			q, err := buildPGAggregateQuery(shard, aggs, tc.aost)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedPG, q)
+			q, err = buildMySQLAggregateQuery(shard, aggs)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedMySQL, q)
+		})
+	}
+}
+
+func TestAggregateResultOIDs(t *testing.T) {
+	table := rowverify.TableShard{
+		VerifiedTable: dbtable.VerifiedTable{
+			Columns: []tree.Name{"id"},
+			ColumnOIDs: [2][]oid.Oid{
+				{oid.T_int4},
+				{oid.T_int8},
+			},
+		},
+	}
+	require.Equal(
+		t,
+		[]aggregate{
+			{kind: aggregateCount, resultOIDs: [2]oid.Oid{oid.T_int8, oid.T_int8}},
+			{kind: aggregateNullCount, column: "id", resultOIDs: [2]oid.Oid{oid.T_int8, oid.T_int8}},
+			{kind: aggregateSum, column: "id", resultOIDs: [2]oid.Oid{oid.T_numeric, oid.T_numeric}},
+			{kind: aggregateMin, column: "id", resultOIDs: [2]oid.Oid{oid.T_int4, oid.T_int8}},
+			{kind: aggregateMax, column: "id", resultOIDs: [2]oid.Oid{oid.T_int4, oid.T_int8}},
+		},
+		aggregatesForTable(table, Settings{ColumnAggregates: true}),
+	)
+}
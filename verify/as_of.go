@@ -0,0 +1,180 @@
+package verify
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/jackc/pgx/v5"
+)
+
+// ParseAsOf parses the time to verify at, which is either a timestamp, a
+// CockroachDB HLC timestamp (e.g. as reported by a replicator), or a
+// negative duration relative to now.
+func ParseAsOf(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		if d > 0 {
+			return time.Time{}, errors.Newf("duration %q must be negative", s)
+		}
+		return now.Add(d), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	// HLC timestamps are nanoseconds since the epoch, optionally followed by
+	// a logical component which is ignored.
+	wallTime, _, _ := strings.Cut(s, ".")
+	if nanos, err := strconv.ParseInt(wallTime, 10, 64); err == nil {
+		return time.Unix(0, nanos).UTC(), nil
+	}
+	return time.Time{}, errors.Newf("could not parse %q as a timestamp, HLC timestamp or negative duration", s)
+}
+
+// pgSnapshot is a snapshot exported from a PostgreSQL database, which every
+// shard imports so that all shards read the same point in time.
+type pgSnapshot struct {
+	// conn holds open the transaction which exported the snapshot, as the
+	// snapshot can only be imported while it is open.
+	conn      dbconn.Conn
+	id        string
+	lsn       string
+	timestamp time.Time
+}
+
+// exportPGSnapshots exports a snapshot from each PostgreSQL connection which
+// is not CockroachDB. CockroachDB connections are read AS OF SYSTEM TIME
+// instead.
+func exportPGSnapshots(
+	ctx context.Context, conns dbconn.OrderedConns,
+) (ret [2]*pgSnapshot, retErr error) {
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		for _, s := range ret {
+			if s != nil {
+				_ = s.close(ctx)
+			}
+		}
+	}()
+	for i, conn := range conns {
+		if pgConn, ok := conn.(*dbconn.PGConn); !ok || pgConn.IsCockroach() {
+			continue
+		}
+		snapshotConn, err := conn.Clone(ctx)
+		if err != nil {
+			return ret, errors.Wrap(err, "error establishing connection to export snapshot")
+		}
+		s := &pgSnapshot{conn: snapshotConn}
+		ret[i] = s
+		pgConn := snapshotConn.(*dbconn.PGConn)
+		if _, err := pgConn.Exec(ctx, "BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY"); err != nil {
+			return ret, errors.Wrapf(err, "error starting snapshot transaction on %s", conn.ID())
+		}
+		if err := pgConn.QueryRow(
+			ctx,
+			"SELECT pg_export_snapshot(), pg_current_wal_lsn()::TEXT, now()",
+		).Scan(&s.id, &s.lsn, &s.timestamp); err != nil {
+			return ret, errors.Wrapf(err, "error exporting snapshot on %s", conn.ID())
+		}
+	}
+	return ret, nil
+}
+
+// importInto makes all subsequent reads on conn use the snapshot.
+func (s *pgSnapshot) importInto(ctx context.Context, conn dbconn.Conn) error {
+	pgConn, ok := conn.(*dbconn.PGConn)
+	if !ok {
+		return errors.AssertionFailedf("cannot import snapshot into %T", conn)
+	}
+	if _, err := pgConn.Exec(ctx, "BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY"); err != nil {
+		return errors.Wrap(err, "error starting snapshot transaction")
+	}
+	if _, err := pgConn.Exec(ctx, "SET TRANSACTION SNAPSHOT '"+s.id+"'"); err != nil {
+		return errors.Wrapf(err, "error importing snapshot %s", s.id)
+	}
+	return nil
+}
+
+func (s *pgSnapshot) close(ctx context.Context) error {
+	return s.conn.Close(ctx)
+}
+
+// waitForReplication waits until the replicator reading the replication slot
+// on the source has confirmed the position of the snapshot, and returns the
+// time of the CockroachDB target afterwards. Everything in the snapshot has
+// been applied to the target by that time.
+func waitForReplication(
+	ctx context.Context,
+	source *dbconn.PGConn,
+	target *dbconn.PGConn,
+	s *pgSnapshot,
+	slot string,
+	pollInterval time.Duration,
+	timeout time.Duration,
+) (time.Time, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		var applied *bool
+		if err := source.QueryRow(
+			ctx,
+			"SELECT confirmed_flush_lsn >= $1::TEXT::pg_lsn FROM pg_replication_slots WHERE slot_name = $2",
+			s.lsn,
+			slot,
+		).Scan(&applied); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return time.Time{}, errors.Newf("replication slot %q not found", slot)
+			}
+			return time.Time{}, errors.Wrapf(err, "error querying replication slot %q", slot)
+		}
+		if applied != nil && *applied {
+			break
+		}
+		if time.Now().After(deadline) {
+			return time.Time{}, errors.Newf(
+				"replication slot %q did not confirm snapshot lsn %s within %s",
+				slot,
+				s.lsn,
+				timeout,
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+	var ts time.Time
+	if err := target.QueryRow(ctx, "SELECT now()").Scan(&ts); err != nil {
+		return time.Time{}, errors.Wrapf(err, "error querying time of %s", target.ID())
+	}
+	return ts, nil
+}
+
+// readAtPointInTime makes all subsequent reads on conn read at the point in
+// time verified at, which is the snapshot if set or asOf for CockroachDB.
+func readAtPointInTime(
+	ctx context.Context, conn dbconn.Conn, snapshot *pgSnapshot, asOf *time.Time,
+) error {
+	if snapshot != nil {
+		return snapshot.importInto(ctx, conn)
+	}
+	pgConn, ok := conn.(*dbconn.PGConn)
+	if asOf == nil || !ok || !pgConn.IsCockroach() {
+		return nil
+	}
+	ts, err := tree.MakeDTimestamp(*asOf, time.Microsecond)
+	if err != nil {
+		return err
+	}
+	if _, err := pgConn.Exec(ctx, "BEGIN AS OF SYSTEM TIME "+tree.AsString(ts)); err != nil {
+		return errors.Wrapf(err, "error starting transaction as of system time %s", tree.AsString(ts))
+	}
+	return nil
+}
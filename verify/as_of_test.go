@@ -0,0 +1,35 @@
+package verify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAsOf(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		desc        string
+		s           string
+		expected    time.Time
+		expectedErr string
+	}{
+		{desc: "rfc3339", s: "2024-01-01T10:00:00.5Z", expected: time.Date(2024, 1, 1, 10, 0, 0, 500000000, time.UTC)},
+		{desc: "sql timestamp", s: "2024-01-01 10:00:00", expected: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{desc: "hlc", s: "1704103200000000000.0000000001", expected: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{desc: "negative duration", s: "-30s", expected: now.Add(-30 * time.Second)},
+		{desc: "positive duration", s: "30s", expectedErr: `duration "30s" must be negative`},
+		{desc: "invalid", s: "yesterday", expectedErr: `could not parse "yesterday"`},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			asOf, err := ParseAsOf(tc.s, now)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tc.expected.Equal(asOf), "expected %s, got %s", tc.expected, asOf)
+		})
+	}
+}
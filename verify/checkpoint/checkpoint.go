@@ -0,0 +1,247 @@
+// Package checkpoint persists the progress of row verification to a file,
+// so that an interrupted verification can be resumed.
+package checkpoint
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/parsectx"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/lib/pq/oid"
+)
+
+// Store persists the progress of each shard to a JSON file.
+type Store struct {
+	path string
+	mu   struct {
+		sync.Mutex
+		shards []shardCheckpoint
+	}
+}
+
+var _ rowverify.ShardCheckpointer = (*Store)(nil)
+
+type checkpointFile struct {
+	Shards []shardCheckpoint `json:"shards"`
+}
+
+type shardCheckpoint struct {
+	Schema            string             `json:"schema"`
+	Table             string             `json:"table"`
+	PrimaryKeyColumns []string           `json:"primary_key_columns"`
+	ShardNum          int                `json:"shard_num"`
+	TotalShards       int                `json:"total_shards"`
+	StartPKVals       []string           `json:"start_pk_vals,omitempty"`
+	EndPKVals         []string           `json:"end_pk_vals,omitempty"`
+	LastPKVals        []string           `json:"last_pk_vals,omitempty"`
+	Stats             rowverify.RowStats `json:"stats"`
+	Completed         bool               `json:"completed"`
+}
+
+func (c shardCheckpoint) matches(table rowverify.TableShard) bool {
+	return c.Schema == string(table.Schema) &&
+		c.Table == string(table.Table) &&
+		c.ShardNum == table.ShardNum &&
+		c.TotalShards == table.TotalShards
+}
+
+// NewStore returns a Store which writes to the given path, replacing any
+// existing checkpoints.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// LoadStore returns a Store containing the checkpoints at the given path.
+func LoadStore(path string) (*Store, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading checkpoint file %s", path)
+	}
+	var f checkpointFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, errors.Wrapf(err, "error decoding checkpoint file %s", path)
+	}
+	s := &Store{path: path}
+	s.mu.shards = f.Shards
+	return s, nil
+}
+
+// Register records the given shards, so that they are resumed even if
+// they never checkpoint any progress.
+func (s *Store) Register(shards []rowverify.TableShard) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, shard := range shards {
+		s.mu.shards = append(s.mu.shards, shardCheckpoint{
+			Schema:            string(shard.Schema),
+			Table:             string(shard.Table),
+			PrimaryKeyColumns: namesToStrings(shard.PrimaryKeyColumns),
+			ShardNum:          shard.ShardNum,
+			TotalShards:       shard.TotalShards,
+			StartPKVals:       encodeDatums(shard.StartPKVals),
+			EndPKVals:         encodeDatums(shard.EndPKVals),
+		})
+	}
+	return s.writeLocked()
+}
+
+// Checkpoint implements the rowverify.ShardCheckpointer interface.
+func (s *Store) Checkpoint(table rowverify.TableShard, progress rowverify.ShardProgress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.mu.shards {
+		if s.mu.shards[i].matches(table) {
+			s.mu.shards[i].LastPKVals = encodeDatums(progress.LastPKVals)
+			s.mu.shards[i].Stats = progress.Stats
+			s.mu.shards[i].Completed = progress.Completed
+			return s.writeLocked()
+		}
+	}
+	return errors.AssertionFailedf(
+		"shard %d/%d of %s.%s was not registered",
+		table.ShardNum,
+		table.TotalShards,
+		table.Schema,
+		table.Table,
+	)
+}
+
+// ResumableShard is a shard with the progress made on it by an earlier run.
+type ResumableShard struct {
+	Shard    rowverify.TableShard
+	Progress rowverify.ShardProgress
+}
+
+// ResumableShards returns the checkpointed shards of the given table, or
+// nil if the table has no checkpoints.
+func (s *Store) ResumableShards(table dbtable.VerifiedTable) ([]ResumableShard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ret []ResumableShard
+	for _, c := range s.mu.shards {
+		if c.Schema != string(table.Schema) || c.Table != string(table.Table) {
+			continue
+		}
+		if !stringsEqual(c.PrimaryKeyColumns, namesToStrings(table.PrimaryKeyColumns)) {
+			return nil, errors.Newf(
+				"primary key of %s.%s has changed since it was checkpointed",
+				table.Schema,
+				table.Table,
+			)
+		}
+		pkOIDs := table.ColumnOIDs[0][:len(table.PrimaryKeyColumns)]
+		shard := rowverify.TableShard{
+			VerifiedTable: table,
+			ShardNum:      c.ShardNum,
+			TotalShards:   c.TotalShards,
+		}
+		var err error
+		if shard.StartPKVals, err = decodeDatums(c.StartPKVals, pkOIDs); err != nil {
+			return nil, err
+		}
+		if shard.EndPKVals, err = decodeDatums(c.EndPKVals, pkOIDs); err != nil {
+			return nil, err
+		}
+		progress := rowverify.ShardProgress{
+			Stats:     c.Stats,
+			Completed: c.Completed,
+		}
+		if progress.LastPKVals, err = decodeDatums(c.LastPKVals, pkOIDs); err != nil {
+			return nil, err
+		}
+		ret = append(ret, ResumableShard{Shard: shard, Progress: progress})
+	}
+	return ret, nil
+}
+
+// Remove deletes the checkpoint file.
+func (s *Store) Remove() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "error removing checkpoint file %s", s.path)
+	}
+	return nil
+}
+
+// writeLocked atomically replaces the checkpoint file.
+func (s *Store) writeLocked() error {
+	b, err := json.MarshalIndent(checkpointFile{Shards: s.mu.shards}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "error creating checkpoint file")
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return errors.Wrap(err, "error writing checkpoint file")
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.Wrap(err, "error writing checkpoint file")
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func encodeDatums(datums tree.Datums) []string {
+	if len(datums) == 0 {
+		return nil
+	}
+	ret := make([]string, len(datums))
+	for i, d := range datums {
+		ret[i] = tree.AsStringWithFlags(d, tree.FmtExport)
+	}
+	return ret
+}
+
+func decodeDatums(vals []string, oids []oid.Oid) (tree.Datums, error) {
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	if len(vals) > len(oids) {
+		return nil, errors.Newf("checkpoint has %d primary key values, expected at most %d", len(vals), len(oids))
+	}
+	ret := make(tree.Datums, len(vals))
+	for i, val := range vals {
+		typ, ok := types.OidToType[oids[i]]
+		if !ok {
+			return nil, errors.Newf("unable to resume primary key of type oid %d", oids[i])
+		}
+		d, _, err := tree.ParseAndRequireString(typ, val, parsectx.ParseContext)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding checkpointed primary key %q", val)
+		}
+		ret[i] = d
+	}
+	return ret, nil
+}
+
+func namesToStrings(names []tree.Name) []string {
+	ret := make([]string, len(names))
+	for i, n := range names {
+		ret[i] = string(n)
+	}
+	return ret
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
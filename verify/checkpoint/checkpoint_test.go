@@ -0,0 +1,97 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/comparectx"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/lib/pq/oid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	ts, err := tree.MakeDTimestampTZ(time.Date(2023, 7, 1, 12, 30, 45, 123456000, time.UTC), time.Microsecond)
+	require.NoError(t, err)
+	table := dbtable.VerifiedTable{
+		Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+		PrimaryKeyColumns: []tree.Name{"id", "s", "b", "ts", "u", "d"},
+		Columns:           []tree.Name{"id", "s", "b", "ts", "u", "d", "v"},
+		ColumnOIDs: [2][]oid.Oid{
+			{oid.T_int8, oid.T_text, oid.T_bytea, oid.T_timestamptz, oid.T_uuid, oid.T_numeric, oid.T_text},
+			{oid.T_int8, oid.T_text, oid.T_bytea, oid.T_timestamptz, oid.T_uuid, oid.T_numeric, oid.T_text},
+		},
+	}
+	u, err := tree.ParseDUuidFromString("205ffda9-7ad4-4783-9f9c-efad83a5eacd")
+	require.NoError(t, err)
+	d, err := tree.ParseDDecimal("123.456")
+	require.NoError(t, err)
+	lastPK := tree.Datums{
+		tree.NewDInt(10),
+		tree.NewDString("it's, \"quoted\""),
+		tree.NewDBytes("\x00\xffab"),
+		ts,
+		u,
+		d,
+	}
+	shards := []rowverify.TableShard{
+		{
+			VerifiedTable: table,
+			EndPKVals:     tree.Datums{tree.NewDInt(100)},
+			ShardNum:      1,
+			TotalShards:   2,
+		},
+		{
+			VerifiedTable: table,
+			StartPKVals:   tree.Datums{tree.NewDInt(100)},
+			ShardNum:      2,
+			TotalShards:   2,
+		},
+	}
+
+	s := NewStore(path)
+	require.NoError(t, s.Register(shards))
+	stats := rowverify.RowStats{NumVerified: 10, NumSuccess: 9, NumMismatch: 1}
+	require.NoError(t, s.Checkpoint(shards[0], rowverify.ShardProgress{LastPKVals: lastPK, Stats: stats}))
+	require.NoError(t, s.Checkpoint(shards[1], rowverify.ShardProgress{Stats: stats, Completed: true}))
+	require.Error(t, s.Checkpoint(rowverify.TableShard{VerifiedTable: table, ShardNum: 1, TotalShards: 1}, rowverify.ShardProgress{}))
+
+	loaded, err := LoadStore(path)
+	require.NoError(t, err)
+	resumable, err := loaded.ResumableShards(table)
+	require.NoError(t, err)
+	require.Len(t, resumable, 2)
+	require.Equal(t, shards[0].ShardNum, resumable[0].Shard.ShardNum)
+	require.Equal(t, shards[0].TotalShards, resumable[0].Shard.TotalShards)
+	require.Nil(t, resumable[0].Shard.StartPKVals)
+	require.Equal(t, []tree.Datum{tree.NewDInt(100)}, resumable[0].Shard.EndPKVals)
+	require.Len(t, resumable[0].Progress.LastPKVals, len(lastPK))
+	for i := range lastPK {
+		require.Zero(t, lastPK[i].Compare(comparectx.CompareContext, resumable[0].Progress.LastPKVals[i]), "mismatch on %s", lastPK[i])
+	}
+	require.Equal(t, stats, resumable[0].Progress.Stats)
+	require.False(t, resumable[0].Progress.Completed)
+	require.Equal(t, []tree.Datum{tree.NewDInt(100)}, resumable[1].Shard.StartPKVals)
+	require.True(t, resumable[1].Progress.Completed)
+
+	otherTable := table
+	otherTable.Table = "other"
+	resumable, err = loaded.ResumableShards(otherTable)
+	require.NoError(t, err)
+	require.Nil(t, resumable)
+
+	changedPK := table
+	changedPK.PrimaryKeyColumns = []tree.Name{"id"}
+	_, err = loaded.ResumableShards(changedPK)
+	require.Error(t, err)
+
+	require.NoError(t, loaded.Remove())
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	require.NoError(t, loaded.Remove())
+}
@@ -0,0 +1,229 @@
+// Package comparator is responsible for deciding whether column values from
+// the source of truth and the target match, allowing for configurable
+// tolerances between types which do not round trip exactly.
+package comparator
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/comparectx"
+	"github.com/cockroachdb/molt/dbtable"
+)
+
+// Comparator decides whether the values of a column match.
+type Comparator interface {
+	Equal(table dbtable.Name, column tree.Name, truthVal tree.Datum, targetVal tree.Datum) bool
+}
+
+// Exact is a Comparator which requires values to compare as equal with
+// tree.Datum.Compare.
+var Exact Comparator = exactComparator{}
+
+type exactComparator struct{}
+
+func (exactComparator) Equal(
+	_ dbtable.Name, _ tree.Name, truthVal tree.Datum, targetVal tree.Datum,
+) bool {
+	return truthVal.Compare(comparectx.CompareContext, targetVal) == 0
+}
+
+// Rule configures the tolerances used to compare values. The zero value
+// compares values exactly.
+type Rule struct {
+	// FloatEpsilon treats floats as equal if they differ by at most
+	// FloatEpsilon, either absolutely or relative to the larger value.
+	FloatEpsilon float64
+	// FloatULPs treats floats as equal if they are at most FloatULPs units
+	// in the last place apart.
+	FloatULPs uint64
+	// DecimalStrictScale requires decimals to have the same scale, e.g.
+	// 1.10 and 1.1 are not equal. By default, decimals are compared by value.
+	DecimalStrictScale bool
+	// TimestampPrecision truncates timestamps to the given precision before
+	// comparing them.
+	TimestampPrecision time.Duration
+	// TimestampLocation is the time zone TIMESTAMP values are assumed to be
+	// in when compared against TIMESTAMPTZ values. Defaults to UTC.
+	TimestampLocation *time.Location
+	// IgnoreTrailingWhitespace ignores trailing spaces on strings, which
+	// databases pad CHAR values with differently.
+	IgnoreTrailingWhitespace bool
+}
+
+// ColumnRef identifies a column of a table.
+type ColumnRef struct {
+	dbtable.Name
+	Column tree.Name
+}
+
+// Settings configures a rule based Comparator.
+type Settings struct {
+	// Default is the rule used for columns without a rule in Columns.
+	Default Rule
+	Columns map[ColumnRef]Rule
+}
+
+// New returns a Comparator which compares values using the rules in
+// the given settings.
+func New(settings Settings) Comparator {
+	return &ruleComparator{settings: settings}
+}
+
+type ruleComparator struct {
+	settings Settings
+}
+
+func (c *ruleComparator) Equal(
+	table dbtable.Name, column tree.Name, truthVal tree.Datum, targetVal tree.Datum,
+) bool {
+	return c.rule(table, column).Equal(truthVal, targetVal)
+}
+
+func (c *ruleComparator) rule(table dbtable.Name, column tree.Name) Rule {
+	if r, ok := c.settings.Columns[ColumnRef{Name: table, Column: column}]; ok {
+		return r
+	}
+	return c.settings.Default
+}
+
+// ColumnRule returns the rule c compares a column with. Comparators which are
+// not rule based, such as Exact, compare with the zero Rule.
+func ColumnRule(c Comparator, table dbtable.Name, column tree.Name) Rule {
+	if c, ok := c.(*ruleComparator); ok {
+		return c.rule(table, column)
+	}
+	return Rule{}
+}
+
+// HasFloatTolerance returns whether the rule treats floats which are not
+// equal as matching.
+func (r Rule) HasFloatTolerance() bool {
+	return r.FloatEpsilon > 0 || r.FloatULPs > 0
+}
+
+// Timestamp returns the time a TIMESTAMP or TIMESTAMPTZ value is compared
+// at, with TIMESTAMP values in TimestampLocation and truncated to
+// TimestampPrecision. ok is false if the value is not a timestamp.
+func (r Rule) Timestamp(d tree.Datum) (t time.Time, ok bool) {
+	t, hasTZ, ok := timestampFromDatum(d)
+	if !ok {
+		return time.Time{}, false
+	}
+	if !hasTZ {
+		t = inLocation(t, r.timestampLocation())
+	}
+	if r.TimestampPrecision > 0 {
+		t = t.Truncate(r.TimestampPrecision)
+	}
+	return t, true
+}
+
+func (r Rule) timestampLocation() *time.Location {
+	if r.TimestampLocation == nil {
+		return time.UTC
+	}
+	return r.TimestampLocation
+}
+
+// Equal returns whether the given values are equal under the rule.
+func (r Rule) Equal(truthVal tree.Datum, targetVal tree.Datum) bool {
+	switch a := truthVal.(type) {
+	case *tree.DFloat:
+		if b, ok := targetVal.(*tree.DFloat); ok {
+			return r.floatsEqual(float64(*a), float64(*b))
+		}
+	case *tree.DDecimal:
+		if b, ok := targetVal.(*tree.DDecimal); ok && r.DecimalStrictScale {
+			return a.Decimal.Cmp(&b.Decimal) == 0 && a.Exponent == b.Exponent
+		}
+	case *tree.DString:
+		if b, ok := targetVal.(*tree.DString); ok && r.IgnoreTrailingWhitespace {
+			return strings.TrimRight(string(*a), " ") == strings.TrimRight(string(*b), " ")
+		}
+	case *tree.DTimestamp, *tree.DTimestampTZ:
+		if ok, equal := r.timestampsEqual(truthVal, targetVal); ok {
+			return equal
+		}
+	}
+	return truthVal.Compare(comparectx.CompareContext, targetVal) == 0
+}
+
+func (r Rule) floatsEqual(a float64, b float64) bool {
+	if a == b || (math.IsNaN(a) && math.IsNaN(b)) {
+		return true
+	}
+	if math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return false
+	}
+	if r.FloatEpsilon > 0 {
+		diff := math.Abs(a - b)
+		if diff <= r.FloatEpsilon || diff <= r.FloatEpsilon*math.Max(math.Abs(a), math.Abs(b)) {
+			return true
+		}
+	}
+	return r.FloatULPs > 0 && ulpDistance(a, b) <= r.FloatULPs
+}
+
+// ulpDistance returns the number of representable float64 values between
+// a and b.
+func ulpDistance(a float64, b float64) uint64 {
+	ia, ib := orderedBits(a), orderedBits(b)
+	if ia > ib {
+		ia, ib = ib, ia
+	}
+	return uint64(ib) - uint64(ia)
+}
+
+// orderedBits maps floats to integers which sort in the same order,
+// with -0 and +0 mapping to the same value.
+func orderedBits(f float64) int64 {
+	b := int64(math.Float64bits(f))
+	if b < 0 {
+		return math.MinInt64 - b
+	}
+	return b
+}
+
+// timestampsEqual compares TIMESTAMP and TIMESTAMPTZ values. ok is false if
+// either value is not a timestamp.
+func (r Rule) timestampsEqual(truthVal tree.Datum, targetVal tree.Datum) (ok bool, equal bool) {
+	a, aHasTZ, ok := timestampFromDatum(truthVal)
+	if !ok {
+		return false, false
+	}
+	b, bHasTZ, ok := timestampFromDatum(targetVal)
+	if !ok {
+		return false, false
+	}
+	if aHasTZ != bHasTZ {
+		loc := r.timestampLocation()
+		if !aHasTZ {
+			a = inLocation(a, loc)
+		} else {
+			b = inLocation(b, loc)
+		}
+	}
+	if r.TimestampPrecision > 0 {
+		a = a.Truncate(r.TimestampPrecision)
+		b = b.Truncate(r.TimestampPrecision)
+	}
+	return true, a.Equal(b)
+}
+
+func timestampFromDatum(d tree.Datum) (t time.Time, hasTZ bool, ok bool) {
+	switch d := d.(type) {
+	case *tree.DTimestamp:
+		return d.Time, false, true
+	case *tree.DTimestampTZ:
+		return d.Time, true, true
+	}
+	return time.Time{}, false, false
+}
+
+// inLocation interprets the wall time of a TIMESTAMP in the given location.
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
@@ -0,0 +1,270 @@
+package comparator
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleEqual(t *testing.T) {
+	mustDecimal := func(s string) tree.Datum {
+		d, err := tree.ParseDDecimal(s)
+		require.NoError(t, err)
+		return d
+	}
+	ts := func(tm time.Time) tree.Datum {
+		d, err := tree.MakeDTimestamp(tm, time.Microsecond)
+		require.NoError(t, err)
+		return d
+	}
+	tstz := func(tm time.Time) tree.Datum {
+		d, err := tree.MakeDTimestampTZ(tm, time.Microsecond)
+		require.NoError(t, err)
+		return d
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	baseTime := time.Date(2023, 7, 1, 12, 30, 45, 123456000, time.UTC)
+
+	for _, tc := range []struct {
+		desc     string
+		rule     Rule
+		a        tree.Datum
+		b        tree.Datum
+		expected bool
+	}{
+		{
+			desc:     "float4 vs float8 exact",
+			a:        tree.NewDFloat(tree.DFloat(float32(1.1))),
+			b:        tree.NewDFloat(1.1),
+			expected: false,
+		},
+		{
+			desc:     "float4 vs float8 within epsilon",
+			rule:     Rule{FloatEpsilon: 1e-6},
+			a:        tree.NewDFloat(tree.DFloat(float32(1.1))),
+			b:        tree.NewDFloat(1.1),
+			expected: true,
+		},
+		{
+			desc:     "relative epsilon on large floats",
+			rule:     Rule{FloatEpsilon: 1e-6},
+			a:        tree.NewDFloat(tree.DFloat(float32(19999443.23))),
+			b:        tree.NewDFloat(19999443.23),
+			expected: true,
+		},
+		{
+			desc:     "outside epsilon",
+			rule:     Rule{FloatEpsilon: 1e-6},
+			a:        tree.NewDFloat(1.1),
+			b:        tree.NewDFloat(1.2),
+			expected: false,
+		},
+		{
+			desc:     "within ulps",
+			rule:     Rule{FloatULPs: 2},
+			a:        tree.NewDFloat(1),
+			b:        tree.NewDFloat(tree.DFloat(math.Nextafter(math.Nextafter(1, 2), 2))),
+			expected: true,
+		},
+		{
+			desc:     "outside ulps",
+			rule:     Rule{FloatULPs: 1},
+			a:        tree.NewDFloat(1),
+			b:        tree.NewDFloat(tree.DFloat(math.Nextafter(math.Nextafter(1, 2), 2))),
+			expected: false,
+		},
+		{
+			desc:     "ulps across zero",
+			rule:     Rule{FloatULPs: 2},
+			a:        tree.NewDFloat(tree.DFloat(-math.SmallestNonzeroFloat64)),
+			b:        tree.NewDFloat(tree.DFloat(math.SmallestNonzeroFloat64)),
+			expected: true,
+		},
+		{
+			desc:     "infinity is not within epsilon",
+			rule:     Rule{FloatEpsilon: math.MaxFloat64},
+			a:        tree.NewDFloat(tree.DFloat(math.Inf(1))),
+			b:        tree.NewDFloat(1),
+			expected: false,
+		},
+		{
+			desc:     "NaNs are equal",
+			rule:     Rule{FloatEpsilon: 1e-6},
+			a:        tree.NewDFloat(tree.DFloat(math.NaN())),
+			b:        tree.NewDFloat(tree.DFloat(math.NaN())),
+			expected: true,
+		},
+		{
+			desc:     "decimal scale ignored by default",
+			a:        mustDecimal("1.10"),
+			b:        mustDecimal("1.1"),
+			expected: true,
+		},
+		{
+			desc:     "decimal strict scale",
+			rule:     Rule{DecimalStrictScale: true},
+			a:        mustDecimal("1.10"),
+			b:        mustDecimal("1.1"),
+			expected: false,
+		},
+		{
+			desc:     "decimal strict scale same scale",
+			rule:     Rule{DecimalStrictScale: true},
+			a:        mustDecimal("1.10"),
+			b:        mustDecimal("1.10"),
+			expected: true,
+		},
+		{
+			desc:     "timestamp precision exact",
+			a:        ts(baseTime),
+			b:        ts(baseTime.Truncate(time.Millisecond)),
+			expected: false,
+		},
+		{
+			desc:     "timestamp precision truncated",
+			rule:     Rule{TimestampPrecision: time.Millisecond},
+			a:        ts(baseTime),
+			b:        ts(baseTime.Truncate(time.Millisecond)),
+			expected: true,
+		},
+		{
+			desc:     "timestamp vs timestamptz in UTC",
+			a:        ts(baseTime),
+			b:        tstz(baseTime),
+			expected: true,
+		},
+		{
+			desc:     "timestamp vs timestamptz in other time zone",
+			rule:     Rule{TimestampLocation: newYork},
+			a:        ts(baseTime),
+			b:        tstz(baseTime.Add(4 * time.Hour)),
+			expected: true,
+		},
+		{
+			desc:     "timestamptz vs timestamp in other time zone",
+			rule:     Rule{TimestampLocation: newYork},
+			a:        tstz(baseTime.Add(4 * time.Hour)),
+			b:        ts(baseTime),
+			expected: true,
+		},
+		{
+			desc:     "timestamp vs timestamptz mismatching time zone",
+			rule:     Rule{TimestampLocation: newYork},
+			a:        ts(baseTime),
+			b:        tstz(baseTime),
+			expected: false,
+		},
+		{
+			desc:     "char trailing whitespace exact",
+			a:        tree.NewDString("abc  "),
+			b:        tree.NewDString("abc"),
+			expected: false,
+		},
+		{
+			desc:     "char trailing whitespace ignored",
+			rule:     Rule{IgnoreTrailingWhitespace: true},
+			a:        tree.NewDString("abc  "),
+			b:        tree.NewDString("abc"),
+			expected: true,
+		},
+		{
+			desc:     "leading whitespace not ignored",
+			rule:     Rule{IgnoreTrailingWhitespace: true},
+			a:        tree.NewDString("  abc"),
+			b:        tree.NewDString("abc"),
+			expected: false,
+		},
+		{
+			desc:     "null vs value",
+			rule:     Rule{FloatEpsilon: 1},
+			a:        tree.DNull,
+			b:        tree.NewDFloat(1),
+			expected: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.rule.Equal(tc.a, tc.b))
+		})
+	}
+}
+
+func TestComparatorColumnRules(t *testing.T) {
+	tbl := dbtable.Name{Schema: "public", Table: "tbl"}
+	c := New(Settings{
+		Columns: map[ColumnRef]Rule{
+			{Name: tbl, Column: "f"}: {FloatEpsilon: 0.5},
+		},
+	})
+	require.True(t, c.Equal(tbl, "f", tree.NewDFloat(1), tree.NewDFloat(1.25)))
+	require.False(t, c.Equal(tbl, "g", tree.NewDFloat(1), tree.NewDFloat(1.25)))
+	require.False(t, Exact.Equal(tbl, "f", tree.NewDFloat(1), tree.NewDFloat(1.25)))
+}
+
+func TestParseColumnRule(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	base := Rule{FloatEpsilon: 0.1, IgnoreTrailingWhitespace: true}
+	for _, tc := range []struct {
+		desc          string
+		s             string
+		expectedRef   ColumnRef
+		expectedRule  Rule
+		expectedError string
+	}{
+		{
+			desc: "all options",
+			s:    "public.tbl.col:float-epsilon=0.001,float-ulps=4,decimal-strict-scale=true,timestamp-precision=1ms,timestamp-timezone=America/New_York,ignore-trailing-whitespace=false",
+			expectedRef: ColumnRef{
+				Name:   dbtable.Name{Schema: "public", Table: "tbl"},
+				Column: "col",
+			},
+			expectedRule: Rule{
+				FloatEpsilon:       0.001,
+				FloatULPs:          4,
+				DecimalStrictScale: true,
+				TimestampPrecision: time.Millisecond,
+				TimestampLocation:  newYork,
+			},
+		},
+		{
+			desc: "inherits base",
+			s:    "public.tbl.col:float-ulps=2",
+			expectedRef: ColumnRef{
+				Name:   dbtable.Name{Schema: "public", Table: "tbl"},
+				Column: "col",
+			},
+			expectedRule: Rule{FloatEpsilon: 0.1, FloatULPs: 2, IgnoreTrailingWhitespace: true},
+		},
+		{
+			desc:          "missing options",
+			s:             "public.tbl.col",
+			expectedError: `expected column rule "public.tbl.col" to be in the form schema.table.column:option=value`,
+		},
+		{
+			desc:          "bad column",
+			s:             "tbl.col:float-ulps=2",
+			expectedError: `expected column "tbl.col" to be in the form schema.table.column`,
+		},
+		{
+			desc:          "unknown option",
+			s:             "public.tbl.col:bad=2",
+			expectedError: `error parsing column rule "public.tbl.col:bad=2": unknown option "bad"`,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ref, rule, err := ParseColumnRule(tc.s, base)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedRef, ref)
+			require.Equal(t, tc.expectedRule, rule)
+		})
+	}
+}
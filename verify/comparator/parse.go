@@ -0,0 +1,63 @@
+package comparator
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+)
+
+// ParseColumnRule parses a column specific rule in the form
+// `schema.table.column:option=value,option=value`, with options overriding
+// those of the given base rule. Options are `float-epsilon`, `float-ulps`,
+// `decimal-strict-scale`, `timestamp-precision`, `timestamp-timezone` and
+// `ignore-trailing-whitespace`.
+func ParseColumnRule(s string, base Rule) (ColumnRef, Rule, error) {
+	colStr, optsStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return ColumnRef{}, Rule{}, errors.Newf("expected column rule %q to be in the form schema.table.column:option=value", s)
+	}
+	parts := strings.Split(colStr, ".")
+	if len(parts) != 3 {
+		return ColumnRef{}, Rule{}, errors.Newf("expected column %q to be in the form schema.table.column", colStr)
+	}
+	ref := ColumnRef{
+		Name:   dbtable.Name{Schema: tree.Name(parts[0]), Table: tree.Name(parts[1])},
+		Column: tree.Name(parts[2]),
+	}
+	rule := base
+	for _, opt := range strings.Split(optsStr, ",") {
+		key, val, ok := strings.Cut(opt, "=")
+		if !ok {
+			return ColumnRef{}, Rule{}, errors.Newf("expected option %q to be in the form option=value", opt)
+		}
+		if err := rule.setOption(strings.TrimSpace(key), strings.TrimSpace(val)); err != nil {
+			return ColumnRef{}, Rule{}, errors.Wrapf(err, "error parsing column rule %q", s)
+		}
+	}
+	return ref, rule, nil
+}
+
+func (r *Rule) setOption(key string, val string) error {
+	var err error
+	switch key {
+	case "float-epsilon":
+		r.FloatEpsilon, err = strconv.ParseFloat(val, 64)
+	case "float-ulps":
+		r.FloatULPs, err = strconv.ParseUint(val, 10, 64)
+	case "decimal-strict-scale":
+		r.DecimalStrictScale, err = strconv.ParseBool(val)
+	case "timestamp-precision":
+		r.TimestampPrecision, err = time.ParseDuration(val)
+	case "timestamp-timezone":
+		r.TimestampLocation, err = time.LoadLocation(val)
+	case "ignore-trailing-whitespace":
+		r.IgnoreTrailingWhitespace, err = strconv.ParseBool(val)
+	default:
+		return errors.Newf("unknown option %q", key)
+	}
+	return err
+}
@@ -20,10 +20,29 @@ func DefaultFilterConfig() FilterConfig {
 type FilterConfig struct {
 	SchemaFilter FilterString
 	TableFilter  FilterString
+	// ExcludedTables are tables which are never matched, e.g. tables written
+	// to by verification itself.
+	ExcludedTables []dbtable.Name
+}
+
+// NameMatcher returns a function which returns whether a name matches the
+// filter.
+func NameMatcher(cfg FilterConfig) (func(dbtable.Name) bool, error) {
+	schemaRe, err := regexp.CompilePOSIX(cfg.SchemaFilter)
+	if err != nil {
+		return nil, err
+	}
+	tableRe, err := regexp.CompilePOSIX(cfg.TableFilter)
+	if err != nil {
+		return nil, err
+	}
+	return func(n dbtable.Name) bool {
+		return matchesFilter(cfg, n, schemaRe, tableRe)
+	}, nil
 }
 
 func FilterResult(cfg FilterConfig, r Result) (Result, error) {
-	if cfg.SchemaFilter == DefaultFilterString && cfg.TableFilter == DefaultFilterString {
+	if cfg.SchemaFilter == DefaultFilterString && cfg.TableFilter == DefaultFilterString && len(cfg.ExcludedTables) == 0 {
 		return r, nil
 	}
 	schemaRe, err := regexp.CompilePOSIX(cfg.SchemaFilter)
@@ -40,23 +59,28 @@ func FilterResult(cfg FilterConfig, r Result) (Result, error) {
 		ExtraneousTables: r.ExtraneousTables[:0],
 	}
 	for _, v := range r.Verified {
-		if matchesFilter(v[0].Name, schemaRe, tableRe) {
+		if matchesFilter(cfg, v[0].Name, schemaRe, tableRe) {
 			newResult.Verified = append(newResult.Verified, v)
 		}
 	}
 	for _, t := range r.MissingTables {
-		if matchesFilter(t.Name, schemaRe, tableRe) {
+		if matchesFilter(cfg, t.Name, schemaRe, tableRe) {
 			newResult.MissingTables = append(newResult.MissingTables, t)
 		}
 	}
 	for _, t := range r.ExtraneousTables {
-		if matchesFilter(t.Name, schemaRe, tableRe) {
+		if matchesFilter(cfg, t.Name, schemaRe, tableRe) {
 			newResult.ExtraneousTables = append(newResult.ExtraneousTables, t)
 		}
 	}
 	return newResult, nil
 }
 
-func matchesFilter(n dbtable.Name, schemaRe, tableRe *regexp.Regexp) bool {
+func matchesFilter(cfg FilterConfig, n dbtable.Name, schemaRe, tableRe *regexp.Regexp) bool {
+	for _, excluded := range cfg.ExcludedTables {
+		if (dbtable.DBTable{Name: n}).Compare(dbtable.DBTable{Name: excluded}) == 0 {
+			return false
+		}
+	}
 	return schemaRe.MatchString(string(n.Schema)) && tableRe.MatchString(string(n.Table))
 }
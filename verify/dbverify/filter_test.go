@@ -169,6 +169,37 @@ func TestFilterResult(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "excluded tables",
+			config: FilterConfig{
+				SchemaFilter:   DefaultFilterString,
+				TableFilter:    DefaultFilterString,
+				ExcludedTables: []dbtable.Name{{Schema: "public", Table: "molt_verify_results"}},
+			},
+			r: Result{
+				Verified: [][2]dbtable.DBTable{
+					{
+						{Name: dbtable.Name{Schema: "public", Table: "aaa"}},
+						{Name: dbtable.Name{Schema: "public", Table: "aaa"}},
+					},
+				},
+				ExtraneousTables: []inconsistency.ExtraneousTable{
+					{DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "bbb"}}},
+					{DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "molt_verify_results"}}},
+				},
+			},
+			expected: Result{
+				Verified: [][2]dbtable.DBTable{
+					{
+						{Name: dbtable.Name{Schema: "public", Table: "aaa"}},
+						{Name: dbtable.Name{Schema: "public", Table: "aaa"}},
+					},
+				},
+				ExtraneousTables: []inconsistency.ExtraneousTable{
+					{DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "bbb"}}},
+				},
+			},
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			r, err := FilterResult(tc.config, tc.r)
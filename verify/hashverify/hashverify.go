@@ -0,0 +1,347 @@
+// Package hashverify is responsible for verifying the rows of tables without
+// a primary key, by comparing the number of times each distinct row occurs
+// in each database.
+package hashverify
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/verify/comparator"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/lib/pq/oid"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+)
+
+// Stats are the statistics of hash verification on a table.
+type Stats struct {
+	NumTruthRows  int64
+	NumTargetRows int64
+	NumMissing    int64
+	NumExtraneous int64
+}
+
+// RowStats converts the statistics to row verification statistics, where
+// truth rows which are not missing are successfully verified.
+func (s Stats) RowStats() rowverify.RowStats {
+	return rowverify.RowStats{
+		NumVerified:   int(s.NumTruthRows),
+		NumSuccess:    int(s.NumTruthRows - s.NumMissing),
+		NumMissing:    int(s.NumMissing),
+		NumExtraneous: int(s.NumExtraneous),
+	}
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf(
+		"truth rows seen: %d, target rows seen: %d, missing: %d, extraneous: %d",
+		s.NumTruthRows,
+		s.NumTargetRows,
+		s.NumMissing,
+		s.NumExtraneous,
+	)
+}
+
+// numBuckets is the number of buckets rows are hashed into on the first
+// scan, which bounds the memory used regardless of the number of rows.
+const numBuckets = 1 << 16
+
+// maxTrackedRows is the maximum number of rows in mismatching buckets whose
+// distinct rows are counted on the second scan, which bounds the memory used
+// when many buckets mismatch, e.g. when the target is empty or far behind.
+// Only the difference in the number of rows is counted in the remaining
+// mismatching buckets.
+const maxTrackedRows = 100_000
+
+// bucket is the number of rows hashed into a bucket on each connection, and
+// the sum of their hashes.
+type bucket struct {
+	count [2]int64
+	sum   [2]uint64
+}
+
+func (b bucket) mismatching() bool {
+	return b.count[0] != b.count[1] || b.sum[0] != b.sum[1]
+}
+
+// trackBuckets returns which mismatching buckets have their distinct rows
+// counted, which are the first buckets holding at most maxRows rows between
+// them. The statistics of the remaining mismatching buckets are counted from
+// the difference in their number of rows, which is a lower bound of their
+// number of missing and extraneous rows.
+func trackBuckets(
+	buckets []bucket, maxRows int64,
+) (tracked []bool, untracked Stats, numUntracked int) {
+	tracked = make([]bool, len(buckets))
+	var numRows int64
+	for i, b := range buckets {
+		if !b.mismatching() {
+			continue
+		}
+		if n := b.count[0] + b.count[1]; numRows+n <= maxRows {
+			tracked[i] = true
+			numRows += n
+			continue
+		}
+		numUntracked++
+		switch {
+		case b.count[0] > b.count[1]:
+			untracked.NumMissing += b.count[0] - b.count[1]
+		case b.count[0] < b.count[1]:
+			untracked.NumExtraneous += b.count[1] - b.count[0]
+		default:
+			// The rows differ while their number is the same, so at least
+			// one is missing and one is extraneous.
+			untracked.NumMissing++
+			untracked.NumExtraneous++
+		}
+	}
+	return tracked, untracked, numUntracked
+}
+
+// VerifyRowHashesOnShard verifies every row of a table without a primary key
+// occurs the same number of times on each connection. Rows are hashed into a
+// fixed number of buckets, each counting the rows and summing their hashes.
+// If any buckets mismatch, the table is scanned again counting each distinct
+// row in the mismatching buckets only, to report the mismatching rows. At
+// most maxTrackedRows rows are counted, beyond which only the difference in
+// the number of rows of each mismatching bucket is counted.
+// Values are hashed after applying the tolerances of cmp, except float
+// tolerances, which cannot be hashed and are refused.
+func VerifyRowHashesOnShard(
+	ctx context.Context,
+	conns dbconn.OrderedConns,
+	table rowverify.TableShard,
+	rowBatchSize int,
+	reporter inconsistency.Reporter,
+	rateLimiter *rate.Limiter,
+	cmp comparator.Comparator,
+) (Stats, error) {
+	if len(table.PrimaryKeyColumns) > 0 || len(table.StartPKVals) > 0 || len(table.EndPKVals) > 0 {
+		return Stats{}, errors.AssertionFailedf("hash verification is only supported on unsharded tables without a primary key")
+	}
+	rules := make([]comparator.Rule, len(table.Columns))
+	for i, col := range table.Columns {
+		rules[i] = comparator.ColumnRule(cmp, table.Name, col)
+		if rules[i].HasFloatTolerance() && isFloat(table.ColumnOIDs[0][i]) {
+			return Stats{}, errors.Newf(
+				"float tolerances cannot be applied to column %s of %s.%s, as rows of tables without a primary key are compared by hash; compare the column exactly or exclude it",
+				col,
+				table.Schema,
+				table.Table,
+			)
+		}
+	}
+
+	var mu sync.Mutex
+	buckets := make([]bucket, numBuckets)
+	var stats Stats
+	if err := scanHashes(ctx, conns, table, rowBatchSize, rateLimiter, rules, func(idx int, h uint64, _ tree.Datums) {
+		mu.Lock()
+		defer mu.Unlock()
+		b := &buckets[h%numBuckets]
+		b.count[idx]++
+		b.sum[idx] += h
+		if idx == 0 {
+			stats.NumTruthRows++
+		} else {
+			stats.NumTargetRows++
+		}
+	}); err != nil {
+		return Stats{}, err
+	}
+
+	tracked, untracked, numUntracked := trackBuckets(buckets, maxTrackedRows)
+	stats.NumMissing += untracked.NumMissing
+	stats.NumExtraneous += untracked.NumExtraneous
+	if numUntracked > 0 {
+		reporter.Report(inconsistency.StatusReport{
+			Info: fmt.Sprintf(
+				"%s.%s has more than %d rows in mismatching hash buckets; only counting at least %d missing and %d extraneous rows in %d of them, without reporting the rows",
+				table.Schema,
+				table.Table,
+				maxTrackedRows,
+				untracked.NumMissing,
+				untracked.NumExtraneous,
+				numUntracked,
+			),
+		})
+	}
+
+	trackedBuckets := false
+	for _, t := range tracked {
+		if t {
+			trackedBuckets = true
+			break
+		}
+	}
+
+	if trackedBuckets {
+		counts := make(map[uint64][2]int64)
+		rows := make(map[uint64]tree.Datums)
+		if err := scanHashes(ctx, conns, table, rowBatchSize, rateLimiter, rules, func(idx int, h uint64, row tree.Datums) {
+			if !tracked[h%numBuckets] {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			c := counts[h]
+			c[idx]++
+			counts[h] = c
+			if _, ok := rows[h]; !ok {
+				rows[h] = row
+			}
+		}); err != nil {
+			return Stats{}, err
+		}
+		for _, c := range counts {
+			if c[0] > c[1] {
+				stats.NumMissing += c[0] - c[1]
+			} else {
+				stats.NumExtraneous += c[1] - c[0]
+			}
+		}
+		for _, m := range sortedMismatches(table, counts, rows) {
+			reporter.Report(m)
+		}
+	}
+
+	rowverify.ObserveRowStats(table.Name, stats.RowStats())
+	reporter.Report(inconsistency.StatusReport{
+		Info: fmt.Sprintf(
+			"finished row hash verification on %s.%s (shard %d/%d): %s",
+			table.Schema,
+			table.Table,
+			table.ShardNum,
+			table.TotalShards,
+			stats.String(),
+		),
+	})
+	return stats, nil
+}
+
+func isFloat(o oid.Oid) bool {
+	return o == oid.T_float4 || o == oid.T_float8
+}
+
+// scanHashes scans every row on each connection concurrently, calling fn
+// with the index of the connection and the hash of each row.
+func scanHashes(
+	ctx context.Context,
+	conns dbconn.OrderedConns,
+	table rowverify.TableShard,
+	rowBatchSize int,
+	rateLimiter *rate.Limiter,
+	rules []comparator.Rule,
+	fn func(idx int, h uint64, row tree.Datums),
+) error {
+	g, gCtx := errgroup.WithContext(ctx)
+	for i, conn := range conns {
+		i, conn := i, conn
+		g.Go(func() error {
+			it, err := rowiterator.NewScanIterator(
+				gCtx,
+				conn,
+				rowiterator.ScanTable{
+					Table: rowiterator.Table{
+						Name:        table.Name,
+						ColumnNames: table.Columns,
+						ColumnOIDs:  table.ColumnOIDs[i],
+					},
+					AOST: table.AOST(conn),
+				},
+				rowBatchSize,
+				rateLimiter,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "error initializing row iterator on %s", conn.ID())
+			}
+			it = rowverify.NewMeteredIterator(it, table, i)
+			h := fnv.New64a()
+			for it.HasNext(gCtx) {
+				row := it.Next(gCtx)
+				fn(i, hashRow(h, row, rules), row)
+			}
+			return it.Error()
+		})
+	}
+	return g.Wait()
+}
+
+func sortedMismatches(
+	table rowverify.TableShard, counts map[uint64][2]int64, rows map[uint64]tree.Datums,
+) []inconsistency.MismatchingRowOccurrences {
+	var ret []inconsistency.MismatchingRowOccurrences
+	for h, c := range counts {
+		if c[0] == c[1] {
+			continue
+		}
+		ret = append(ret, inconsistency.MismatchingRowOccurrences{
+			Name:        table.Name,
+			Columns:     table.Columns,
+			Values:      rows[h],
+			TruthCount:  c[0],
+			TargetCount: c[1],
+		})
+	}
+	// Sort for deterministic output.
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Values.String() < ret[j].Values.String()
+	})
+	return ret
+}
+
+// hashRow hashes a row such that rows which compare as equal between
+// databases under the rule of each column hash to the same value.
+func hashRow(h hash.Hash64, row tree.Datums, rules []comparator.Rule) uint64 {
+	h.Reset()
+	var lenBuf [binary.MaxVarintLen64]byte
+	for i, d := range row {
+		if d == tree.DNull {
+			_, _ = h.Write([]byte{0})
+			continue
+		}
+		s := normalizedString(d, rules[i])
+		_, _ = h.Write([]byte{1})
+		_, _ = h.Write(lenBuf[:binary.PutUvarint(lenBuf[:], uint64(len(s)))])
+		_, _ = h.Write([]byte(s))
+	}
+	return h.Sum64()
+}
+
+// normalizedString returns a string representation of a datum which is equal
+// for values which compare as equal under the rule, e.g. decimals with
+// trailing zeroes and TIMESTAMP vs TIMESTAMPTZ values.
+func normalizedString(d tree.Datum, rule comparator.Rule) string {
+	switch d := d.(type) {
+	case *tree.DDecimal:
+		if rule.DecimalStrictScale {
+			return d.Decimal.String()
+		}
+		var reduced apd.Decimal
+		reduced.Reduce(&d.Decimal)
+		return reduced.String()
+	case *tree.DTimestamp, *tree.DTimestampTZ:
+		t, _ := rule.Timestamp(d)
+		return t.UTC().Format(time.RFC3339Nano)
+	case *tree.DString:
+		if rule.IgnoreTrailingWhitespace {
+			return strings.TrimRight(string(*d), " ")
+		}
+	}
+	return tree.AsStringWithFlags(d, tree.FmtBareStrings|tree.FmtParsableNumerics)
+}
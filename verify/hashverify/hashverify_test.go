@@ -0,0 +1,135 @@
+package hashverify
+
+import (
+	"hash/fnv"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/verify/comparator"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashRow(t *testing.T) {
+	mustDecimal := func(s string) tree.Datum {
+		d, err := tree.ParseDDecimal(s)
+		require.NoError(t, err)
+		return d
+	}
+	baseTime := time.Date(2023, 7, 1, 12, 30, 45, 123456000, time.UTC)
+	ts, err := tree.MakeDTimestamp(baseTime, time.Microsecond)
+	require.NoError(t, err)
+	tstz, err := tree.MakeDTimestampTZ(baseTime, time.Microsecond)
+	require.NoError(t, err)
+	mustTimestamp := func(t2 time.Time) tree.Datum {
+		d, err := tree.MakeDTimestamp(t2, time.Microsecond)
+		require.NoError(t, err)
+		return d
+	}
+
+	for _, tc := range []struct {
+		desc     string
+		a        tree.Datums
+		b        tree.Datums
+		rule     comparator.Rule
+		expected bool
+	}{
+		{
+			desc:     "same values",
+			a:        tree.Datums{tree.NewDInt(1), tree.NewDString("a")},
+			b:        tree.Datums{tree.NewDInt(1), tree.NewDString("a")},
+			expected: true,
+		},
+		{
+			desc:     "different values",
+			a:        tree.Datums{tree.NewDInt(1), tree.NewDString("a")},
+			b:        tree.Datums{tree.NewDInt(1), tree.NewDString("b")},
+			expected: false,
+		},
+		{
+			desc:     "values do not run into each other",
+			a:        tree.Datums{tree.NewDString("ab"), tree.NewDString("c")},
+			b:        tree.Datums{tree.NewDString("a"), tree.NewDString("bc")},
+			expected: false,
+		},
+		{
+			desc:     "null is not an empty string",
+			a:        tree.Datums{tree.DNull},
+			b:        tree.Datums{tree.NewDString("")},
+			expected: false,
+		},
+		{
+			desc:     "null is not the string NULL",
+			a:        tree.Datums{tree.DNull},
+			b:        tree.Datums{tree.NewDString("NULL")},
+			expected: false,
+		},
+		{
+			desc:     "decimal trailing zeroes",
+			a:        tree.Datums{mustDecimal("1.10")},
+			b:        tree.Datums{mustDecimal("1.1")},
+			expected: true,
+		},
+		{
+			desc:     "timestamp vs timestamptz",
+			a:        tree.Datums{ts},
+			b:        tree.Datums{tstz},
+			expected: true,
+		},
+		{
+			desc:     "decimal strict scale",
+			a:        tree.Datums{mustDecimal("1.10")},
+			b:        tree.Datums{mustDecimal("1.1")},
+			rule:     comparator.Rule{DecimalStrictScale: true},
+			expected: false,
+		},
+		{
+			desc:     "timestamp precision",
+			a:        tree.Datums{ts},
+			b:        tree.Datums{mustTimestamp(baseTime.Add(time.Microsecond))},
+			rule:     comparator.Rule{TimestampPrecision: time.Millisecond},
+			expected: true,
+		},
+		{
+			desc:     "timestamp time zone",
+			a:        tree.Datums{ts},
+			b:        tree.Datums{tstz},
+			rule:     comparator.Rule{TimestampLocation: time.FixedZone("UTC+1", 60*60)},
+			expected: false,
+		},
+		{
+			desc:     "trailing whitespace",
+			a:        tree.Datums{tree.NewDString("a  ")},
+			b:        tree.Datums{tree.NewDString("a")},
+			rule:     comparator.Rule{IgnoreTrailingWhitespace: true},
+			expected: true,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			h := fnv.New64a()
+			rules := make([]comparator.Rule, len(tc.a))
+			for i := range rules {
+				rules[i] = tc.rule
+			}
+			require.Equal(t, tc.expected, hashRow(h, tc.a, rules) == hashRow(h, tc.b, rules))
+		})
+	}
+}
+
+func TestTrackBuckets(t *testing.T) {
+	buckets := []bucket{
+		// Matching buckets are never tracked.
+		{count: [2]int64{3, 3}, sum: [2]uint64{5, 5}},
+		{count: [2]int64{2, 1}, sum: [2]uint64{5, 3}},
+		// Too many rows to track after the first mismatching bucket.
+		{count: [2]int64{4, 0}, sum: [2]uint64{9, 0}},
+		{count: [2]int64{1, 3}, sum: [2]uint64{1, 4}},
+		{count: [2]int64{2, 2}, sum: [2]uint64{1, 4}},
+		// Small enough to track after skipping the buckets above.
+		{count: [2]int64{0, 1}, sum: [2]uint64{0, 7}},
+	}
+	tracked, untracked, numUntracked := trackBuckets(buckets, 4)
+	require.Equal(t, []bool{false, true, false, false, false, true}, tracked)
+	require.Equal(t, Stats{NumMissing: 5, NumExtraneous: 3}, untracked)
+	require.Equal(t, 3, numUntracked)
+}
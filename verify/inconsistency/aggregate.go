@@ -0,0 +1,32 @@
+package inconsistency
+
+import (
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+)
+
+// MismatchingRowCount represents a table shard whose row count differs
+// between the source of truth and the target.
+type MismatchingRowCount struct {
+	dbtable.Name
+
+	ShardNum    int
+	TotalShards int
+
+	TruthCount  int64
+	TargetCount int64
+}
+
+// MismatchingAggregate represents a column aggregate (e.g. sum, min, max)
+// over a table shard which differs between the source of truth and the target.
+type MismatchingAggregate struct {
+	dbtable.Name
+
+	ShardNum    int
+	TotalShards int
+
+	Column    tree.Name
+	Aggregate string
+	TruthVal  tree.Datum
+	TargetVal tree.Datum
+}
@@ -1,13 +1,18 @@
 package inconsistency
 
-import "github.com/cockroachdb/molt/dbtable"
+import (
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+)
 
 // MissingTable represents a table that is missing from a database.
 type MissingTable struct {
 	dbtable.DBTable
+	Target dbconn.ID
 }
 
 // ExtraneousTable represents a table that is extraneous to a database.
 type ExtraneousTable struct {
 	dbtable.DBTable
+	Target dbconn.ID
 }
@@ -0,0 +1,284 @@
+package inconsistency
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/rs/zerolog"
+)
+
+// DefaultHTMLMaxRowsPerTable is the default number of rows of each table
+// included in an HTML report.
+const DefaultHTMLMaxRowsPerTable = 1000
+
+// HTMLReporter collects mismatching, missing and extraneous rows and writes
+// them to a self-contained HTML report when closed.
+type HTMLReporter struct {
+	path string
+	// maxRowsPerTable limits the rows of each table kept in the report.
+	// Rows beyond the limit are still counted.
+	maxRowsPerTable int
+	logger          zerolog.Logger
+
+	mu     sync.Mutex
+	tables map[dbtable.Name]*htmlTable
+}
+
+// NewHTMLReporter returns a reporter which writes an HTML report to path.
+func NewHTMLReporter(path string, maxRowsPerTable int, logger zerolog.Logger) *HTMLReporter {
+	return &HTMLReporter{
+		path:            path,
+		maxRowsPerTable: maxRowsPerTable,
+		logger:          logger,
+		tables:          make(map[dbtable.Name]*htmlTable),
+	}
+}
+
+type htmlCounts struct {
+	Mismatching int
+	Missing     int
+	Extraneous  int
+}
+
+func (c htmlCounts) Total() int {
+	return c.Mismatching + c.Missing + c.Extraneous
+}
+
+type htmlTable struct {
+	Name   string
+	Counts htmlCounts
+	Rows   []htmlRow
+}
+
+// Omitted is the number of rows which were counted but not kept.
+func (t *htmlTable) Omitted() int {
+	return t.Counts.Total() - len(t.Rows)
+}
+
+type htmlRow struct {
+	// ID is the anchor of the row's details.
+	ID         string
+	Kind       string
+	PrimaryKey string
+	Columns    []htmlColumn
+}
+
+// NumDiffering returns the number of columns which differ.
+func (r htmlRow) NumDiffering() int {
+	n := 0
+	for _, c := range r.Columns {
+		if c.Differs {
+			n++
+		}
+	}
+	return n
+}
+
+type htmlColumn struct {
+	Name    string
+	Truth   string
+	Target  string
+	Differs bool
+}
+
+// Report implements Reporter.
+func (r *HTMLReporter) Report(obj ReportableObject) {
+	var name dbtable.Name
+	var row htmlRow
+	switch obj := obj.(type) {
+	case MismatchingRow:
+		name = obj.Name
+		row = htmlRow{Kind: "mismatching", PrimaryKey: htmlPrimaryKey(obj.PrimaryKeyColumns, obj.PrimaryKeyValues)}
+		row.Columns = htmlPrimaryKeyColumns(obj.PrimaryKeyColumns, obj.PrimaryKeyValues, true, true)
+		for i, col := range obj.MismatchingColumns {
+			row.Columns = append(row.Columns, htmlColumn{
+				Name:    string(col),
+				Truth:   reportableVal(obj.TruthVals[i]),
+				Target:  reportableVal(obj.TargetVals[i]),
+				Differs: true,
+			})
+		}
+	case MissingRow:
+		name = obj.Name
+		row = htmlRow{Kind: "missing", PrimaryKey: htmlPrimaryKey(obj.PrimaryKeyColumns, obj.PrimaryKeyValues)}
+		for i, col := range obj.Columns {
+			row.Columns = append(row.Columns, htmlColumn{
+				Name:    string(col),
+				Truth:   reportableVal(obj.Values[i]),
+				Differs: true,
+			})
+		}
+	case ExtraneousRow:
+		name = obj.Name
+		row = htmlRow{Kind: "extraneous", PrimaryKey: htmlPrimaryKey(obj.PrimaryKeyColumns, obj.PrimaryKeyValues)}
+		row.Columns = htmlPrimaryKeyColumns(obj.PrimaryKeyColumns, obj.PrimaryKeyValues, false, true)
+	default:
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tables[name]
+	if !ok {
+		t = &htmlTable{Name: name.SafeString()}
+		r.tables[name] = t
+	}
+	switch row.Kind {
+	case "mismatching":
+		t.Counts.Mismatching++
+	case "missing":
+		t.Counts.Missing++
+	case "extraneous":
+		t.Counts.Extraneous++
+	}
+	if len(t.Rows) < r.maxRowsPerTable {
+		t.Rows = append(t.Rows, row)
+	}
+}
+
+func htmlPrimaryKey(cols []tree.Name, vals tree.Datums) string {
+	parts := make([]string, len(vals))
+	for i := range vals {
+		parts[i] = string(cols[i]) + "=" + reportableVal(vals[i])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// htmlPrimaryKeyColumns returns the primary key columns of a row, with the
+// values on the sides the row exists on.
+func htmlPrimaryKeyColumns(
+	cols []tree.Name, vals tree.Datums, onTruth bool, onTarget bool,
+) []htmlColumn {
+	ret := make([]htmlColumn, len(vals))
+	for i := range vals {
+		ret[i] = htmlColumn{Name: string(cols[i]), Differs: onTruth != onTarget}
+		if onTruth {
+			ret[i].Truth = reportableVal(vals[i])
+		}
+		if onTarget {
+			ret[i].Target = reportableVal(vals[i])
+		}
+	}
+	return ret
+}
+
+type htmlReport struct {
+	GeneratedAt string
+	Counts      htmlCounts
+	Tables      []*htmlTable
+}
+
+// Percent returns the percentage of inconsistencies which n makes up.
+func (r htmlReport) Percent(n int) int {
+	if r.Counts.Total() == 0 {
+		return 0
+	}
+	return n * 100 / r.Counts.Total()
+}
+
+// Close implements Reporter, writing the report.
+func (r *HTMLReporter) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.write(); err != nil {
+		r.logger.Err(err).Str("path", r.path).Msgf("error writing HTML report")
+		return
+	}
+	r.logger.Info().Str("path", r.path).Msgf("wrote HTML report")
+}
+
+func (r *HTMLReporter) write() error {
+	f, err := os.Create(r.path)
+	if err != nil {
+		return err
+	}
+	if err := htmlReportTemplate.Execute(f, r.report(time.Now())); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (r *HTMLReporter) report(now time.Time) htmlReport {
+	ret := htmlReport{GeneratedAt: now.UTC().Format(time.RFC3339)}
+	for _, t := range r.tables {
+		ret.Tables = append(ret.Tables, t)
+		ret.Counts.Mismatching += t.Counts.Mismatching
+		ret.Counts.Missing += t.Counts.Missing
+		ret.Counts.Extraneous += t.Counts.Extraneous
+	}
+	sort.Slice(ret.Tables, func(i, j int) bool {
+		return ret.Tables[i].Name < ret.Tables[j].Name
+	})
+	for tblIdx, t := range ret.Tables {
+		for rowIdx := range t.Rows {
+			t.Rows[rowIdx].ID = fmt.Sprintf("row-%d-%d", tblIdx, rowIdx)
+		}
+	}
+	return ret
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>MOLT verify report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; font-family: monospace; }
+th { background: #f0f0f0; font-family: sans-serif; }
+.chart { width: 40em; }
+.bar { height: 1.4em; margin: 4px 0; color: #fff; padding-left: 6px; white-space: nowrap; min-width: 2em; }
+.mismatching { background: #d9822b; }
+.missing { background: #c23030; }
+.extraneous { background: #2b6cd9; }
+tr.differs td { background: #ffe0e0; }
+.kind { color: #fff; padding: 1px 6px; border-radius: 3px; font-family: sans-serif; }
+.detail { margin: 1em 0 2em 0; }
+.omitted { color: #666; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>MOLT verify report</h1>
+<p>Generated at {{.GeneratedAt}}.
+{{.Counts.Total}} inconsistencies across {{len .Tables}} tables.</p>
+
+<h2>Summary</h2>
+<div class="chart">
+<div class="bar mismatching" style="width: {{.Percent .Counts.Mismatching}}%">mismatching: {{.Counts.Mismatching}}</div>
+<div class="bar missing" style="width: {{.Percent .Counts.Missing}}%">missing: {{.Counts.Missing}}</div>
+<div class="bar extraneous" style="width: {{.Percent .Counts.Extraneous}}%">extraneous: {{.Counts.Extraneous}}</div>
+</div>
+<table>
+<tr><th>Table</th><th>Mismatching</th><th>Missing</th><th>Extraneous</th></tr>
+{{range .Tables}}<tr><td><a href="#{{.Name}}">{{.Name}}</a></td><td>{{.Counts.Mismatching}}</td><td>{{.Counts.Missing}}</td><td>{{.Counts.Extraneous}}</td></tr>
+{{end}}</table>
+
+{{range .Tables}}
+<h2 id="{{.Name}}">{{.Name}}</h2>
+<table>
+<tr><th>Type</th><th>Primary key</th><th>Differing columns</th></tr>
+{{range .Rows}}<tr><td><span class="kind {{.Kind}}">{{.Kind}}</span></td><td><a href="#{{.ID}}">{{.PrimaryKey}}</a></td><td>{{.NumDiffering}}</td></tr>
+{{end}}</table>
+{{if .Omitted}}<p class="omitted">{{.Omitted}} more rows not shown.</p>{{end}}
+{{range .Rows}}
+<div class="detail" id="{{.ID}}">
+<h3><span class="kind {{.Kind}}">{{.Kind}}</span> {{.PrimaryKey}}</h3>
+<table>
+<tr><th>Column</th><th>Source of truth</th><th>Target</th></tr>
+{{range .Columns}}<tr{{if .Differs}} class="differs"{{end}}><td>{{.Name}}</td><td>{{.Truth}}</td><td>{{.Target}}</td></tr>
+{{end}}</table>
+</div>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
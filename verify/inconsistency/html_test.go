@@ -0,0 +1,67 @@
+package inconsistency
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLReporter(t *testing.T) {
+	name := dbtable.Name{Schema: "public", Table: "tbl"}
+	r := NewHTMLReporter("", 2, zerolog.Nop())
+	r.Report(MismatchingRow{
+		Name:               name,
+		PrimaryKeyColumns:  []tree.Name{"id"},
+		PrimaryKeyValues:   tree.Datums{tree.NewDInt(1)},
+		MismatchingColumns: []tree.Name{"txt"},
+		TruthVals:          tree.Datums{tree.NewDString("<b>truth</b>")},
+		TargetVals:         tree.Datums{tree.NewDString("target")},
+	})
+	r.Report(MissingRow{
+		Name:              name,
+		PrimaryKeyColumns: []tree.Name{"id"},
+		PrimaryKeyValues:  tree.Datums{tree.NewDInt(2)},
+		Columns:           []tree.Name{"id", "txt"},
+		Values:            tree.Datums{tree.NewDInt(2), tree.NewDString("missing")},
+	})
+	r.Report(ExtraneousRow{
+		Name:              name,
+		PrimaryKeyColumns: []tree.Name{"id"},
+		PrimaryKeyValues:  tree.Datums{tree.NewDInt(3)},
+	})
+	r.Report(StatusReport{Info: "ignored"})
+
+	report := r.report(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	require.Equal(t, htmlCounts{Mismatching: 1, Missing: 1, Extraneous: 1}, report.Counts)
+	require.Len(t, report.Tables, 1)
+	tbl := report.Tables[0]
+	require.Equal(t, "public.tbl", tbl.Name)
+	require.Equal(t, 1, tbl.Omitted())
+	require.Equal(
+		t,
+		htmlRow{
+			ID:         "row-0-0",
+			Kind:       "mismatching",
+			PrimaryKey: "id=1",
+			Columns: []htmlColumn{
+				{Name: "id", Truth: "1", Target: "1"},
+				{Name: "txt", Truth: "<b>truth</b>", Target: "target", Differs: true},
+			},
+		},
+		tbl.Rows[0],
+	)
+	require.Equal(t, 2, tbl.Rows[1].NumDiffering())
+
+	var buf bytes.Buffer
+	require.NoError(t, htmlReportTemplate.Execute(&buf, report))
+	out := buf.String()
+	require.Contains(t, out, `<a href="#row-0-0">id=1</a>`)
+	require.Contains(t, out, `id="row-0-1"`)
+	require.Contains(t, out, `&lt;b&gt;truth&lt;/b&gt;`)
+	require.Contains(t, out, "1 more rows not shown.")
+}
@@ -43,17 +43,17 @@ type LogReporter struct {
 func (l LogReporter) Report(obj ReportableObject) {
 	switch obj := obj.(type) {
 	case MissingTable:
-		l.Warn().
+		l.warn(obj.Target).
 			Str("table_schema", string(obj.Schema)).
 			Str("table_name", string(obj.Table)).
 			Msgf("missing table detected")
 	case ExtraneousTable:
-		l.Warn().
+		l.warn(obj.Target).
 			Str("table_schema", string(obj.Schema)).
 			Str("table_name", string(obj.Table)).
 			Msgf("extraneous table detected")
 	case MismatchingTableDefinition:
-		l.Warn().
+		l.warn(obj.Target).
 			Str("table_schema", string(obj.Schema)).
 			Str("table_name", string(obj.Table)).
 			Str("mismatch_info", obj.Info).
@@ -67,7 +67,7 @@ func (l LogReporter) Report(obj ReportableObject) {
 			targetVals = targetVals.Str(string(col), reportableVal(obj.TruthVals[i]))
 			sourceValues = sourceValues.Str(string(col), reportableVal(obj.TargetVals[i]))
 		}
-		l.Warn().
+		l.warn(obj.Target).
 			Str("table_schema", string(obj.Schema)).
 			Str("table_name", string(obj.Table)).
 			Dict("source_values", targetVals).
@@ -75,17 +75,137 @@ func (l LogReporter) Report(obj ReportableObject) {
 			Strs("primary_key", zipPrimaryKeysForReporting(obj.PrimaryKeyValues)).
 			Msgf("mismatching row value")
 	case MissingRow:
-		l.Warn().
+		l.warn(obj.Target).
 			Str("table_schema", string(obj.Schema)).
 			Str("table_name", string(obj.Table)).
 			Strs("primary_key", zipPrimaryKeysForReporting(obj.PrimaryKeyValues)).
 			Msgf("missing row")
 	case ExtraneousRow:
-		l.Warn().
+		l.warn(obj.Target).
 			Str("table_schema", string(obj.Schema)).
 			Str("table_name", string(obj.Table)).
 			Strs("primary_key", zipPrimaryKeysForReporting(obj.PrimaryKeyValues)).
 			Msgf("extraneous row")
+	case LiveRetryResolved:
+		l.debug(obj.Target).
+			Str("table_schema", string(obj.Schema)).
+			Str("table_name", string(obj.Table)).
+			Strs("primary_key", zipPrimaryKeysForReporting(obj.PrimaryKeyValues)).
+			Str("inconsistency_type", obj.InconsistencyType).
+			Int("live_retries", obj.LiveRetries).
+			Msgf("row consistent after live retry")
+	case MismatchingRowOccurrences:
+		values := zerolog.Dict()
+		for i := range obj.Values {
+			values = values.Str(string(obj.Columns[i]), reportableVal(obj.Values[i]))
+		}
+		l.warn(obj.Target).
+			Str("table_schema", string(obj.Schema)).
+			Str("table_name", string(obj.Table)).
+			Dict("values", values).
+			Int64("source_count", obj.TruthCount).
+			Int64("target_count", obj.TargetCount).
+			Msgf("mismatching row occurrences")
+	case MismatchingRowCount:
+		l.Warn().
+			Str("table_schema", string(obj.Schema)).
+			Str("table_name", string(obj.Table)).
+			Str("shard", fmt.Sprintf("%d/%d", obj.ShardNum, obj.TotalShards)).
+			Int64("source_row_count", obj.TruthCount).
+			Int64("target_row_count", obj.TargetCount).
+			Msgf("mismatching row count")
+	case MismatchingAggregate:
+		l.Warn().
+			Str("table_schema", string(obj.Schema)).
+			Str("table_name", string(obj.Table)).
+			Str("shard", fmt.Sprintf("%d/%d", obj.ShardNum, obj.TotalShards)).
+			Str("column", string(obj.Column)).
+			Str("aggregate", obj.Aggregate).
+			Str("source_value", reportableVal(obj.TruthVal)).
+			Str("target_value", reportableVal(obj.TargetVal)).
+			Msgf("mismatching column aggregate")
+	case MismatchingIndex:
+		l.Warn().
+			Str("table_schema", string(obj.Schema)).
+			Str("table_name", string(obj.Table)).
+			Str("source_index", obj.TruthIndex).
+			Str("source_definition", obj.TruthDefinition).
+			Str("target_index", obj.TargetIndex).
+			Str("target_definition", obj.TargetDefinition).
+			Msg(schemaObjectMessage("index", obj.TruthIndex, obj.TargetIndex))
+	case MismatchingConstraint:
+		l.Warn().
+			Str("table_schema", string(obj.Schema)).
+			Str("table_name", string(obj.Table)).
+			Str("constraint_type", obj.ConstraintType).
+			Str("source_constraint", obj.TruthConstraint).
+			Str("source_definition", obj.TruthDefinition).
+			Str("target_constraint", obj.TargetConstraint).
+			Str("target_definition", obj.TargetDefinition).
+			Msg(schemaObjectMessage("constraint", obj.TruthConstraint, obj.TargetConstraint))
+	case MismatchingColumnDefault:
+		l.Warn().
+			Str("table_schema", string(obj.Schema)).
+			Str("table_name", string(obj.Table)).
+			Str("column", string(obj.Column)).
+			Str("source_default", obj.TruthDefault).
+			Str("target_default", obj.TargetDefault).
+			Msgf("mismatching column default")
+	case MismatchingComputedColumn:
+		l.Warn().
+			Str("table_schema", string(obj.Schema)).
+			Str("table_name", string(obj.Table)).
+			Str("column", string(obj.Column)).
+			Str("source_expression", obj.TruthExpression).
+			Str("target_expression", obj.TargetExpression).
+			Msgf("mismatching computed column")
+	case MissingSequence:
+		l.Warn().
+			Str("sequence_schema", string(obj.Schema)).
+			Str("sequence_name", string(obj.Table)).
+			Msgf("missing sequence detected")
+	case ExtraneousSequence:
+		l.Warn().
+			Str("sequence_schema", string(obj.Schema)).
+			Str("sequence_name", string(obj.Table)).
+			Msgf("extraneous sequence detected")
+	case MismatchingSequenceValue:
+		l.Warn().
+			Str("sequence_schema", string(obj.Schema)).
+			Str("sequence_name", string(obj.Table)).
+			Int64("source_value", obj.TruthValue).
+			Int64("target_value", obj.TargetValue).
+			Msgf("mismatching sequence value")
+	case MissingObject:
+		l.Warn().
+			Str("object_schema", string(obj.Schema)).
+			Str("object_name", string(obj.Table)).
+			Msg("missing " + obj.ObjectType + " detected")
+	case ExtraneousObject:
+		l.Warn().
+			Str("object_schema", string(obj.Schema)).
+			Str("object_name", string(obj.Table)).
+			Msg("extraneous " + obj.ObjectType + " detected")
+	case MismatchingEnumLabels:
+		l.Warn().
+			Str("enum_schema", string(obj.Schema)).
+			Str("enum_name", string(obj.Table)).
+			Strs("source_labels", obj.TruthLabels).
+			Strs("target_labels", obj.TargetLabels).
+			Msgf("mismatching enum labels")
+	case MismatchingViewDefinition:
+		l.Warn().
+			Str("view_schema", string(obj.Schema)).
+			Str("view_name", string(obj.Table)).
+			Str("mismatch_info", obj.Info).
+			Msgf("mismatching view definition")
+	case RoutineRequiresReview:
+		l.Info().
+			Str("routine_schema", string(obj.Schema)).
+			Str("routine_name", string(obj.Table)).
+			Str("routine_type", obj.RoutineType).
+			Bool("manual_review", true).
+			Msg(obj.RoutineType + " exists on both sides and requires manual review")
 	default:
 		l.Error().
 			Str("type", fmt.Sprintf("%T", obj)).
@@ -93,6 +213,18 @@ func (l LogReporter) Report(obj ReportableObject) {
 	}
 }
 
+// schemaObjectMessage describes a schema object which differs, depending on
+// which side it exists on.
+func schemaObjectMessage(kind string, truthName string, targetName string) string {
+	switch {
+	case targetName == "":
+		return "missing " + kind
+	case truthName == "":
+		return "extraneous " + kind
+	}
+	return "mismatching " + kind
+}
+
 func reportableVal(d tree.Datum) string {
 	f := tree.NewFmtCtx(tree.FmtBareStrings | tree.FmtParsableNumerics)
 	f.FormatNode(d)
@@ -107,6 +239,24 @@ func zipPrimaryKeysForReporting(columnVals tree.Datums) []string {
 	return ret
 }
 
+// warn returns a warning event, labelled with the target if set.
+func (l LogReporter) warn(target dbconn.ID) *zerolog.Event {
+	e := l.Warn()
+	if target != "" {
+		e = e.Str("target", string(target))
+	}
+	return e
+}
+
+// debug returns a debug event, labelled with the target if set.
+func (l LogReporter) debug(target dbconn.ID) *zerolog.Event {
+	e := l.Debug()
+	if target != "" {
+		e = e.Str("target", string(target))
+	}
+	return e
+}
+
 func (l LogReporter) Close() {
 }
 
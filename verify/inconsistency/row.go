@@ -2,11 +2,19 @@ package inconsistency
 
 import (
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/dbtable"
 )
 
 type ReportableObject interface{}
 
+// Inconsistency types of rows, as written to the results table.
+const (
+	MissingRowType     = "missing_row"
+	ExtraneousRowType  = "extraneous_row"
+	MismatchingRowType = "mismatching_row"
+)
+
 type MissingRow struct {
 	dbtable.Name
 
@@ -15,6 +23,11 @@ type MissingRow struct {
 
 	Columns []tree.Name
 	Values  tree.Datums
+
+	Target dbconn.ID
+	// LiveRetries is the number of times the row was retried during live
+	// verification before being reported.
+	LiveRetries int
 }
 
 type ExtraneousRow struct {
@@ -22,6 +35,11 @@ type ExtraneousRow struct {
 
 	PrimaryKeyColumns []tree.Name
 	PrimaryKeyValues  tree.Datums
+
+	Target dbconn.ID
+	// LiveRetries is the number of times the row was retried during live
+	// verification before being reported.
+	LiveRetries int
 }
 
 type MismatchingRow struct {
@@ -33,4 +51,89 @@ type MismatchingRow struct {
 	MismatchingColumns []tree.Name
 	TruthVals          tree.Datums
 	TargetVals         tree.Datums
+
+	Target dbconn.ID
+	// LiveRetries is the number of times the row was retried during live
+	// verification before being reported.
+	LiveRetries int
+}
+
+// LiveRetryResolved is reported when a row found to be inconsistent during
+// live verification is consistent once retried.
+type LiveRetryResolved struct {
+	dbtable.Name
+
+	PrimaryKeyColumns []tree.Name
+	PrimaryKeyValues  tree.Datums
+
+	// InconsistencyType is the type the row was first found to be
+	// inconsistent with, one of MissingRowType, ExtraneousRowType or
+	// MismatchingRowType.
+	InconsistencyType string
+	// LiveRetries is the number of times the row was retried before it was
+	// consistent.
+	LiveRetries int
+
+	Target dbconn.ID
+}
+
+// MismatchingRowOccurrences represents a row of a table without a primary key
+// which occurs a different number of times on the source of truth and target.
+type MismatchingRowOccurrences struct {
+	dbtable.Name
+
+	Columns []tree.Name
+	// Values may be empty if the row could not be found again when
+	// collecting rows to report.
+	Values tree.Datums
+
+	TruthCount  int64
+	TargetCount int64
+
+	Target dbconn.ID
+}
+
+// WithTarget returns the inconsistency labelled with the target it is on, if
+// it can be labelled. The Target field of inconsistencies is only set when
+// verifying against multiple targets.
+func WithTarget(obj ReportableObject, target dbconn.ID) ReportableObject {
+	switch obj := obj.(type) {
+	case MissingTable:
+		obj.Target = target
+		return obj
+	case ExtraneousTable:
+		obj.Target = target
+		return obj
+	case MismatchingTableDefinition:
+		obj.Target = target
+		return obj
+	case MissingRow:
+		obj.Target = target
+		return obj
+	case ExtraneousRow:
+		obj.Target = target
+		return obj
+	case MismatchingRow:
+		obj.Target = target
+		return obj
+	case MismatchingRowOccurrences:
+		obj.Target = target
+		return obj
+	case LiveRetryResolved:
+		obj.Target = target
+		return obj
+	}
+	return obj
+}
+
+// TargetReporter labels inconsistencies with the target they are on before
+// reporting them, for verifying against multiple targets.
+type TargetReporter struct {
+	Reporter
+	Target dbconn.ID
+}
+
+// Report implements Reporter.
+func (r TargetReporter) Report(obj ReportableObject) {
+	r.Reporter.Report(WithTarget(obj, r.Target))
 }
@@ -0,0 +1,119 @@
+package inconsistency
+
+import (
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+)
+
+// MismatchingIndex represents a secondary index which is missing from,
+// extraneous on or defined differently on the target. The name and
+// definition of the index on a side is empty if it does not exist there.
+type MismatchingIndex struct {
+	dbtable.Name
+
+	TruthIndex       string
+	TruthDefinition  string
+	TargetIndex      string
+	TargetDefinition string
+}
+
+// MismatchingConstraint represents a CHECK or FOREIGN KEY constraint which is
+// missing from, extraneous on or defined differently on the target. The name
+// and definition of the constraint on a side is empty if it does not exist
+// there.
+type MismatchingConstraint struct {
+	dbtable.Name
+
+	// ConstraintType is either "CHECK" or "FOREIGN KEY".
+	ConstraintType string
+
+	TruthConstraint  string
+	TruthDefinition  string
+	TargetConstraint string
+	TargetDefinition string
+}
+
+// MismatchingColumnDefault represents a column whose DEFAULT expression
+// differs between the source of truth and the target. An empty default
+// means the column has no default.
+type MismatchingColumnDefault struct {
+	dbtable.Name
+
+	Column        tree.Name
+	TruthDefault  string
+	TargetDefault string
+}
+
+// MismatchingComputedColumn represents a column whose computed (generated)
+// expression differs between the source of truth and the target. An empty
+// expression means the column is not computed.
+type MismatchingComputedColumn struct {
+	dbtable.Name
+
+	Column           tree.Name
+	TruthExpression  string
+	TargetExpression string
+}
+
+// MissingSequence represents a sequence which is missing from the target.
+type MissingSequence struct {
+	dbtable.Name
+}
+
+// ExtraneousSequence represents a sequence which only exists on the target.
+type ExtraneousSequence struct {
+	dbtable.Name
+}
+
+// MismatchingSequenceValue represents a sequence whose current value differs
+// between the source of truth and the target.
+type MismatchingSequenceValue struct {
+	dbtable.Name
+
+	TruthValue  int64
+	TargetValue int64
+}
+
+// MissingObject represents a non-table schema object, e.g. a view, enum,
+// function or procedure, which is missing from the target.
+type MissingObject struct {
+	dbtable.Name
+
+	// ObjectType is the kind of object, e.g. "view" or "enum".
+	ObjectType string
+}
+
+// ExtraneousObject represents a non-table schema object which only exists on
+// the target.
+type ExtraneousObject struct {
+	dbtable.Name
+
+	// ObjectType is the kind of object, e.g. "view" or "enum".
+	ObjectType string
+}
+
+// MismatchingEnumLabels represents an enum whose labels, or the order of its
+// labels, differ between the source of truth and the target.
+type MismatchingEnumLabels struct {
+	dbtable.Name
+
+	TruthLabels  []string
+	TargetLabels []string
+}
+
+// MismatchingViewDefinition represents a view whose columns differ between
+// the source of truth and the target.
+type MismatchingViewDefinition struct {
+	dbtable.Name
+	Info string
+}
+
+// RoutineRequiresReview represents a function or procedure which exists on
+// both the source of truth and the target. Routine bodies cannot be compared
+// across databases, so they are flagged for manual review.
+type RoutineRequiresReview struct {
+	dbtable.Name
+
+	// RoutineType is either "function" or "procedure".
+	RoutineType string
+}
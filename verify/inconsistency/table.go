@@ -1,9 +1,13 @@
 package inconsistency
 
-import "github.com/cockroachdb/molt/dbtable"
+import (
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+)
 
 // MismatchingTableDefinition represents a missing table definition.
 type MismatchingTableDefinition struct {
 	dbtable.DBTable
-	Info string
+	Info   string
+	Target dbconn.ID
 }
@@ -0,0 +1,377 @@
+package inconsistency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/rs/zerolog"
+)
+
+// DefaultResultsTable is the default table results are written to by the
+// TableReporter.
+const DefaultResultsTable = "molt_verify_results"
+
+// DefaultResultsBatchSize is the default number of results written to the
+// results table at a time.
+const DefaultResultsBatchSize = 100
+
+// DefaultResultsFlushInterval is the default interval at which results are
+// written to the results table, even if a batch is not full.
+const DefaultResultsFlushInterval = 10 * time.Second
+
+// resultsColumns are the columns written to the results table, in order.
+var resultsColumns = []string{
+	"run_id",
+	"reported_at",
+	"table_schema",
+	"table_name",
+	"inconsistency_type",
+	"primary_key",
+	"column_diffs",
+	"live_retry_outcome",
+}
+
+// Live retry outcomes of rows retried during live verification.
+const (
+	// liveRetryStillInconsistent is the outcome of rows which remained
+	// inconsistent after being retried.
+	liveRetryStillInconsistent = "still_inconsistent"
+	// liveRetryResolved is the outcome of rows which were consistent once
+	// retried.
+	liveRetryResolved = "resolved"
+)
+
+// TableReporter writes inconsistencies into a table on a PostgreSQL or
+// CockroachDB database, so that results can be queried and kept across
+// runs. Results are written in batches, or once the flush interval has
+// passed, and any remaining results are written when the reporter is closed.
+type TableReporter struct {
+	conn      *dbconn.PGConn
+	table     *tree.TableName
+	runID     string
+	batchSize int
+	logger    zerolog.Logger
+
+	stop chan struct{}
+	done chan struct{}
+
+	mu      sync.Mutex
+	pending []result
+}
+
+// result is a row of the results table.
+type result struct {
+	reportedAt        time.Time
+	name              dbtable.Name
+	inconsistencyType string
+	primaryKey        map[string]string
+	columnDiffs       map[string]columnDiff
+	liveRetryOutcome  string
+	// shard is the shard of inconsistencies found on a table shard, e.g.
+	// aggregates, which is only used to distinguish them.
+	shard string
+}
+
+type columnDiff struct {
+	Source *string `json:"source"`
+	Target *string `json:"target"`
+}
+
+// NewTableReporter returns a TableReporter writing to the given table using
+// conn, creating the table if it does not exist. The reporter closes conn
+// when it is closed. runID identifies the results of this run. Pending
+// results are written every flushInterval, as a continuous verification is
+// never closed.
+func NewTableReporter(
+	ctx context.Context,
+	conn dbconn.Conn,
+	table string,
+	runID string,
+	batchSize int,
+	flushInterval time.Duration,
+	logger zerolog.Logger,
+) (*TableReporter, error) {
+	if flushInterval <= 0 {
+		return nil, errors.Newf("results flush interval must be positive, got %s", flushInterval)
+	}
+	pgConn, ok := conn.(*dbconn.PGConn)
+	if !ok {
+		return nil, errors.Newf("writing results to a table is not supported for %s", conn.Dialect())
+	}
+	un, err := parser.ParseTableName(table)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing results table name %q", table)
+	}
+	tn := un.ToTableName()
+	r := &TableReporter{
+		conn:      pgConn,
+		table:     &tn,
+		runID:     runID,
+		batchSize: batchSize,
+		logger:    logger,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if _, err := pgConn.Exec(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (
+  run_id TEXT NOT NULL,
+  reported_at TIMESTAMPTZ NOT NULL,
+  table_schema TEXT NOT NULL,
+  table_name TEXT NOT NULL,
+  inconsistency_type TEXT NOT NULL,
+  primary_key JSONB,
+  column_diffs JSONB,
+  live_retry_outcome TEXT
+)`,
+		r.tableString(),
+	)); err != nil {
+		return nil, errors.Wrapf(err, "error creating results table %s", r.tableString())
+	}
+	go r.flushEvery(flushInterval)
+	return r, nil
+}
+
+// flushEvery writes pending results every interval until the reporter is
+// closed.
+func (r *TableReporter) flushEvery(interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			r.flushLocked()
+			r.mu.Unlock()
+		}
+	}
+}
+
+// TableName returns the name of the results table, which is in the public
+// schema unless a schema was given.
+func (r *TableReporter) TableName() dbtable.Name {
+	schema := r.table.SchemaName
+	if !r.table.ExplicitSchema {
+		schema = "public"
+	}
+	return dbtable.Name{Schema: schema, Table: r.table.ObjectName}
+}
+
+func (r *TableReporter) tableString() string {
+	return tree.AsString(r.table)
+}
+
+// Report implements Reporter.
+func (r *TableReporter) Report(obj ReportableObject) {
+	var res result
+	if resolved, ok := obj.(LiveRetryResolved); ok {
+		// Rows consistent once retried are only recorded in the results
+		// table, and are not inconsistencies for the other reporters.
+		res = result{
+			name:              resolved.Name,
+			inconsistencyType: resolved.InconsistencyType,
+			primaryKey:        resultPrimaryKey(resolved.PrimaryKeyColumns, resolved.PrimaryKeyValues),
+			liveRetryOutcome:  liveRetryResolved,
+		}
+	} else if res, ok = makeResult(obj); !ok {
+		return
+	}
+	res.reportedAt = time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, res)
+	if len(r.pending) >= r.batchSize {
+		r.flushLocked()
+	}
+}
+
+// Close implements Reporter, writing any remaining results.
+func (r *TableReporter) Close() {
+	close(r.stop)
+	<-r.done
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushLocked()
+	_ = r.conn.Close(context.Background())
+}
+
+func (r *TableReporter) flushLocked() {
+	if len(r.pending) == 0 {
+		return
+	}
+	if err := r.write(context.Background(), r.pending); err != nil {
+		r.logger.Err(err).
+			Str("table", r.tableString()).
+			Int("num_results", len(r.pending)).
+			Msgf("error writing results")
+	}
+	r.pending = r.pending[:0]
+}
+
+func (r *TableReporter) write(ctx context.Context, results []result) error {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(r.tableString())
+	sb.WriteString(" (")
+	sb.WriteString(strings.Join(resultsColumns, ", "))
+	sb.WriteString(") VALUES ")
+	args := make([]any, 0, len(results)*len(resultsColumns))
+	for i, res := range results {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for j := range resultsColumns {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "$%d", len(args)+j+1)
+		}
+		sb.WriteString(")")
+		vals, err := r.resultValues(res)
+		if err != nil {
+			return err
+		}
+		args = append(args, vals...)
+	}
+	_, err := r.conn.Exec(ctx, sb.String(), args...)
+	return err
+}
+
+// resultValues returns the values of each of resultsColumns for a result.
+func (r *TableReporter) resultValues(res result) ([]any, error) {
+	var pk, diffs *string
+	if res.primaryKey != nil {
+		b, err := json.Marshal(res.primaryKey)
+		if err != nil {
+			return nil, err
+		}
+		s := string(b)
+		pk = &s
+	}
+	if res.columnDiffs != nil {
+		b, err := json.Marshal(res.columnDiffs)
+		if err != nil {
+			return nil, err
+		}
+		s := string(b)
+		diffs = &s
+	}
+	var liveRetryOutcome *string
+	if res.liveRetryOutcome != "" {
+		liveRetryOutcome = &res.liveRetryOutcome
+	}
+	return []any{
+		r.runID,
+		res.reportedAt,
+		string(res.name.Schema),
+		string(res.name.Table),
+		res.inconsistencyType,
+		pk,
+		diffs,
+		liveRetryOutcome,
+	}, nil
+}
+
+func makeResult(obj ReportableObject) (result, bool) {
+	switch obj := obj.(type) {
+	case MissingTable:
+		return result{name: obj.Name, inconsistencyType: "missing_table"}, true
+	case ExtraneousTable:
+		return result{name: obj.Name, inconsistencyType: "extraneous_table"}, true
+	case MismatchingRow:
+		res := result{
+			name:              obj.Name,
+			inconsistencyType: MismatchingRowType,
+			primaryKey:        resultPrimaryKey(obj.PrimaryKeyColumns, obj.PrimaryKeyValues),
+			columnDiffs:       make(map[string]columnDiff, len(obj.MismatchingColumns)),
+			liveRetryOutcome:  liveRetryOutcome(obj.LiveRetries),
+		}
+		for i, col := range obj.MismatchingColumns {
+			truth, target := reportableVal(obj.TruthVals[i]), reportableVal(obj.TargetVals[i])
+			res.columnDiffs[string(col)] = columnDiff{Source: &truth, Target: &target}
+		}
+		return res, true
+	case MissingRow:
+		res := result{
+			name:              obj.Name,
+			inconsistencyType: MissingRowType,
+			primaryKey:        resultPrimaryKey(obj.PrimaryKeyColumns, obj.PrimaryKeyValues),
+			columnDiffs:       make(map[string]columnDiff, len(obj.Columns)),
+			liveRetryOutcome:  liveRetryOutcome(obj.LiveRetries),
+		}
+		for i, col := range obj.Columns {
+			truth := reportableVal(obj.Values[i])
+			res.columnDiffs[string(col)] = columnDiff{Source: &truth}
+		}
+		return res, true
+	case ExtraneousRow:
+		return result{
+			name:              obj.Name,
+			inconsistencyType: ExtraneousRowType,
+			primaryKey:        resultPrimaryKey(obj.PrimaryKeyColumns, obj.PrimaryKeyValues),
+			liveRetryOutcome:  liveRetryOutcome(obj.LiveRetries),
+		}, true
+	case MismatchingRowOccurrences:
+		res := result{
+			name:              obj.Name,
+			inconsistencyType: "mismatching_row_occurrences",
+			columnDiffs:       make(map[string]columnDiff, len(obj.Values)),
+		}
+		for i := range obj.Values {
+			val := reportableVal(obj.Values[i])
+			res.columnDiffs[string(obj.Columns[i])] = columnDiff{Source: &val, Target: &val}
+		}
+		truthCount, targetCount := fmt.Sprint(obj.TruthCount), fmt.Sprint(obj.TargetCount)
+		res.columnDiffs["count(*)"] = columnDiff{Source: &truthCount, Target: &targetCount}
+		return res, true
+	case MismatchingRowCount:
+		truthCount, targetCount := fmt.Sprint(obj.TruthCount), fmt.Sprint(obj.TargetCount)
+		return result{
+			name:              obj.Name,
+			inconsistencyType: "mismatching_row_count",
+			columnDiffs:       map[string]columnDiff{"count(*)": {Source: &truthCount, Target: &targetCount}},
+			shard:             fmt.Sprintf("%d/%d", obj.ShardNum, obj.TotalShards),
+		}, true
+	case MismatchingAggregate:
+		truth, target := reportableVal(obj.TruthVal), reportableVal(obj.TargetVal)
+		return result{
+			name:              obj.Name,
+			inconsistencyType: "mismatching_aggregate",
+			columnDiffs: map[string]columnDiff{
+				fmt.Sprintf("%s(%s)", obj.Aggregate, obj.Column): {Source: &truth, Target: &target},
+			},
+			shard: fmt.Sprintf("%d/%d", obj.ShardNum, obj.TotalShards),
+		}, true
+	}
+	return result{}, false
+}
+
+// liveRetryOutcome returns the live retry outcome of a row reported after
+// the given number of live retries, which is empty if it was not retried.
+func liveRetryOutcome(liveRetries int) string {
+	if liveRetries > 0 {
+		return liveRetryStillInconsistent
+	}
+	return ""
+}
+
+func resultPrimaryKey(cols []tree.Name, vals tree.Datums) map[string]string {
+	ret := make(map[string]string, len(vals))
+	for i := range vals {
+		ret[string(cols[i])] = reportableVal(vals[i])
+	}
+	return ret
+}
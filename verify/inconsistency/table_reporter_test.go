@@ -0,0 +1,108 @@
+package inconsistency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableReporterResultValues(t *testing.T) {
+	name := dbtable.Name{Schema: "public", Table: "tbl"}
+	reportedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	strPtr := func(s string) *string { return &s }
+	for _, tc := range []struct {
+		desc     string
+		obj      ReportableObject
+		expected []any
+	}{
+		{
+			desc: "mismatching row",
+			obj: MismatchingRow{
+				Name:               name,
+				PrimaryKeyColumns:  []tree.Name{"id"},
+				PrimaryKeyValues:   tree.Datums{tree.NewDInt(1)},
+				MismatchingColumns: []tree.Name{"txt"},
+				TruthVals:          tree.Datums{tree.NewDString("a")},
+				TargetVals:         tree.Datums{tree.DNull},
+			},
+			expected: []any{
+				"run", reportedAt, "public", "tbl", "mismatching_row",
+				strPtr(`{"id":"1"}`), strPtr(`{"txt":{"source":"a","target":"NULL"}}`), (*string)(nil),
+			},
+		},
+		{
+			desc: "missing row after live retries",
+			obj: MissingRow{
+				Name:              name,
+				PrimaryKeyColumns: []tree.Name{"id"},
+				PrimaryKeyValues:  tree.Datums{tree.NewDInt(1)},
+				Columns:           []tree.Name{"id", "txt"},
+				Values:            tree.Datums{tree.NewDInt(1), tree.NewDString("a")},
+				LiveRetries:       3,
+			},
+			expected: []any{
+				"run", reportedAt, "public", "tbl", "missing_row",
+				strPtr(`{"id":"1"}`), strPtr(`{"id":{"source":"1","target":null},"txt":{"source":"a","target":null}}`), strPtr("still_inconsistent"),
+			},
+		},
+		{
+			desc: "extraneous row",
+			obj: ExtraneousRow{
+				Name:              name,
+				PrimaryKeyColumns: []tree.Name{"id"},
+				PrimaryKeyValues:  tree.Datums{tree.NewDInt(2)},
+			},
+			expected: []any{
+				"run", reportedAt, "public", "tbl", "extraneous_row",
+				strPtr(`{"id":"2"}`), (*string)(nil), (*string)(nil),
+			},
+		},
+		{
+			desc: "missing table",
+			obj:  MissingTable{DBTable: dbtable.DBTable{Name: name}},
+			expected: []any{
+				"run", reportedAt, "public", "tbl", "missing_table",
+				(*string)(nil), (*string)(nil), (*string)(nil),
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := &TableReporter{runID: "run"}
+			res, ok := makeResult(tc.obj)
+			require.True(t, ok)
+			res.reportedAt = reportedAt
+			vals, err := r.resultValues(res)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, vals)
+		})
+	}
+
+	_, ok := makeResult(StatusReport{Info: "ignored"})
+	require.False(t, ok)
+}
+
+func TestTableReporterLiveRetryResolved(t *testing.T) {
+	r := &TableReporter{runID: "run", batchSize: 10}
+	r.Report(LiveRetryResolved{
+		Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+		PrimaryKeyColumns: []tree.Name{"id"},
+		PrimaryKeyValues:  tree.Datums{tree.NewDInt(1)},
+		InconsistencyType: MismatchingRowType,
+		LiveRetries:       2,
+	})
+	require.Len(t, r.pending, 1)
+	vals, err := r.resultValues(r.pending[0])
+	require.NoError(t, err)
+	strPtr := func(s string) *string { return &s }
+	require.Equal(t, []any{
+		"run", r.pending[0].reportedAt, "public", "tbl", "mismatching_row",
+		strPtr(`{"id":"1"}`), (*string)(nil), strPtr("resolved"),
+	}, vals)
+
+	// Resolved rows are not inconsistencies for other reporters.
+	_, ok := makeResult(LiveRetryResolved{})
+	require.False(t, ok)
+}
@@ -0,0 +1,331 @@
+package inconsistency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/rs/zerolog"
+	"github.com/thediveo/enumflag/v2"
+	"golang.org/x/time/rate"
+)
+
+// WebhookFormat is the payload format posted by the WebhookReporter.
+type WebhookFormat enumflag.Flag
+
+const (
+	// WebhookFormatJSON posts a structured JSON digest.
+	WebhookFormatJSON WebhookFormat = iota
+	// WebhookFormatSlack posts a Slack incoming webhook message.
+	WebhookFormatSlack
+	// WebhookFormatTeams posts a Microsoft Teams message card.
+	WebhookFormatTeams
+)
+
+var WebhookFormatStringRepresentations = map[WebhookFormat][]string{
+	WebhookFormatJSON:  {"json"},
+	WebhookFormatSlack: {"slack"},
+	WebhookFormatTeams: {"teams"},
+}
+
+// WebhookSettings configures a WebhookReporter.
+type WebhookSettings struct {
+	URL    string
+	Format WebhookFormat
+	// DigestInterval is how often inconsistencies reported since the last
+	// digest are posted, which must be positive.
+	DigestInterval time.Duration
+	// MaxPerHour limits the number of digests posted an hour. Digests which
+	// would exceed the limit are merged into the next digest. Unlimited if 0.
+	MaxPerHour int
+	// DedupeWindow is how long an inconsistency on the same primary key is
+	// not alerted on again, e.g. when re-verified by continuous verification.
+	// Inconsistencies are always alerted on if 0.
+	DedupeWindow time.Duration
+	// MaxSamplesPerTable is the number of primary keys of each table included
+	// in a digest.
+	MaxSamplesPerTable int
+}
+
+// DefaultWebhookSettings are the default settings of a WebhookReporter.
+var DefaultWebhookSettings = WebhookSettings{
+	Format:             WebhookFormatJSON,
+	DigestInterval:     time.Minute,
+	MaxPerHour:         12,
+	DedupeWindow:       24 * time.Hour,
+	MaxSamplesPerTable: 5,
+}
+
+func (s WebhookSettings) rateLimit() rate.Limit {
+	if s.MaxPerHour == 0 {
+		return rate.Inf
+	}
+	return rate.Every(time.Hour / time.Duration(s.MaxPerHour))
+}
+
+// WebhookReporter posts digests of the inconsistencies reported to a webhook,
+// which is useful for alerting on inconsistencies found by continuous
+// verification.
+type WebhookReporter struct {
+	settings WebhookSettings
+	client   *http.Client
+	limiter  *rate.Limiter
+	logger   zerolog.Logger
+	now      func() time.Time
+
+	mu      sync.Mutex
+	pending map[dbtable.Name]*webhookTable
+	seen    map[string]time.Time
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewWebhookReporter returns a WebhookReporter which posts a digest every
+// DigestInterval until it is closed.
+func NewWebhookReporter(settings WebhookSettings, logger zerolog.Logger) *WebhookReporter {
+	r := newWebhookReporter(settings, logger)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(settings.DigestInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.flush(false)
+			case <-r.stopCh:
+				return
+			}
+		}
+	}()
+	return r
+}
+
+func newWebhookReporter(settings WebhookSettings, logger zerolog.Logger) *WebhookReporter {
+	return &WebhookReporter{
+		settings: settings,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		limiter:  rate.NewLimiter(settings.rateLimit(), 1),
+		logger:   logger,
+		now:      time.Now,
+		pending:  make(map[dbtable.Name]*webhookTable),
+		seen:     make(map[string]time.Time),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+type webhookTable struct {
+	Schema string         `json:"schema"`
+	Table  string         `json:"table"`
+	Counts map[string]int `json:"counts"`
+	// SamplePrimaryKeys are the primary keys of some of the inconsistent
+	// rows.
+	SamplePrimaryKeys []map[string]string `json:"sample_primary_keys,omitempty"`
+}
+
+type webhookDigest struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Total       int             `json:"total"`
+	Tables      []*webhookTable `json:"tables"`
+}
+
+// Report implements Reporter.
+func (r *WebhookReporter) Report(obj ReportableObject) {
+	res, ok := makeResult(obj)
+	if !ok {
+		return
+	}
+	key, err := webhookDedupeKey(res)
+	if err != nil {
+		r.logger.Err(err).Msgf("error de-duplicating inconsistency for webhook")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if r.settings.DedupeWindow > 0 && key != "" {
+		if seenAt, ok := r.seen[key]; ok && now.Sub(seenAt) < r.settings.DedupeWindow {
+			return
+		}
+		r.seen[key] = now
+	}
+	t, ok := r.pending[res.name]
+	if !ok {
+		t = &webhookTable{
+			Schema: string(res.name.Schema),
+			Table:  string(res.name.Table),
+			Counts: make(map[string]int),
+		}
+		r.pending[res.name] = t
+	}
+	t.Counts[res.inconsistencyType]++
+	if res.primaryKey != nil && len(t.SamplePrimaryKeys) < r.settings.MaxSamplesPerTable {
+		t.SamplePrimaryKeys = append(t.SamplePrimaryKeys, res.primaryKey)
+	}
+}
+
+// webhookDedupeKey identifies an inconsistency across verification runs.
+func webhookDedupeKey(res result) (string, error) {
+	pk, err := json.Marshal(res.primaryKey)
+	if err != nil {
+		return "", err
+	}
+	// Inconsistencies without a primary key, e.g. aggregates, are
+	// distinguished by the columns and shard they are on.
+	cols := make([]string, 0, len(res.columnDiffs))
+	if res.primaryKey == nil {
+		for col := range res.columnDiffs {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+	}
+	return strings.Join(
+		[]string{res.inconsistencyType, res.name.SafeString(), string(pk), strings.Join(cols, ","), res.shard},
+		"\x00",
+	), nil
+}
+
+// Close implements Reporter, posting any remaining inconsistencies
+// regardless of the rate limit.
+func (r *WebhookReporter) Close() {
+	close(r.stopCh)
+	r.wg.Wait()
+	r.flush(true)
+}
+
+// flush posts a digest of the pending inconsistencies, unless there are none
+// or the rate limit has been reached.
+func (r *WebhookReporter) flush(force bool) {
+	r.mu.Lock()
+	if len(r.pending) == 0 || (!force && !r.limiter.Allow()) {
+		r.mu.Unlock()
+		return
+	}
+	now := r.now()
+	digest := webhookDigest{GeneratedAt: now.UTC()}
+	for _, t := range r.pending {
+		digest.Tables = append(digest.Tables, t)
+		for _, n := range t.Counts {
+			digest.Total += n
+		}
+	}
+	r.pending = make(map[dbtable.Name]*webhookTable)
+	for key, seenAt := range r.seen {
+		if now.Sub(seenAt) >= r.settings.DedupeWindow {
+			delete(r.seen, key)
+		}
+	}
+	r.mu.Unlock()
+
+	sort.Slice(digest.Tables, func(i, j int) bool {
+		if digest.Tables[i].Schema != digest.Tables[j].Schema {
+			return digest.Tables[i].Schema < digest.Tables[j].Schema
+		}
+		return digest.Tables[i].Table < digest.Tables[j].Table
+	})
+	if err := r.post(context.Background(), digest); err != nil {
+		r.logger.Err(err).Int("num_inconsistencies", digest.Total).Msgf("error posting to webhook")
+	}
+}
+
+func (r *WebhookReporter) post(ctx context.Context, digest webhookDigest) error {
+	body, err := webhookPayload(r.settings.Format, digest)
+	if err != nil {
+		return errors.Wrap(err, "error encoding webhook payload")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.settings.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Newf("webhook responded with %s: %s", resp.Status, respBody)
+	}
+	return nil
+}
+
+func webhookPayload(format WebhookFormat, digest webhookDigest) ([]byte, error) {
+	switch format {
+	case WebhookFormatJSON:
+		return json.Marshal(digest)
+	case WebhookFormatSlack:
+		return json.Marshal(struct {
+			Text string `json:"text"`
+		}{Text: digest.text("•")})
+	case WebhookFormatTeams:
+		return json.Marshal(struct {
+			Type    string `json:"@type"`
+			Context string `json:"@context"`
+			Summary string `json:"summary"`
+			Text    string `json:"text"`
+		}{
+			Type:    "MessageCard",
+			Context: "https://schema.org/extensions",
+			Summary: digest.title(),
+			// Teams renders text as markdown, which needs blank lines
+			// between paragraphs.
+			Text: strings.ReplaceAll(digest.text("-"), "\n", "\n\n"),
+		})
+	}
+	return nil, errors.AssertionFailedf("unknown webhook format %d", format)
+}
+
+func (d webhookDigest) title() string {
+	return fmt.Sprintf("molt verify found %d new inconsistencies", d.Total)
+}
+
+// text returns a human readable digest, with a line for each table.
+func (d webhookDigest) text(bullet string) string {
+	var sb strings.Builder
+	sb.WriteString(d.title())
+	sb.WriteString(":")
+	for _, t := range d.Tables {
+		types := make([]string, 0, len(t.Counts))
+		for typ := range t.Counts {
+			types = append(types, typ)
+		}
+		sort.Strings(types)
+		counts := make([]string, len(types))
+		for i, typ := range types {
+			counts[i] = fmt.Sprintf("%d %s", t.Counts[typ], typ)
+		}
+		fmt.Fprintf(&sb, "\n%s %s.%s: %s", bullet, t.Schema, t.Table, strings.Join(counts, ", "))
+		if len(t.SamplePrimaryKeys) > 0 {
+			samples := make([]string, len(t.SamplePrimaryKeys))
+			for i, pk := range t.SamplePrimaryKeys {
+				samples[i] = webhookPrimaryKey(pk)
+			}
+			fmt.Fprintf(&sb, " (e.g. %s)", strings.Join(samples, "; "))
+		}
+	}
+	return sb.String()
+}
+
+func webhookPrimaryKey(pk map[string]string) string {
+	cols := make([]string, 0, len(pk))
+	for col := range pk {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	parts := make([]string, len(cols))
+	for i, col := range cols {
+		parts[i] = col + "=" + pk[col]
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,208 @@
+package inconsistency
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookServer is a stand-in for a webhook, recording the bodies posted.
+type webhookServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []string
+}
+
+func newWebhookServer(t *testing.T) *webhookServer {
+	s := &webhookServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		b, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.bodies = append(s.bodies, string(b))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) Bodies() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+func webhookTestRow(id int) MismatchingRow {
+	return MismatchingRow{
+		Name:               dbtable.Name{Schema: "public", Table: "tbl"},
+		PrimaryKeyColumns:  []tree.Name{"id"},
+		PrimaryKeyValues:   tree.Datums{tree.NewDInt(tree.DInt(id))},
+		MismatchingColumns: []tree.Name{"txt"},
+		TruthVals:          tree.Datums{tree.NewDString("truth")},
+		TargetVals:         tree.Datums{tree.NewDString("target")},
+	}
+}
+
+func TestWebhookReporter(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		format   WebhookFormat
+		expected string
+	}{
+		{
+			desc:     "json",
+			format:   WebhookFormatJSON,
+			expected: `{"generated_at":"2024-01-02T03:04:05Z","total":3,"tables":[{"schema":"public","table":"other","counts":{"missing_table":1}},{"schema":"public","table":"tbl","counts":{"extraneous_row":1,"mismatching_row":1},"sample_primary_keys":[{"id":"1"}]}]}`,
+		},
+		{
+			desc:     "slack",
+			format:   WebhookFormatSlack,
+			expected: `{"text":"molt verify found 3 new inconsistencies:\n• public.other: 1 missing_table\n• public.tbl: 1 extraneous_row, 1 mismatching_row (e.g. id=1)"}`,
+		},
+		{
+			desc:     "teams",
+			format:   WebhookFormatTeams,
+			expected: `{"@type":"MessageCard","@context":"https://schema.org/extensions","summary":"molt verify found 3 new inconsistencies","text":"molt verify found 3 new inconsistencies:\n\n- public.other: 1 missing_table\n\n- public.tbl: 1 extraneous_row, 1 mismatching_row (e.g. id=1)"}`,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			server := newWebhookServer(t)
+			settings := DefaultWebhookSettings
+			settings.URL = server.URL
+			settings.Format = tc.format
+			settings.MaxSamplesPerTable = 1
+			r := newWebhookReporter(settings, zerolog.Nop())
+			r.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+			r.Report(webhookTestRow(1))
+			r.Report(ExtraneousRow{
+				Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+				PrimaryKeyColumns: []tree.Name{"id"},
+				PrimaryKeyValues:  tree.Datums{tree.NewDInt(2)},
+			})
+			r.Report(MissingTable{DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "other"}}})
+			r.Report(StatusReport{Info: "ignored"})
+			r.Close()
+
+			bodies := server.Bodies()
+			require.Len(t, bodies, 1)
+			require.JSONEq(t, tc.expected, bodies[0])
+		})
+	}
+}
+
+func TestWebhookReporterDedupe(t *testing.T) {
+	server := newWebhookServer(t)
+	settings := DefaultWebhookSettings
+	settings.URL = server.URL
+	settings.MaxPerHour = 0
+	settings.DedupeWindow = time.Hour
+	r := newWebhookReporter(settings, zerolog.Nop())
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	// A repeated primary key is only alerted on once within the window.
+	r.Report(webhookTestRow(1))
+	r.Report(webhookTestRow(1))
+	r.Report(webhookTestRow(2))
+	r.flush(false)
+
+	now = now.Add(30 * time.Minute)
+	r.Report(webhookTestRow(1))
+	r.flush(false)
+
+	now = now.Add(time.Hour)
+	r.Report(webhookTestRow(1))
+	r.Close()
+
+	bodies := server.Bodies()
+	require.Len(t, bodies, 2)
+	var digests [2]webhookDigest
+	for i := range digests {
+		require.NoError(t, json.Unmarshal([]byte(bodies[i]), &digests[i]))
+	}
+	require.Equal(t, 2, digests[0].Total)
+	require.Equal(t, 1, digests[1].Total)
+}
+
+func TestWebhookReporterDedupeShards(t *testing.T) {
+	server := newWebhookServer(t)
+	settings := DefaultWebhookSettings
+	settings.URL = server.URL
+	settings.DedupeWindow = time.Hour
+	r := newWebhookReporter(settings, zerolog.Nop())
+
+	// The same aggregate is alerted on once for each shard it mismatches on.
+	aggregate := func(shardNum int) MismatchingAggregate {
+		return MismatchingAggregate{
+			Name:        dbtable.Name{Schema: "public", Table: "tbl"},
+			ShardNum:    shardNum,
+			TotalShards: 2,
+			Column:      "n",
+			Aggregate:   "sum",
+			TruthVal:    tree.NewDInt(1),
+			TargetVal:   tree.NewDInt(2),
+		}
+	}
+	r.Report(aggregate(1))
+	r.Report(aggregate(2))
+	r.Report(aggregate(1))
+	r.Close()
+
+	bodies := server.Bodies()
+	require.Len(t, bodies, 1)
+	var digest webhookDigest
+	require.NoError(t, json.Unmarshal([]byte(bodies[0]), &digest))
+	require.Equal(t, 2, digest.Total)
+}
+
+func TestWebhookReporterRateLimit(t *testing.T) {
+	server := newWebhookServer(t)
+	settings := DefaultWebhookSettings
+	settings.URL = server.URL
+	settings.MaxPerHour = 1
+	r := newWebhookReporter(settings, zerolog.Nop())
+
+	r.Report(webhookTestRow(1))
+	r.flush(false)
+	require.Len(t, server.Bodies(), 1)
+
+	// Inconsistencies beyond the rate limit are merged into the next digest.
+	r.Report(webhookTestRow(2))
+	r.flush(false)
+	r.Report(webhookTestRow(3))
+	r.flush(false)
+	require.Len(t, server.Bodies(), 1)
+
+	r.Close()
+	bodies := server.Bodies()
+	require.Len(t, bodies, 2)
+	var digest webhookDigest
+	require.NoError(t, json.Unmarshal([]byte(bodies[1]), &digest))
+	require.Equal(t, 2, digest.Total)
+}
+
+func TestWebhookReporterTicker(t *testing.T) {
+	server := newWebhookServer(t)
+	settings := DefaultWebhookSettings
+	settings.URL = server.URL
+	settings.DigestInterval = 10 * time.Millisecond
+	settings.MaxPerHour = 0
+	r := NewWebhookReporter(settings, zerolog.Nop())
+	defer r.Close()
+
+	r.Report(webhookTestRow(1))
+	require.Eventually(t, func() bool {
+		return len(server.Bodies()) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,57 @@
+package verify
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var tableLastSuccessMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "molt",
+	Subsystem: "verify",
+	Name:      "table_last_success_timestamp_seconds",
+	Help:      "Unix timestamp by which every shard of each table had last finished verifying successfully.",
+}, []string{"table"})
+
+// lastSuccessTracker tracks when each table was last verified successfully.
+// A table is only successfully verified once every shard has been, so during
+// continuous verification its last success is when the shard which has gone
+// longest without finishing successfully last did so.
+type lastSuccessTracker struct {
+	mu     sync.Mutex
+	shards map[dbtable.Name]map[int]time.Time
+}
+
+func newLastSuccessTracker() *lastSuccessTracker {
+	return &lastSuccessTracker{shards: make(map[dbtable.Name]map[int]time.Time)}
+}
+
+// shardSucceeded records a shard finished verifying successfully at the
+// given time, returning the time of the last success of its table if every
+// shard has succeeded.
+func (t *lastSuccessTracker) shardSucceeded(
+	name dbtable.Name, shardNum int, totalShards int, at time.Time,
+) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	shards, ok := t.shards[name]
+	if !ok {
+		shards = make(map[int]time.Time, totalShards)
+		t.shards[name] = shards
+	}
+	shards[shardNum] = at
+	if len(shards) < totalShards {
+		return time.Time{}, false
+	}
+	var ret time.Time
+	for _, shardAt := range shards {
+		if ret.IsZero() || shardAt.Before(ret) {
+			ret = shardAt
+		}
+	}
+	tableLastSuccessMetric.WithLabelValues(name.SafeString()).Set(float64(ret.Unix()))
+	return ret, true
+}
@@ -0,0 +1,35 @@
+package verify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastSuccessTracker(t *testing.T) {
+	name := dbtable.Name{Schema: "public", Table: "last_success"}
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tracker := newLastSuccessTracker()
+
+	// The table is not successful until every shard is.
+	_, ok := tracker.shardSucceeded(name, 1, 2, start)
+	require.False(t, ok)
+	_, ok = tracker.shardSucceeded(name, 1, 2, start.Add(time.Minute))
+	require.False(t, ok)
+
+	at, ok := tracker.shardSucceeded(name, 2, 2, start.Add(2*time.Minute))
+	require.True(t, ok)
+	require.Equal(t, start.Add(time.Minute), at)
+	require.Equal(
+		t,
+		float64(start.Add(time.Minute).Unix()),
+		testutil.ToFloat64(tableLastSuccessMetric.WithLabelValues(name.SafeString())),
+	)
+
+	at, ok = tracker.shardSucceeded(name, 1, 2, start.Add(3*time.Minute))
+	require.True(t, ok)
+	require.Equal(t, start.Add(2*time.Minute), at)
+}
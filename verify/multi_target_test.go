@@ -0,0 +1,93 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/lib/pq/oid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingReporter records the objects reported.
+type recordingReporter struct {
+	reported []inconsistency.ReportableObject
+}
+
+func (r *recordingReporter) Report(obj inconsistency.ReportableObject) {
+	r.reported = append(r.reported, obj)
+}
+
+func (r *recordingReporter) Close() {}
+
+func TestAdditionalTargetTables(t *testing.T) {
+	makeResult := func(table string, columns []tree.Name, targetOIDs []oid.Oid) tableverify.Result {
+		return tableverify.Result{
+			RowVerifiable: true,
+			RowKey:        tableverify.RowKeyPrimaryKey,
+			VerifiedTable: dbtable.VerifiedTable{
+				Name:              dbtable.Name{Schema: "public", Table: tree.Name(table)},
+				PrimaryKeyColumns: []tree.Name{"id"},
+				Columns:           columns,
+				ColumnOIDs:        [2][]oid.Oid{{oid.T_int8, oid.T_text}, targetOIDs},
+			},
+		}
+	}
+	cols := []tree.Name{"id", "txt"}
+	tbls := []tableverify.Result{
+		makeResult("same", cols, []oid.Oid{oid.T_int8, oid.T_text}),
+		makeResult("missing", cols, []oid.Oid{oid.T_int8, oid.T_text}),
+		makeResult("different_columns", cols, []oid.Oid{oid.T_int8, oid.T_text}),
+	}
+	target2, target3 := dbconn.MakeFakeConn("target_2"), dbconn.MakeFakeConn("target_3")
+	targetTbls := [][]tableverify.Result{
+		{
+			makeResult("same", cols, []oid.Oid{oid.T_int4, oid.T_varchar}),
+			makeResult("different_columns", []tree.Name{"id"}, []oid.Oid{oid.T_int8}),
+		},
+		{
+			makeResult("same", cols, []oid.Oid{oid.T_int8, oid.T_text}),
+			makeResult("missing", cols, []oid.Oid{oid.T_int8, oid.T_varchar}),
+			makeResult("different_columns", cols, []oid.Oid{oid.T_int8, oid.T_text}),
+		},
+	}
+
+	reporter := &recordingReporter{}
+	ret := additionalTargetTables(tbls, []dbconn.Conn{target2, target3}, targetTbls, reporter, zerolog.Nop())
+	// Tables are verified on every target they match on, rather than being
+	// skipped on all targets if they differ on one.
+	require.Equal(
+		t,
+		map[dbtable.Name][]rowverify.Target{
+			{Schema: "public", Table: "same"}: {
+				{Conn: target2, ColumnOIDs: []oid.Oid{oid.T_int4, oid.T_varchar}},
+				{Conn: target3, ColumnOIDs: []oid.Oid{oid.T_int8, oid.T_text}},
+			},
+			{Schema: "public", Table: "missing"}: {
+				{Conn: target3, ColumnOIDs: []oid.Oid{oid.T_int8, oid.T_varchar}},
+			},
+			{Schema: "public", Table: "different_columns"}: {
+				{Conn: target3, ColumnOIDs: []oid.Oid{oid.T_int8, oid.T_text}},
+			},
+		},
+		ret,
+	)
+	// The missing table is already reported when verifying the tables of
+	// target_2, so only the different columns are reported.
+	require.Equal(
+		t,
+		[]inconsistency.ReportableObject{
+			inconsistency.MismatchingTableDefinition{
+				DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "different_columns"}},
+				Info:    "table cannot be row verified the same way as on the first target, so its rows are not verified on this target",
+				Target:  "target_2",
+			},
+		},
+		reporter.reported,
+	)
+}
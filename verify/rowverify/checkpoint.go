@@ -0,0 +1,72 @@
+package rowverify
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// ShardProgress is the progress of row verification on a shard.
+type ShardProgress struct {
+	// LastPKVals is the last primary key of the source of truth which has
+	// been verified. All rows up to and including it on both sides have been
+	// compared.
+	LastPKVals tree.Datums
+	Stats      RowStats
+	Completed  bool
+}
+
+// ShardCheckpointer persists the progress of row verification on a shard.
+type ShardCheckpointer interface {
+	Checkpoint(table TableShard, progress ShardProgress) error
+}
+
+// CheckpointSettings configures checkpointing row verification on a shard,
+// so it can be resumed if interrupted.
+type CheckpointSettings struct {
+	Checkpointer ShardCheckpointer
+	// Interval is the minimum amount of time between checkpoints.
+	Interval time.Duration
+	// Resume, if set, is the progress of an earlier run to continue from.
+	Resume *ShardProgress
+}
+
+// progressTracker checkpoints the progress of a shard as rows are verified.
+type progressTracker struct {
+	settings       CheckpointSettings
+	table          TableShard
+	stats          *RowStats
+	lastPKVals     tree.Datums
+	lastCheckpoint time.Time
+}
+
+func (t *progressTracker) afterTruthRow(pkVals tree.Datums) error {
+	if t == nil {
+		return nil
+	}
+	t.lastPKVals = pkVals
+	if time.Since(t.lastCheckpoint) < t.settings.Interval {
+		return nil
+	}
+	return t.checkpoint(false)
+}
+
+func (t *progressTracker) complete() error {
+	if t == nil {
+		return nil
+	}
+	return t.checkpoint(true)
+}
+
+func (t *progressTracker) checkpoint(completed bool) error {
+	t.lastCheckpoint = time.Now()
+	if err := t.settings.Checkpointer.Checkpoint(t.table, ShardProgress{
+		LastPKVals: t.lastPKVals,
+		Stats:      *t.stats,
+		Completed:  completed,
+	}); err != nil {
+		return errors.Wrapf(err, "error checkpointing %s.%s (shard %d/%d)", t.table.Schema, t.table.Table, t.table.ShardNum, t.table.TotalShards)
+	}
+	return nil
+}
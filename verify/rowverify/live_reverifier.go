@@ -2,6 +2,7 @@ package rowverify
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -10,7 +11,9 @@ import (
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/retry"
 	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/verify/comparator"
 	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/lib/pq/oid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/rs/zerolog"
@@ -33,16 +36,21 @@ func (s LiveReverificationSettings) rateLimit() rate.Limit {
 
 type liveRetryItem struct {
 	PrimaryKeys []tree.Datums
-	Retry       *retry.Retry
+	// InconsistencyTypes is the type each of PrimaryKeys was first found to
+	// be inconsistent with.
+	InconsistencyTypes []string
+	Retry              *retry.Retry
 }
 
 type liveReverifier struct {
+	connLocks    *[2]sync.Mutex
 	insertQueue  chan *liveRetryItem
 	scanComplete chan struct{}
 	done         chan struct{}
 	logger       zerolog.Logger
 	table        TableShard
 	limiter      *rate.Limiter
+	comparator   comparator.Comparator
 	testingKnobs struct {
 		beforeScan func(*retry.Retry, []tree.Datums)
 		blockScan  atomic.Bool
@@ -70,46 +78,40 @@ var (
 	})
 )
 
+// newLiveReverifier returns a live reverifier which looks up rows on the
+// connections of the shard's scan, holding connLocks while using them.
 func newLiveReverifier(
 	ctx context.Context,
 	logger zerolog.Logger,
-	baseConns dbconn.OrderedConns,
+	conns dbconn.OrderedConns,
+	connLocks *[2]sync.Mutex,
 	table TableShard,
 	baseListener RowEventListener,
 	rateLimiter *rate.Limiter,
+	valueComparator comparator.Comparator,
 ) (*liveReverifier, error) {
 	r := &liveReverifier{
+		connLocks:    connLocks,
 		insertQueue:  make(chan *liveRetryItem),
 		logger:       logger,
 		table:        table,
 		done:         make(chan struct{}),
 		scanComplete: make(chan struct{}),
 		limiter:      rateLimiter,
+		comparator:   valueComparator,
 	}
 
-	var conns dbconn.OrderedConns
-	for i, conn := range baseConns {
-		var err error
-		conns[i], err = conn.Clone(ctx)
-		if err != nil {
+	// The connections are shared with the scan rather than cloned, so that
+	// reverifying a shard needs no more connections than scanning it.
+	for i, conn := range conns {
+		if err := r.initTypes(ctx, i, conn, table.ColumnOIDs[i]); err != nil {
 			return nil, err
 		}
-		// Initialize the type map on the connection.
-		for _, typOID := range table.ColumnOIDs[i] {
-			if _, err := dbconn.GetDataType(ctx, conn, typOID); err != nil {
-				return nil, errors.Wrapf(err, "error initializing type oid %d", typOID)
-			}
-		}
 	}
 
 	go func() {
 		defer func() {
 			logger.Debug().Msgf("live reverifier exiting")
-			for _, conn := range conns {
-				if err := conn.Close(ctx); err != nil {
-					logger.Err(err).Msgf("error closing live reverifier connection")
-				}
-			}
 			close(r.done)
 		}()
 		var queue liveRetryQueue
@@ -173,13 +175,32 @@ func newLiveReverifier(
 							PrimaryKeyColumns: table.PrimaryKeyColumns,
 						},
 						it.PrimaryKeys,
+						rowiterator.WithConnLock(&connLocks[i]),
 					)
 				}
-				it.PrimaryKeys = it.PrimaryKeys[:0]
-				if err := verifyRows(ctx, iterators, table, &reverifyEventListener{RetryItem: it, BaseListener: baseListener}); err != nil {
+				retriedPKs, retriedTypes := it.PrimaryKeys, it.InconsistencyTypes
+				it.PrimaryKeys, it.InconsistencyTypes = nil, nil
+				evl := &reverifyEventListener{RetryItem: it, BaseListener: baseListener, types: make(map[string]string, len(retriedPKs))}
+				for i, pk := range retriedPKs {
+					evl.types[pkKey(pk)] = retriedTypes[i]
+				}
+				if err := verifyRows(ctx, iterators[:], table, evl, r.comparator, nil, nil); err != nil {
 					logger.Err(err).Msgf("error during live verification")
 					continue
 				}
+				for _, pk := range retriedPKs {
+					typ, ok := evl.types[pkKey(pk)]
+					if !ok {
+						continue
+					}
+					baseListener.OnLiveRetryResolved(inconsistency.LiveRetryResolved{
+						Name:              table.Name,
+						PrimaryKeyColumns: table.PrimaryKeyColumns,
+						PrimaryKeyValues:  pk,
+						InconsistencyType: typ,
+						LiveRetries:       it.Retry.Iteration,
+					})
+				}
 				if len(it.PrimaryKeys) > 0 {
 					it.Retry.Next()
 					queue.heapPush(it)
@@ -196,6 +217,20 @@ func newLiveReverifier(
 	return r, nil
 }
 
+// initTypes initializes the type map of a connection with the given types.
+func (r *liveReverifier) initTypes(
+	ctx context.Context, i int, conn dbconn.Conn, typOIDs []oid.Oid,
+) error {
+	r.connLocks[i].Lock()
+	defer r.connLocks[i].Unlock()
+	for _, typOID := range typOIDs {
+		if _, err := dbconn.GetDataType(ctx, conn, typOID); err != nil {
+			return errors.Wrapf(err, "error initializing type oid %d", typOID)
+		}
+	}
+	return nil
+}
+
 func (r *liveReverifier) Push(it *liveRetryItem) {
 	r.insertQueue <- it
 }
@@ -208,37 +243,63 @@ func (r *liveReverifier) WaitForDone() {
 	<-r.done
 }
 
+// reverifyEventListener requeues rows which are still inconsistent, until
+// they have been retried the maximum number of times.
 type reverifyEventListener struct {
 	RetryItem    *liveRetryItem
 	BaseListener RowEventListener
+	// types maps the key of each retried row to the type it was first found
+	// to be inconsistent with. Rows which are still inconsistent are
+	// removed, leaving the rows which have been resolved.
+	types map[string]string
 }
 
-func (r *reverifyEventListener) OnExtraneousRow(row inconsistency.ExtraneousRow) {
+// stillInconsistent marks a row as still inconsistent, returning whether it
+// should be reported instead of retried again.
+func (r *reverifyEventListener) stillInconsistent(pk tree.Datums) bool {
+	key := pkKey(pk)
+	typ := r.types[key]
+	delete(r.types, key)
 	if !r.RetryItem.Retry.ShouldContinue() {
+		return true
+	}
+	r.RetryItem.PrimaryKeys = append(r.RetryItem.PrimaryKeys, pk)
+	r.RetryItem.InconsistencyTypes = append(r.RetryItem.InconsistencyTypes, typ)
+	return false
+}
+
+func (r *reverifyEventListener) OnExtraneousRow(row inconsistency.ExtraneousRow) {
+	if r.stillInconsistent(row.PrimaryKeyValues) {
+		row.LiveRetries = r.RetryItem.Retry.Iteration
 		r.BaseListener.OnExtraneousRow(row)
-	} else {
-		r.RetryItem.PrimaryKeys = append(r.RetryItem.PrimaryKeys, row.PrimaryKeyValues)
 	}
 }
 
 func (r *reverifyEventListener) OnMissingRow(row inconsistency.MissingRow) {
-	if !r.RetryItem.Retry.ShouldContinue() {
+	if r.stillInconsistent(row.PrimaryKeyValues) {
+		row.LiveRetries = r.RetryItem.Retry.Iteration
 		r.BaseListener.OnMissingRow(row)
-	} else {
-		r.RetryItem.PrimaryKeys = append(r.RetryItem.PrimaryKeys, row.PrimaryKeyValues)
 	}
 }
 
 func (r *reverifyEventListener) OnMismatchingRow(row inconsistency.MismatchingRow) {
-	if !r.RetryItem.Retry.ShouldContinue() {
+	if r.stillInconsistent(row.PrimaryKeyValues) {
+		row.LiveRetries = r.RetryItem.Retry.Iteration
 		r.BaseListener.OnMismatchingRow(row)
-	} else {
-		r.RetryItem.PrimaryKeys = append(r.RetryItem.PrimaryKeys, row.PrimaryKeyValues)
 	}
 }
 
+func (r *reverifyEventListener) OnLiveRetryResolved(row inconsistency.LiveRetryResolved) {
+	r.BaseListener.OnLiveRetryResolved(row)
+}
+
 func (r *reverifyEventListener) OnMatch() {
 	r.BaseListener.OnMatch()
 }
 
 func (r *reverifyEventListener) OnRowScan() {}
+
+// pkKey returns a key identifying a row by its primary key.
+func pkKey(pk tree.Datums) string {
+	return tree.AsString(&pk)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/retry"
 	"github.com/cockroachdb/molt/testutils"
+	"github.com/cockroachdb/molt/verify/comparator"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/lib/pq/oid"
 	"github.com/rs/zerolog"
@@ -42,6 +44,16 @@ func TestLiveReverifier(t *testing.T) {
 		MaxRetries:     3,
 	}
 
+	resolved := func(id int, inconsistencyType string, liveRetries int) inconsistency.LiveRetryResolved {
+		return inconsistency.LiveRetryResolved{
+			Name:              tbl.Name,
+			PrimaryKeyColumns: tbl.PrimaryKeyColumns,
+			PrimaryKeyValues:  tree.Datums{tree.NewDInt(tree.DInt(id))},
+			InconsistencyType: inconsistencyType,
+			LiveRetries:       liveRetries,
+		}
+	}
+
 	now := time.Now()
 	ctx := context.Background()
 	for testIdx, tc := range []struct {
@@ -54,6 +66,7 @@ func TestLiveReverifier(t *testing.T) {
 		expectedExtraneous  []inconsistency.ExtraneousRow
 		expectedMissing     []inconsistency.MissingRow
 		expectedMismatching []inconsistency.MismatchingRow
+		expectedResolved    []inconsistency.LiveRetryResolved
 	}{
 		{
 			desc: "no items ever pop up on queue",
@@ -62,21 +75,27 @@ func TestLiveReverifier(t *testing.T) {
 			desc: "items get fixed before iteration 1",
 			push: []liveRetryItem{
 				{
-					PrimaryKeys: []tree.Datums{{tree.NewDInt(1)}, {tree.NewDInt(2)}},
-					Retry:       retry.MustRetry(defaultRetrySettings),
+					PrimaryKeys:        []tree.Datums{{tree.NewDInt(1)}, {tree.NewDInt(2)}},
+					InconsistencyTypes: []string{inconsistency.MissingRowType, inconsistency.MissingRowType},
+					Retry:              retry.MustRetry(defaultRetrySettings),
 				},
 			},
 
 			expectedBeforeScans: [][]tree.Datums{
 				{{tree.NewDInt(1)}, {tree.NewDInt(2)}},
 			},
+			expectedResolved: []inconsistency.LiveRetryResolved{
+				resolved(1, inconsistency.MissingRowType, 1),
+				resolved(2, inconsistency.MissingRowType, 1),
+			},
 		},
 		{
 			desc: "items get fixed before iteration 2",
 			push: []liveRetryItem{
 				{
-					PrimaryKeys: []tree.Datums{{tree.NewDInt(1)}, {tree.NewDInt(2)}},
-					Retry:       retry.MustRetry(defaultRetrySettings),
+					PrimaryKeys:        []tree.Datums{{tree.NewDInt(1)}, {tree.NewDInt(2)}},
+					InconsistencyTypes: []string{inconsistency.MissingRowType, inconsistency.MissingRowType},
+					Retry:              retry.MustRetry(defaultRetrySettings),
 				},
 			},
 			beforeIteration: map[int]func(t *testing.T, conns dbconn.OrderedConns, pks []tree.Datums){
@@ -93,13 +112,18 @@ func TestLiveReverifier(t *testing.T) {
 				{{tree.NewDInt(1)}, {tree.NewDInt(2)}},
 				{{tree.NewDInt(2)}},
 			},
+			expectedResolved: []inconsistency.LiveRetryResolved{
+				resolved(1, inconsistency.MissingRowType, 1),
+				resolved(2, inconsistency.MissingRowType, 2),
+			},
 		},
 		{
 			desc: "items never get restored",
 			push: []liveRetryItem{
 				{
-					PrimaryKeys: []tree.Datums{{tree.NewDInt(1)}, {tree.NewDInt(2)}},
-					Retry:       retry.MustRetry(defaultRetrySettings),
+					PrimaryKeys:        []tree.Datums{{tree.NewDInt(1)}, {tree.NewDInt(2)}},
+					InconsistencyTypes: []string{inconsistency.MissingRowType, inconsistency.MissingRowType},
+					Retry:              retry.MustRetry(defaultRetrySettings),
 				},
 			},
 			beforeIteration: map[int]func(t *testing.T, conns dbconn.OrderedConns, pks []tree.Datums){
@@ -123,19 +147,25 @@ func TestLiveReverifier(t *testing.T) {
 					PrimaryKeyValues:  tree.Datums{tree.NewDInt(2)},
 					Columns:           []tree.Name{"id", "text"},
 					Values:            tree.Datums{tree.NewDInt(2), tree.NewDString("a")},
+					LiveRetries:       3,
 				},
 			},
+			expectedResolved: []inconsistency.LiveRetryResolved{
+				resolved(1, inconsistency.MissingRowType, 1),
+			},
 		},
 		{
 			desc: "two items in queue, 1 gets fixed on iteration 1 and one on iteration 2",
 			push: []liveRetryItem{
 				{
-					PrimaryKeys: []tree.Datums{{tree.NewDInt(1)}, {tree.NewDInt(2)}},
-					Retry:       retry.MustRetryWithTime(now, defaultRetrySettings),
+					PrimaryKeys:        []tree.Datums{{tree.NewDInt(1)}, {tree.NewDInt(2)}},
+					InconsistencyTypes: []string{inconsistency.MissingRowType, inconsistency.MissingRowType},
+					Retry:              retry.MustRetryWithTime(now, defaultRetrySettings),
 				},
 				{
-					PrimaryKeys: []tree.Datums{{tree.NewDInt(3)}},
-					Retry:       retry.MustRetryWithTime(now.Add(time.Microsecond), defaultRetrySettings),
+					PrimaryKeys:        []tree.Datums{{tree.NewDInt(3)}},
+					InconsistencyTypes: []string{inconsistency.MismatchingRowType},
+					Retry:              retry.MustRetryWithTime(now.Add(time.Microsecond), defaultRetrySettings),
 				},
 			},
 			beforeIteration: map[int]func(t *testing.T, conns dbconn.OrderedConns, pks []tree.Datums){
@@ -161,6 +191,11 @@ func TestLiveReverifier(t *testing.T) {
 				{{tree.NewDInt(3)}},
 				{{tree.NewDInt(2)}},
 			},
+			expectedResolved: []inconsistency.LiveRetryResolved{
+				resolved(1, inconsistency.MissingRowType, 1),
+				resolved(3, inconsistency.MismatchingRowType, 1),
+				resolved(2, inconsistency.MissingRowType, 2),
+			},
 		},
 	} {
 		testIdx := testIdx
@@ -189,9 +224,11 @@ func TestLiveReverifier(t *testing.T) {
 				ctx,
 				zerolog.New(os.Stderr),
 				conns,
+				&[2]sync.Mutex{},
 				TableShard{VerifiedTable: tbl},
 				evl,
 				rate.NewLimiter(rate.Inf, 1),
+				comparator.Exact,
 			)
 			require.NoError(t, err)
 
@@ -218,6 +255,7 @@ func TestLiveReverifier(t *testing.T) {
 			require.Equal(t, tc.expectedExtraneous, evl.extraneous)
 			require.Equal(t, tc.expectedMissing, evl.missing)
 			require.Equal(t, tc.expectedMismatching, evl.mismatching)
+			require.Equal(t, tc.expectedResolved, evl.resolved)
 		})
 	}
 }
@@ -226,6 +264,7 @@ type mockRowEventListener struct {
 	extraneous  []inconsistency.ExtraneousRow
 	missing     []inconsistency.MissingRow
 	mismatching []inconsistency.MismatchingRow
+	resolved    []inconsistency.LiveRetryResolved
 }
 
 func (m *mockRowEventListener) OnExtraneousRow(row inconsistency.ExtraneousRow) {
@@ -240,6 +279,10 @@ func (m *mockRowEventListener) OnMismatchingRow(row inconsistency.MismatchingRow
 	m.mismatching = append(m.mismatching, row)
 }
 
+func (m *mockRowEventListener) OnLiveRetryResolved(row inconsistency.LiveRetryResolved) {
+	m.resolved = append(m.resolved, row)
+}
+
 func (m *mockRowEventListener) OnMatch() {}
 
 func (m *mockRowEventListener) OnRowScan() {}
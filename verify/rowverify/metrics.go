@@ -0,0 +1,196 @@
+package rowverify
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	tableRowsMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "molt",
+		Subsystem: "verify",
+		Name:      "table_rows",
+		Help:      "Rows of each table that have been verified, by type (verified, success, missing, mismatching or extraneous).",
+	}, []string{"table", "type"})
+	shardProgressMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "molt",
+		Subsystem: "verify",
+		Name:      "shard_progress",
+		Help:      "Estimated fraction of each shard that has been verified, based on the position of the primary key between the bounds of the shard.",
+	}, []string{"table", "shard"})
+	scanRowsPerSecondMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "molt",
+		Subsystem: "verify",
+		Name:      "scan_rows_per_second",
+		Help:      "Rate at which rows of each shard are being scanned from the source or target.",
+	}, []string{"table", "shard", "side"})
+)
+
+// rowMetrics are the metrics of row verification on a table.
+type rowMetrics struct {
+	verified    prometheus.Counter
+	success     prometheus.Counter
+	missing     prometheus.Counter
+	mismatching prometheus.Counter
+	extraneous  prometheus.Counter
+}
+
+func newRowMetrics(name dbtable.Name) rowMetrics {
+	table := name.SafeString()
+	return rowMetrics{
+		verified:    tableRowsMetric.WithLabelValues(table, "verified"),
+		success:     tableRowsMetric.WithLabelValues(table, "success"),
+		missing:     tableRowsMetric.WithLabelValues(table, "missing"),
+		mismatching: tableRowsMetric.WithLabelValues(table, "mismatching"),
+		extraneous:  tableRowsMetric.WithLabelValues(table, "extraneous"),
+	}
+}
+
+// ObserveRowStats adds the statistics of a shard verified without the row
+// event listener, e.g. by hash verification, to the table metrics.
+func ObserveRowStats(name dbtable.Name, stats RowStats) {
+	m := newRowMetrics(name)
+	m.verified.Add(float64(stats.NumVerified))
+	m.success.Add(float64(stats.NumSuccess))
+	m.missing.Add(float64(stats.NumMissing))
+	m.mismatching.Add(float64(stats.NumMismatch))
+	m.extraneous.Add(float64(stats.NumExtraneous))
+}
+
+func shardLabel(table TableShard) string {
+	return fmt.Sprintf("%d/%d", table.ShardNum, table.TotalShards)
+}
+
+// progressGauge estimates the progress of verifying a shard.
+type progressGauge struct {
+	gauge   prometheus.Gauge
+	lo      tree.Datum
+	hi      tree.Datum
+	numRows int
+}
+
+// progressUpdateInterval is the number of rows between progress updates.
+const progressUpdateInterval = 1000
+
+func newProgressGauge(table TableShard) *progressGauge {
+	g := &progressGauge{
+		gauge: shardProgressMetric.WithLabelValues(table.Name.SafeString(), shardLabel(table)),
+	}
+	g.gauge.Set(0)
+	lo, hi := table.StartPKVals, table.EndPKVals
+	if len(lo) == 0 {
+		lo = table.TableMinPKVals
+	}
+	if len(hi) == 0 {
+		hi = table.TableMaxPKVals
+	}
+	if len(lo) > 0 && len(hi) > 0 {
+		g.lo, g.hi = lo[0], hi[0]
+	}
+	return g
+}
+
+// afterTruthRow periodically sets the progress to the position of the given
+// primary key, if it can be estimated.
+func (g *progressGauge) afterTruthRow(pk tree.Datums) {
+	if g == nil || g.lo == nil || len(pk) == 0 {
+		return
+	}
+	g.numRows++
+	if g.numRows%progressUpdateInterval != 0 {
+		return
+	}
+	if f, ok := estimateProgress(g.lo, g.hi, pk[0]); ok {
+		g.gauge.Set(f)
+	}
+}
+
+func (g *progressGauge) complete() {
+	if g == nil {
+		return
+	}
+	g.gauge.Set(1)
+}
+
+// estimateProgress returns the fraction of the way val is between lo and hi,
+// which is only possible for numeric and UUID values.
+func estimateProgress(lo tree.Datum, hi tree.Datum, val tree.Datum) (float64, bool) {
+	toFloat := func(d tree.Datum) (float64, bool) {
+		switch d := d.(type) {
+		case *tree.DInt:
+			return float64(*d), true
+		case *tree.DFloat:
+			return float64(*d), true
+		case *tree.DUuid:
+			return float64(d.UUID.ToUint128().Hi), true
+		}
+		return 0, false
+	}
+	l, okL := toFloat(lo)
+	h, okH := toFloat(hi)
+	v, okV := toFloat(val)
+	if !okL || !okH || !okV || h <= l || math.IsInf(h-l, 0) || math.IsNaN(h-l) {
+		return 0, false
+	}
+	return math.Min(math.Max((v-l)/(h-l), 0), 1), true
+}
+
+// meteredIterator measures the rate rows are scanned from an iterator.
+type meteredIterator struct {
+	rowiterator.Iterator
+	gauge      prometheus.Gauge
+	lastUpdate time.Time
+	numRows    int
+}
+
+// scanRateUpdateInterval is how often the scan rate is measured.
+const scanRateUpdateInterval = time.Second
+
+// NewMeteredIterator wraps an iterator of the connection at the given index,
+// exposing the rate rows are scanned from it as a metric. The source of truth
+// is labelled as the source, and targets by their ID.
+func NewMeteredIterator(it rowiterator.Iterator, table TableShard, idx int) rowiterator.Iterator {
+	side := "source"
+	if idx > 0 {
+		side = string(it.Conn().ID())
+	}
+	return &meteredIterator{
+		Iterator: it,
+		gauge: scanRowsPerSecondMetric.WithLabelValues(
+			table.Name.SafeString(),
+			shardLabel(table),
+			side,
+		),
+		lastUpdate: time.Now(),
+	}
+}
+
+func (it *meteredIterator) HasNext(ctx context.Context) bool {
+	if it.Iterator.HasNext(ctx) {
+		return true
+	}
+	// The scan is complete.
+	it.gauge.Set(0)
+	return false
+}
+
+func (it *meteredIterator) Next(ctx context.Context) tree.Datums {
+	ret := it.Iterator.Next(ctx)
+	if ret != nil {
+		it.numRows++
+	}
+	if elapsed := time.Since(it.lastUpdate); elapsed >= scanRateUpdateInterval {
+		it.gauge.Set(float64(it.numRows) / elapsed.Seconds())
+		it.numRows = 0
+		it.lastUpdate = time.Now()
+	}
+	return ret
+}
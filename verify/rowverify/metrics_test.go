@@ -0,0 +1,73 @@
+package rowverify
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateProgress(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		lo       tree.Datum
+		hi       tree.Datum
+		val      tree.Datum
+		expected float64
+		ok       bool
+	}{
+		{desc: "int", lo: tree.NewDInt(100), hi: tree.NewDInt(200), val: tree.NewDInt(125), expected: 0.25, ok: true},
+		{desc: "int past end", lo: tree.NewDInt(100), hi: tree.NewDInt(200), val: tree.NewDInt(300), expected: 1, ok: true},
+		{desc: "float", lo: tree.NewDFloat(0), hi: tree.NewDFloat(2), val: tree.NewDFloat(1), expected: 0.5, ok: true},
+		{
+			desc:     "uuid",
+			lo:       &tree.DUuid{UUID: uuid.FromStringOrNil("00000000-0000-0000-0000-000000000000")},
+			hi:       &tree.DUuid{UUID: uuid.FromStringOrNil("80000000-0000-0000-0000-000000000000")},
+			val:      &tree.DUuid{UUID: uuid.FromStringOrNil("40000000-0000-0000-0000-000000000000")},
+			expected: 0.5,
+			ok:       true,
+		},
+		{desc: "single value", lo: tree.NewDInt(1), hi: tree.NewDInt(1), val: tree.NewDInt(1)},
+		{desc: "string", lo: tree.NewDString("a"), hi: tree.NewDString("z"), val: tree.NewDString("m")},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			f, ok := estimateProgress(tc.lo, tc.hi, tc.val)
+			require.Equal(t, tc.ok, ok)
+			require.InDelta(t, tc.expected, f, 0.0001)
+		})
+	}
+}
+
+func TestProgressGauge(t *testing.T) {
+	table := TableShard{
+		VerifiedTable: dbtable.VerifiedTable{
+			Name: dbtable.Name{Schema: "public", Table: "progress_gauge"},
+		},
+		EndPKVals:      tree.Datums{tree.NewDInt(2000)},
+		ShardNum:       1,
+		TotalShards:    2,
+		TableMinPKVals: tree.Datums{tree.NewDInt(0)},
+		TableMaxPKVals: tree.Datums{tree.NewDInt(4000)},
+	}
+	g := newProgressGauge(table)
+	for i := 1; i <= progressUpdateInterval; i++ {
+		g.afterTruthRow(tree.Datums{tree.NewDInt(tree.DInt(i))})
+	}
+	require.InDelta(t, 0.5, testutil.ToFloat64(g.gauge), 0.0001)
+	g.complete()
+	require.Equal(t, 1.0, testutil.ToFloat64(g.gauge))
+}
+
+func TestObserveRowStats(t *testing.T) {
+	name := dbtable.Name{Schema: "public", Table: "observe_row_stats"}
+	ObserveRowStats(name, RowStats{NumVerified: 5, NumSuccess: 2, NumMissing: 1, NumMismatch: 1, NumExtraneous: 1})
+	m := newRowMetrics(name)
+	require.Equal(t, 5.0, testutil.ToFloat64(m.verified))
+	require.Equal(t, 2.0, testutil.ToFloat64(m.success))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.missing))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.mismatching))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.extraneous))
+}
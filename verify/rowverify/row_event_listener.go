@@ -15,6 +15,9 @@ type RowEventListener interface {
 	OnExtraneousRow(row inconsistency.ExtraneousRow)
 	OnMissingRow(row inconsistency.MissingRow)
 	OnMismatchingRow(row inconsistency.MismatchingRow)
+	// OnLiveRetryResolved is called when a row found to be inconsistent
+	// during live verification is consistent once retried.
+	OnLiveRetryResolved(row inconsistency.LiveRetryResolved)
 	OnMatch()
 	OnRowScan()
 }
@@ -44,66 +47,84 @@ func init() {
 // defaultRowEventListener is the default invocation of the row event listener.
 type defaultRowEventListener struct {
 	reporter inconsistency.Reporter
-	stats    rowStats
+	stats    RowStats
 	table    TableShard
+	metrics  rowMetrics
 }
 
 func (n *defaultRowEventListener) OnExtraneousRow(row inconsistency.ExtraneousRow) {
 	n.reporter.Report(row)
-	n.stats.numExtraneous++
+	n.stats.NumExtraneous++
 	rowStatusMetric.WithLabelValues("extraneous").Inc()
+	n.metrics.extraneous.Inc()
 }
 
 func (n *defaultRowEventListener) OnMissingRow(row inconsistency.MissingRow) {
-	n.stats.numMissing++
+	n.stats.NumMissing++
 	n.reporter.Report(row)
 	rowStatusMetric.WithLabelValues("missing").Inc()
+	n.metrics.missing.Inc()
 }
 
 func (n *defaultRowEventListener) OnMismatchingRow(row inconsistency.MismatchingRow) {
 	n.reporter.Report(row)
-	n.stats.numMismatch++
+	n.stats.NumMismatch++
 	rowStatusMetric.WithLabelValues("mismatching").Inc()
+	n.metrics.mismatching.Inc()
+}
+
+func (n *defaultRowEventListener) OnLiveRetryResolved(row inconsistency.LiveRetryResolved) {
+	n.reporter.Report(row)
 }
 
 func (n *defaultRowEventListener) OnMatch() {
-	n.stats.numSuccess++
+	n.stats.NumSuccess++
 	rowStatusMetric.WithLabelValues("success").Inc()
+	n.metrics.success.Inc()
 }
 
 func (n *defaultRowEventListener) OnRowScan() {
-	if n.stats.numVerified%10000 == 0 && n.stats.numVerified > 0 {
+	if n.stats.NumVerified%10000 == 0 && n.stats.NumVerified > 0 {
 		n.reporter.Report(inconsistency.StatusReport{
 			Info: fmt.Sprintf("progress on %s.%s (shard %d/%d): %s", n.table.Schema, n.table.Table, n.table.ShardNum, n.table.TotalShards, n.stats.String()),
 		})
 	}
 	rowsReadMetric.Inc()
-	n.stats.numVerified++
+	n.metrics.verified.Inc()
+	n.stats.NumVerified++
 }
 
 // liveRowEventListener is used when `live` mode is enabled.
 type liveRowEventListener struct {
-	base *defaultRowEventListener
-	pks  []tree.Datums
-	r    *liveReverifier
+	base  *defaultRowEventListener
+	pks   []tree.Datums
+	types []string
+	r     *liveReverifier
 
 	settings  LiveReverificationSettings
 	lastFlush time.Time
 }
 
 func (n *liveRowEventListener) OnExtraneousRow(row inconsistency.ExtraneousRow) {
-	n.pks = append(n.pks, row.PrimaryKeyValues)
-	n.base.stats.numLiveRetry++
+	n.retry(row.PrimaryKeyValues, inconsistency.ExtraneousRowType)
 }
 
 func (n *liveRowEventListener) OnMissingRow(row inconsistency.MissingRow) {
-	n.pks = append(n.pks, row.PrimaryKeyValues)
-	n.base.stats.numLiveRetry++
+	n.retry(row.PrimaryKeyValues, inconsistency.MissingRowType)
 }
 
 func (n *liveRowEventListener) OnMismatchingRow(row inconsistency.MismatchingRow) {
-	n.pks = append(n.pks, row.PrimaryKeyValues)
-	n.base.stats.numLiveRetry++
+	n.retry(row.PrimaryKeyValues, inconsistency.MismatchingRowType)
+}
+
+func (n *liveRowEventListener) retry(pk tree.Datums, inconsistencyType string) {
+	n.pks = append(n.pks, pk)
+	n.types = append(n.types, inconsistencyType)
+	n.base.stats.NumLiveRetry++
+}
+
+func (n *liveRowEventListener) OnLiveRetryResolved(row inconsistency.LiveRetryResolved) {
+	n.base.OnLiveRetryResolved(row)
 }
 
 func (n *liveRowEventListener) OnMatch() {
@@ -125,9 +146,11 @@ func (n *liveRowEventListener) Flush() {
 			panic(err)
 		}
 		n.r.Push(&liveRetryItem{
-			PrimaryKeys: n.pks,
-			Retry:       r,
+			PrimaryKeys:        n.pks,
+			InconsistencyTypes: n.types,
+			Retry:              r,
 		})
 		n.pks = nil
+		n.types = nil
 	}
 }
@@ -3,6 +3,7 @@ package rowverify
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
@@ -11,29 +12,38 @@ import (
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/verify/comparator"
 	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/lib/pq/oid"
 	"github.com/rs/zerolog"
 	"golang.org/x/time/rate"
 )
 
-type rowStats struct {
-	numVerified   int
-	numSuccess    int
-	numMissing    int
-	numMismatch   int
-	numExtraneous int
-	numLiveRetry  int
+// RowStats are the statistics of row verification on a shard.
+type RowStats struct {
+	NumVerified   int `json:"num_verified"`
+	NumSuccess    int `json:"num_success"`
+	NumMissing    int `json:"num_missing"`
+	NumMismatch   int `json:"num_mismatch"`
+	NumExtraneous int `json:"num_extraneous"`
+	NumLiveRetry  int `json:"num_live_retry"`
 }
 
-func (s *rowStats) String() string {
+// NumInconsistent returns the number of missing, mismatching and extraneous
+// rows.
+func (s RowStats) NumInconsistent() int {
+	return s.NumMissing + s.NumMismatch + s.NumExtraneous
+}
+
+func (s *RowStats) String() string {
 	return fmt.Sprintf(
 		"truth rows seen: %d, success: %d, missing: %d, mismatch: %d, extraneous: %d, live_retry: %d",
-		s.numVerified,
-		s.numSuccess,
-		s.numMissing,
-		s.numMismatch,
-		s.numExtraneous,
-		s.numLiveRetry,
+		s.NumVerified,
+		s.NumSuccess,
+		s.NumMissing,
+		s.NumMismatch,
+		s.NumExtraneous,
+		s.NumLiveRetry,
 	)
 }
 
@@ -45,20 +55,74 @@ type TableShard struct {
 
 	ShardNum    int
 	TotalShards int
+
+	// AsOf, if set, is the time CockroachDB connections are read at.
+	AsOf *time.Time
+
+	// TableMinPKVals and TableMaxPKVals are the smallest and largest primary
+	// keys of the table, if known, which bound the first and last shards
+	// when estimating progress.
+	TableMinPKVals tree.Datums
+	TableMaxPKVals tree.Datums
+}
+
+// AOST returns the time the shard is read AS OF SYSTEM TIME on the given
+// connection, which is only set for CockroachDB connections.
+func (t TableShard) AOST(conn dbconn.Conn) *time.Time {
+	if !conn.IsCockroach() {
+		return nil
+	}
+	return t.AsOf
+}
+
+// Target is a further target rows are verified against, using the same scan
+// of the source of truth.
+type Target struct {
+	Conn dbconn.Conn
+	// ColumnOIDs are the types of the columns of the table on the target.
+	ColumnOIDs []oid.Oid
 }
 
 func VerifyRowsOnShard(
 	ctx context.Context,
 	conns dbconn.OrderedConns,
+	additionalTargets []Target,
 	table TableShard,
 	rowBatchSize int,
 	reporter inconsistency.Reporter,
 	logger zerolog.Logger,
 	liveReverifySettings *LiveReverificationSettings,
 	rateLimiter *rate.Limiter,
-) error {
-	var iterators [2]rowiterator.Iterator
-	for i, conn := range conns {
+	checkpointSettings *CheckpointSettings,
+	valueComparator comparator.Comparator,
+) (RowStats, error) {
+	if checkpointSettings != nil && liveReverifySettings != nil {
+		return RowStats{}, errors.AssertionFailedf("checkpoints are not supported with live reverification")
+	}
+	if len(additionalTargets) > 0 && liveReverifySettings != nil {
+		return RowStats{}, errors.AssertionFailedf("multiple targets are not supported with live reverification")
+	}
+	var resumeAfterPKVals tree.Datums
+	if checkpointSettings != nil && checkpointSettings.Resume != nil {
+		resumeAfterPKVals = checkpointSettings.Resume.LastPKVals
+	}
+	scanConns := append(
+		[]Target{
+			{Conn: conns[0], ColumnOIDs: table.ColumnOIDs[0]},
+			{Conn: conns[1], ColumnOIDs: table.ColumnOIDs[1]},
+		},
+		additionalTargets...,
+	)
+	// The live reverifier shares the connections of the scan, so each is only
+	// used by one at a time.
+	var connLocks [2]sync.Mutex
+	iterators := make([]rowiterator.Iterator, len(scanConns))
+	for i, scanConn := range scanConns {
+		conn := scanConn.Conn
+		var iteratorOpts []rowiterator.IteratorOpt
+		if liveReverifySettings != nil {
+			iteratorOpts = append(iteratorOpts, rowiterator.WithConnLock(&connLocks[i]))
+		}
 		var err error
 		iterators[i], err = rowiterator.NewScanIterator(
 			ctx,
@@ -67,28 +131,49 @@ func VerifyRowsOnShard(
 				Table: rowiterator.Table{
 					Name:              table.Name,
 					ColumnNames:       table.Columns,
-					ColumnOIDs:        table.ColumnOIDs[i],
+					ColumnOIDs:        scanConn.ColumnOIDs,
 					PrimaryKeyColumns: table.PrimaryKeyColumns,
 				},
-				StartPKVals: table.StartPKVals,
-				EndPKVals:   table.EndPKVals,
+				AOST:              table.AOST(conn),
+				StartPKVals:       table.StartPKVals,
+				EndPKVals:         table.EndPKVals,
+				ResumeAfterPKVals: resumeAfterPKVals,
 			},
 			rowBatchSize,
 			rateLimiter,
+			iteratorOpts...,
 		)
 		if err != nil {
-			return errors.Wrapf(err, "error initializing row iterator on %s", conn.ID())
+			return RowStats{}, errors.Wrapf(err, "error initializing row iterator on %s", conn.ID())
 		}
+		iterators[i] = NewMeteredIterator(iterators[i], table, i)
 	}
 
-	defaultRowEVL := &defaultRowEventListener{reporter: reporter, table: table}
+	defaultRowEVL := &defaultRowEventListener{
+		reporter: reporter,
+		table:    table,
+		metrics:  newRowMetrics(table.Name),
+	}
+	var tracker *progressTracker
+	if checkpointSettings != nil {
+		tracker = &progressTracker{
+			settings:       *checkpointSettings,
+			table:          table,
+			stats:          &defaultRowEVL.stats,
+			lastCheckpoint: time.Now(),
+		}
+		if resume := checkpointSettings.Resume; resume != nil {
+			defaultRowEVL.stats = resume.Stats
+			tracker.lastPKVals = resume.LastPKVals
+		}
+	}
 	var rowEVL RowEventListener = defaultRowEVL
 	var liveReverifier *liveReverifier
 	if liveReverifySettings != nil {
 		var err error
-		liveReverifier, err = newLiveReverifier(ctx, logger, conns, table, rowEVL, rate.NewLimiter(liveReverifySettings.rateLimit(), 1))
+		liveReverifier, err = newLiveReverifier(ctx, logger, conns, &connLocks, table, rowEVL, rate.NewLimiter(liveReverifySettings.rateLimit(), 1), valueComparator)
 		if err != nil {
-			return err
+			return RowStats{}, err
 		}
 		rowEVL = &liveRowEventListener{
 			base:      defaultRowEVL,
@@ -97,9 +182,14 @@ func VerifyRowsOnShard(
 			lastFlush: time.Now(),
 		}
 	}
-	if err := verifyRows(ctx, iterators, table, rowEVL); err != nil {
-		return err
+	progress := newProgressGauge(table)
+	if err := verifyRows(ctx, iterators, table, rowEVL, valueComparator, tracker, progress); err != nil {
+		return RowStats{}, err
+	}
+	if err := tracker.complete(); err != nil {
+		return RowStats{}, err
 	}
+	progress.complete()
 	switch rowEVL := rowEVL.(type) {
 	case *defaultRowEventListener:
 		reporter.Report(inconsistency.StatusReport{
@@ -115,103 +205,164 @@ func VerifyRowsOnShard(
 			Info: fmt.Sprintf("finished LIVE row verification on %s.%s (shard %d/%d): %s", table.Schema, table.Table, table.ShardNum, table.TotalShards, rowEVL.base.stats.String()),
 		})
 	default:
-		return errors.Newf("unknown row event listener: %T", rowEVL)
+		return RowStats{}, errors.Newf("unknown row event listener: %T", rowEVL)
 	}
-	return nil
+	return defaultRowEVL.stats, nil
 }
 
+// verifyRows scans the source of truth, the first iterator, once, comparing
+// each row against every target iterator.
 func verifyRows(
-	ctx context.Context, iterators [2]rowiterator.Iterator, table TableShard, evl RowEventListener,
+	ctx context.Context,
+	iterators []rowiterator.Iterator,
+	table TableShard,
+	evl RowEventListener,
+	valueComparator comparator.Comparator,
+	tracker *progressTracker,
+	progress *progressGauge,
 ) error {
 	truth := iterators[0]
+	targets := iterators[1:]
+	// Inconsistencies are only labelled with their target if there are
+	// several.
+	targetIDs := make([]dbconn.ID, len(targets))
+	if len(targets) > 1 {
+		for i, it := range targets {
+			targetIDs[i] = it.Conn().ID()
+		}
+	}
 	for truth.HasNext(ctx) {
 		evl.OnRowScan()
 
 		truthVals := truth.Next(ctx)
-		it := iterators[1]
-
-	itLoop:
-		for {
-			if !it.HasNext(ctx) {
-				if err := it.Error(); err == nil {
-					evl.OnMissingRow(inconsistency.MissingRow{
-						Name:              table.Name,
-						PrimaryKeyColumns: table.PrimaryKeyColumns,
-						PrimaryKeyValues:  truthVals[:len(table.PrimaryKeyColumns)],
-						Columns:           table.Columns,
-						Values:            truthVals,
-					})
-				}
-				break
+		matched := true
+		for targetIdx, it := range targets {
+			if !verifyRowOnTarget(ctx, it, targetIDs[targetIdx], truthVals, table, evl, valueComparator) {
+				matched = false
 			}
+		}
+		if matched {
+			evl.OnMatch()
+		}
+		if targetsErr(targets) != nil {
+			break
+		}
+		if err := tracker.afterTruthRow(truthVals[:len(table.PrimaryKeyColumns)]); err != nil {
+			return err
+		}
+		progress.afterTruthRow(truthVals[:len(table.PrimaryKeyColumns)])
+	}
 
-			// Check the primary key.
-			targetVals := it.Peek(ctx)
-			var compareVal int
-			for i := range table.PrimaryKeyColumns {
-				if compareVal = truthVals[i].Compare(comparectx.CompareContext, targetVals[i]); compareVal != 0 {
-					break
-				}
-			}
-			switch compareVal {
-			case 1:
-				// Extraneous row. Log and continue.
-				it.Next(ctx)
-				evl.OnExtraneousRow(inconsistency.ExtraneousRow{
-					Name:              table.Name,
-					PrimaryKeyColumns: table.PrimaryKeyColumns,
-					PrimaryKeyValues:  targetVals[:len(table.PrimaryKeyColumns)],
-				})
-			case 0:
-				// Matching primary key. Compare values and break loop.
-				targetVals = it.Next(ctx)
-				mismatches := inconsistency.MismatchingRow{
-					Name:              table.Name,
-					PrimaryKeyColumns: table.PrimaryKeyColumns,
-					PrimaryKeyValues:  targetVals[:len(table.PrimaryKeyColumns)],
-				}
-				for valIdx := len(table.PrimaryKeyColumns); valIdx < len(targetVals); valIdx++ {
-					if targetVals[valIdx].Compare(comparectx.CompareContext, truthVals[valIdx]) != 0 {
-						mismatches.MismatchingColumns = append(mismatches.MismatchingColumns, table.Columns[valIdx])
-						mismatches.TargetVals = append(mismatches.TargetVals, targetVals[valIdx])
-						mismatches.TruthVals = append(mismatches.TruthVals, truthVals[valIdx])
-					}
-				}
-				if len(mismatches.MismatchingColumns) > 0 {
-					evl.OnMismatchingRow(mismatches)
-				} else {
-					evl.OnMatch()
-				}
-				break itLoop
-			case -1:
+	if err := truth.Error(); err != nil {
+		return err
+	}
+	if err := targetsErr(targets); err != nil {
+		return err
+	}
+	// If we still have rows in our target iterators, they're all extraneous.
+	for targetIdx, it := range targets {
+		for it.HasNext(ctx) {
+			targetVals := it.Next(ctx)
+			evl.OnExtraneousRow(inconsistency.ExtraneousRow{
+				Name:              table.Name,
+				PrimaryKeyColumns: table.PrimaryKeyColumns,
+				PrimaryKeyValues:  targetVals[:len(table.PrimaryKeyColumns)],
+				Target:            targetIDs[targetIdx],
+			})
+		}
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func targetsErr(targets []rowiterator.Iterator) error {
+	for _, it := range targets {
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyRowOnTarget compares a row of the source of truth against the
+// target, reporting the extraneous rows of the target before it. It returns
+// whether the row matched.
+func verifyRowOnTarget(
+	ctx context.Context,
+	it rowiterator.Iterator,
+	targetID dbconn.ID,
+	truthVals tree.Datums,
+	table TableShard,
+	evl RowEventListener,
+	valueComparator comparator.Comparator,
+) bool {
+	for {
+		if !it.HasNext(ctx) {
+			if err := it.Error(); err == nil {
 				evl.OnMissingRow(inconsistency.MissingRow{
 					Name:              table.Name,
 					PrimaryKeyColumns: table.PrimaryKeyColumns,
 					PrimaryKeyValues:  truthVals[:len(table.PrimaryKeyColumns)],
 					Columns:           table.Columns,
 					Values:            truthVals,
+					Target:            targetID,
 				})
-				break itLoop
 			}
+			return false
 		}
-	}
 
-	for idx, it := range iterators {
-		if err := it.Error(); err != nil {
-			return err
+		// Check the primary key.
+		targetVals := it.Peek(ctx)
+		var compareVal int
+		for i := range table.PrimaryKeyColumns {
+			if compareVal = truthVals[i].Compare(comparectx.CompareContext, targetVals[i]); compareVal != 0 {
+				break
+			}
 		}
-		// If we still have rows in our iterator, they're all extraneous.
-		if idx > 0 {
-			for it.HasNext(ctx) {
-				targetVals := it.Next(ctx)
-				evl.OnExtraneousRow(inconsistency.ExtraneousRow{
-					Name:              table.Name,
-					PrimaryKeyColumns: table.PrimaryKeyColumns,
-					PrimaryKeyValues:  targetVals[:len(table.PrimaryKeyColumns)],
-				})
+		switch compareVal {
+		case 1:
+			// Extraneous row. Log and continue.
+			it.Next(ctx)
+			evl.OnExtraneousRow(inconsistency.ExtraneousRow{
+				Name:              table.Name,
+				PrimaryKeyColumns: table.PrimaryKeyColumns,
+				PrimaryKeyValues:  targetVals[:len(table.PrimaryKeyColumns)],
+				Target:            targetID,
+			})
+		case 0:
+			// Matching primary key. Compare values.
+			targetVals = it.Next(ctx)
+			mismatches := inconsistency.MismatchingRow{
+				Name:              table.Name,
+				PrimaryKeyColumns: table.PrimaryKeyColumns,
+				PrimaryKeyValues:  targetVals[:len(table.PrimaryKeyColumns)],
+				Target:            targetID,
 			}
+			for valIdx := len(table.PrimaryKeyColumns); valIdx < len(targetVals); valIdx++ {
+				if !valueComparator.Equal(table.Name, table.Columns[valIdx], truthVals[valIdx], targetVals[valIdx]) {
+					mismatches.MismatchingColumns = append(mismatches.MismatchingColumns, table.Columns[valIdx])
+					mismatches.TargetVals = append(mismatches.TargetVals, targetVals[valIdx])
+					mismatches.TruthVals = append(mismatches.TruthVals, truthVals[valIdx])
+				}
+			}
+			if len(mismatches.MismatchingColumns) > 0 {
+				evl.OnMismatchingRow(mismatches)
+				return false
+			}
+			return true
+		case -1:
+			evl.OnMissingRow(inconsistency.MissingRow{
+				Name:              table.Name,
+				PrimaryKeyColumns: table.PrimaryKeyColumns,
+				PrimaryKeyValues:  truthVals[:len(table.PrimaryKeyColumns)],
+				Columns:           table.Columns,
+				Values:            truthVals,
+				Target:            targetID,
+			})
+			return false
 		}
 	}
-
-	return nil
 }
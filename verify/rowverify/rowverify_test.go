@@ -0,0 +1,162 @@
+package rowverify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/verify/comparator"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceIterator iterates over rows held in memory.
+type sliceIterator struct {
+	conn dbconn.Conn
+	rows []tree.Datums
+}
+
+func (it *sliceIterator) Conn() dbconn.Conn                    { return it.conn }
+func (it *sliceIterator) HasNext(ctx context.Context) bool     { return len(it.rows) > 0 }
+func (it *sliceIterator) Error() error                         { return nil }
+func (it *sliceIterator) Peek(ctx context.Context) tree.Datums { return it.rows[0] }
+func (it *sliceIterator) Next(ctx context.Context) tree.Datums {
+	ret := it.rows[0]
+	it.rows = it.rows[1:]
+	return ret
+}
+
+// recordingEventListener records the events of row verification.
+type recordingEventListener struct {
+	defaultRowEventListener
+	reported []inconsistency.ReportableObject
+}
+
+func (l *recordingEventListener) OnExtraneousRow(row inconsistency.ExtraneousRow) {
+	l.reported = append(l.reported, row)
+	l.stats.NumExtraneous++
+}
+
+func (l *recordingEventListener) OnMissingRow(row inconsistency.MissingRow) {
+	l.reported = append(l.reported, row)
+	l.stats.NumMissing++
+}
+
+func (l *recordingEventListener) OnMismatchingRow(row inconsistency.MismatchingRow) {
+	l.reported = append(l.reported, row)
+	l.stats.NumMismatch++
+}
+
+func (l *recordingEventListener) OnMatch() {
+	l.stats.NumSuccess++
+}
+
+func (l *recordingEventListener) OnRowScan() {
+	l.stats.NumVerified++
+}
+
+func testRows(vals ...[2]string) []tree.Datums {
+	ret := make([]tree.Datums, len(vals))
+	for i, v := range vals {
+		id, err := tree.ParseDInt(v[0])
+		if err != nil {
+			panic(err)
+		}
+		ret[i] = tree.Datums{id, tree.NewDString(v[1])}
+	}
+	return ret
+}
+
+func TestVerifyRowsMultipleTargets(t *testing.T) {
+	table := TableShard{
+		VerifiedTable: dbtable.VerifiedTable{
+			Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+			PrimaryKeyColumns: []tree.Name{"id"},
+			Columns:           []tree.Name{"id", "txt"},
+		},
+		ShardNum:    1,
+		TotalShards: 1,
+	}
+	truth := &sliceIterator{
+		conn: dbconn.MakeFakeConn("source"),
+		rows: testRows([2]string{"1", "a"}, [2]string{"2", "b"}, [2]string{"3", "c"}),
+	}
+	target := &sliceIterator{
+		conn: dbconn.MakeFakeConn("target"),
+		rows: testRows([2]string{"1", "a"}, [2]string{"2", "x"}, [2]string{"3", "c"}),
+	}
+	dr := &sliceIterator{
+		conn: dbconn.MakeFakeConn("target_2"),
+		rows: testRows([2]string{"1", "a"}, [2]string{"3", "c"}, [2]string{"4", "d"}),
+	}
+	evl := &recordingEventListener{}
+	require.NoError(t, verifyRows(
+		context.Background(),
+		[]rowiterator.Iterator{truth, target, dr},
+		table,
+		evl,
+		comparator.Exact,
+		nil,
+		nil,
+	))
+
+	require.Equal(t, RowStats{NumVerified: 3, NumSuccess: 2, NumMissing: 1, NumMismatch: 1, NumExtraneous: 1}, evl.stats)
+	require.Equal(
+		t,
+		[]inconsistency.ReportableObject{
+			inconsistency.MismatchingRow{
+				Name:               table.Name,
+				PrimaryKeyColumns:  table.PrimaryKeyColumns,
+				PrimaryKeyValues:   tree.Datums{tree.NewDInt(2)},
+				MismatchingColumns: []tree.Name{"txt"},
+				TruthVals:          tree.Datums{tree.NewDString("b")},
+				TargetVals:         tree.Datums{tree.NewDString("x")},
+				Target:             "target",
+			},
+			inconsistency.MissingRow{
+				Name:              table.Name,
+				PrimaryKeyColumns: table.PrimaryKeyColumns,
+				PrimaryKeyValues:  tree.Datums{tree.NewDInt(2)},
+				Columns:           table.Columns,
+				Values:            tree.Datums{tree.NewDInt(2), tree.NewDString("b")},
+				Target:            "target_2",
+			},
+			inconsistency.ExtraneousRow{
+				Name:              table.Name,
+				PrimaryKeyColumns: table.PrimaryKeyColumns,
+				PrimaryKeyValues:  tree.Datums{tree.NewDInt(4)},
+				Target:            "target_2",
+			},
+		},
+		evl.reported,
+	)
+}
+
+func TestVerifyRowsSingleTargetUnlabelled(t *testing.T) {
+	table := TableShard{
+		VerifiedTable: dbtable.VerifiedTable{
+			Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+			PrimaryKeyColumns: []tree.Name{"id"},
+			Columns:           []tree.Name{"id", "txt"},
+		},
+	}
+	evl := &recordingEventListener{}
+	require.NoError(t, verifyRows(
+		context.Background(),
+		[]rowiterator.Iterator{
+			&sliceIterator{conn: dbconn.MakeFakeConn("source"), rows: testRows([2]string{"1", "a"})},
+			&sliceIterator{conn: dbconn.MakeFakeConn("target"), rows: testRows([2]string{"2", "b"})},
+		},
+		table,
+		evl,
+		comparator.Exact,
+		nil,
+		nil,
+	))
+	require.Len(t, evl.reported, 2)
+	require.Equal(t, dbconn.ID(""), evl.reported[0].(inconsistency.MissingRow).Target)
+	require.Equal(t, dbconn.ID(""), evl.reported[1].(inconsistency.ExtraneousRow).Target)
+}
@@ -0,0 +1,53 @@
+package schemaverify
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+)
+
+// funcAliases maps functions to a canonical function which is equivalent.
+var funcAliases = map[string]string{
+	"now":                   "current_timestamp",
+	"transaction_timestamp": "current_timestamp",
+}
+
+// normalizeExpr returns a canonical form of a SQL expression, such that the
+// same expression as printed by different databases compares equal. Casts,
+// type annotations, parentheses and quoting are removed. Expressions which
+// cannot be parsed only have their case and whitespace normalized.
+func normalizeExpr(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	// MySQL quotes identifiers with backticks.
+	expr, err := parser.ParseExpr(strings.ReplaceAll(s, "`", `"`))
+	if err != nil {
+		return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	}
+	expr, err = tree.SimpleVisit(expr, func(expr tree.Expr) (bool, tree.Expr, error) {
+		switch e := expr.(type) {
+		case *tree.ParenExpr:
+			return true, tree.StripParens(e), nil
+		case *tree.CastExpr:
+			return true, tree.StripParens(e.Expr), nil
+		case *tree.AnnotateTypeExpr:
+			return true, tree.StripParens(e.Expr), nil
+		case *tree.FuncExpr:
+			name := strings.ToLower(e.Func.String())
+			if alias, ok := funcAliases[name]; ok {
+				name = alias
+			}
+			ret := *e
+			ret.Func = tree.WrapFunction(name)
+			return true, &ret, nil
+		}
+		return true, expr, nil
+	})
+	if err != nil {
+		return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	}
+	return tree.AsStringWithFlags(expr, tree.FmtBareStrings|tree.FmtBareIdentifiers)
+}
@@ -0,0 +1,376 @@
+package schemaverify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/cockroachdb/molt/verify/tableverify"
+)
+
+type enum struct {
+	dbtable.DBTable
+	labels []string
+}
+
+type routine struct {
+	dbtable.DBTable
+	// routineType is either "function" or "procedure".
+	routineType string
+}
+
+// matchObjects matches up objects with the same name on the source of truth
+// and the target, assuming both are sorted by name.
+func matchObjects[T any](
+	truth []T, target []T, name func(T) dbtable.DBTable,
+) (missing []T, extraneous []T, common [][2]T) {
+	for len(truth) > 0 || len(target) > 0 {
+		cmp := -1
+		if len(truth) == 0 {
+			cmp = 1
+		} else if len(target) > 0 {
+			cmp = name(truth[0]).Compare(name(target[0]))
+		}
+		switch {
+		case cmp < 0:
+			missing = append(missing, truth[0])
+			truth = truth[1:]
+		case cmp > 0:
+			extraneous = append(extraneous, target[0])
+			target = target[1:]
+		default:
+			common = append(common, [2]T{truth[0], target[0]})
+			truth, target = truth[1:], target[1:]
+		}
+	}
+	return missing, extraneous, common
+}
+
+func sortObjects[T any](objs []T, name func(T) dbtable.DBTable) {
+	sort.Slice(objs, func(i, j int) bool {
+		return name(objs[i]).Less(name(objs[j]))
+	})
+}
+
+// VerifyEnums compares the user-defined enums of each database for which
+// include returns true, including the order of their labels. Enums are only
+// compared between PostgreSQL and CockroachDB, as MySQL enums are part of
+// column types and are compared with the table's columns.
+func VerifyEnums(
+	ctx context.Context, conns dbconn.OrderedConns, include func(dbtable.Name) bool,
+) ([]inconsistency.ReportableObject, error) {
+	var enums [2][]enum
+	for i, conn := range conns {
+		pgConn, ok := conn.(*dbconn.PGConn)
+		if !ok {
+			return nil, nil
+		}
+		all, err := getEnums(ctx, pgConn)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting enums on %s", conn.ID())
+		}
+		for _, e := range all {
+			if include(e.Name) {
+				enums[i] = append(enums[i], e)
+			}
+		}
+	}
+	return compareEnums(enums), nil
+}
+
+func compareEnums(enums [2][]enum) []inconsistency.ReportableObject {
+	var ret []inconsistency.ReportableObject
+	missing, extraneous, common := matchObjects(
+		enums[0], enums[1], func(e enum) dbtable.DBTable { return e.DBTable },
+	)
+	for _, e := range missing {
+		ret = append(ret, inconsistency.MissingObject{Name: e.Name, ObjectType: "enum"})
+	}
+	for _, e := range extraneous {
+		ret = append(ret, inconsistency.ExtraneousObject{Name: e.Name, ObjectType: "enum"})
+	}
+	for _, c := range common {
+		if strings.Join(c[0].labels, "\x00") != strings.Join(c[1].labels, "\x00") {
+			ret = append(ret, inconsistency.MismatchingEnumLabels{
+				Name:         c[0].Name,
+				TruthLabels:  c[0].labels,
+				TargetLabels: c[1].labels,
+			})
+		}
+	}
+	return ret
+}
+
+func getEnums(ctx context.Context, conn *dbconn.PGConn) ([]enum, error) {
+	var ret []enum
+	rows, err := conn.Query(
+		ctx,
+		`SELECT n.nspname, t.typname,
+  ARRAY(SELECT e.enumlabel FROM pg_enum e WHERE e.enumtypid = t.oid ORDER BY e.enumsortorder)
+FROM pg_type t
+JOIN pg_namespace n ON n.oid = t.typnamespace
+WHERE t.typtype = 'e' AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'crdb_internal', 'pg_extension')`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var e enum
+		if err := rows.Scan(&e.Schema, &e.Table, &e.labels); err != nil {
+			return nil, errors.Wrap(err, "error decoding enum")
+		}
+		ret = append(ret, e)
+	}
+	if rows.Err() != nil {
+		return nil, errors.Wrap(rows.Err(), "error collecting enums")
+	}
+	rows.Close()
+	sortObjects(ret, func(e enum) dbtable.DBTable { return e.DBTable })
+	return ret, nil
+}
+
+// VerifyViews compares the views of each database for which include returns
+// true, including the names and types of their columns. View definitions
+// themselves are not compared as they are written differently on each
+// database.
+func VerifyViews(
+	ctx context.Context, conns dbconn.OrderedConns, include func(dbtable.Name) bool,
+) ([]inconsistency.ReportableObject, error) {
+	var views [2][]dbtable.DBTable
+	for i, conn := range conns {
+		all, err := getViews(ctx, conn)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting views on %s", conn.ID())
+		}
+		for _, v := range all {
+			if include(v.Name) {
+				views[i] = append(views[i], v)
+			}
+		}
+	}
+
+	var ret []inconsistency.ReportableObject
+	missing, extraneous, common := matchObjects(
+		views[0], views[1], func(v dbtable.DBTable) dbtable.DBTable { return v },
+	)
+	for _, v := range missing {
+		ret = append(ret, inconsistency.MissingObject{Name: v.Name, ObjectType: "view"})
+	}
+	for _, v := range extraneous {
+		ret = append(ret, inconsistency.ExtraneousObject{Name: v.Name, ObjectType: "view"})
+	}
+	for _, c := range common {
+		var columns [2][]tableverify.Column
+		for i, conn := range conns {
+			var err error
+			columns[i], err = tableverify.GetColumns(ctx, conn, c[i])
+			if err != nil {
+				return nil, errors.Wrapf(err, "error getting columns of view %s on %s", c[i].String(), conn.ID())
+			}
+		}
+		infos, err := compareViewColumns(ctx, conns, columns)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			ret = append(ret, inconsistency.MismatchingViewDefinition{Name: c[0].Name, Info: info})
+		}
+	}
+	return ret, nil
+}
+
+// compareViewColumns returns a description of each difference between the
+// columns of a view, which are expected to be in the same order.
+func compareViewColumns(
+	ctx context.Context, conns dbconn.OrderedConns, columns [2][]tableverify.Column,
+) ([]string, error) {
+	var ret []string
+	truthCols, targetCols := columns[0], columns[1]
+	for i := 0; i < len(truthCols) || i < len(targetCols); i++ {
+		switch {
+		case i >= len(targetCols):
+			ret = append(ret, fmt.Sprintf("missing column %s", truthCols[i].Name))
+			continue
+		case i >= len(truthCols):
+			ret = append(ret, fmt.Sprintf("extraneous column %s found", targetCols[i].Name))
+			continue
+		case truthCols[i].Name != targetCols[i].Name:
+			ret = append(
+				ret,
+				fmt.Sprintf("column %d name mismatch: %s vs %s", i+1, truthCols[i].Name, targetCols[i].Name),
+			)
+			continue
+		}
+		truthTyp, err := dbconn.GetDataType(ctx, conns[0], truthCols[i].OID)
+		if err != nil {
+			return nil, err
+		}
+		targetTyp, err := dbconn.GetDataType(ctx, conns[1], targetCols[i].OID)
+		if err != nil {
+			return nil, err
+		}
+		if !tableverify.ComparableType(truthTyp, targetTyp) {
+			ret = append(
+				ret,
+				fmt.Sprintf("column type mismatch on %s: %s vs %s", truthCols[i].Name, truthTyp.Name, targetTyp.Name),
+			)
+		}
+	}
+	return ret, nil
+}
+
+func getViews(ctx context.Context, conn dbconn.Conn) ([]dbtable.DBTable, error) {
+	var ret []dbtable.DBTable
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		rows, err := conn.Query(
+			ctx,
+			`SELECT c.oid, n.nspname, c.relname
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('v', 'm') AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'crdb_internal', 'pg_extension')`,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var v dbtable.DBTable
+			if err := rows.Scan(&v.OID, &v.Schema, &v.Table); err != nil {
+				return nil, errors.Wrap(err, "error decoding view")
+			}
+			ret = append(ret, v)
+		}
+		if rows.Err() != nil {
+			return nil, errors.Wrap(rows.Err(), "error collecting views")
+		}
+		rows.Close()
+	case *dbconn.MySQLConn:
+		rows, err := conn.QueryContext(
+			ctx,
+			`SELECT table_name FROM information_schema.views WHERE table_schema = database()`,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return nil, errors.Wrap(err, "error decoding view")
+			}
+			// Fake the public schema, as is done for tables.
+			ret = append(ret, dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: tree.Name(name)}})
+		}
+		if rows.Err() != nil {
+			return nil, errors.Wrap(rows.Err(), "error collecting views")
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Newf("view verification is not supported for %s", conn.Dialect())
+	}
+	sortObjects(ret, func(v dbtable.DBTable) dbtable.DBTable { return v })
+	return ret, nil
+}
+
+// VerifyRoutines compares the functions and stored procedures of each
+// database for which include returns true. Only the existence of each
+// routine is compared; routines which exist on both sides are flagged for
+// manual review as their bodies cannot be compared across databases.
+func VerifyRoutines(
+	ctx context.Context, conns dbconn.OrderedConns, include func(dbtable.Name) bool,
+) ([]inconsistency.ReportableObject, error) {
+	var routines [2][]routine
+	for i, conn := range conns {
+		all, err := getRoutines(ctx, conn)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting routines on %s", conn.ID())
+		}
+		for _, r := range all {
+			if include(r.Name) {
+				routines[i] = append(routines[i], r)
+			}
+		}
+	}
+	return compareRoutines(routines), nil
+}
+
+func compareRoutines(routines [2][]routine) []inconsistency.ReportableObject {
+	var ret []inconsistency.ReportableObject
+	missing, extraneous, common := matchObjects(
+		routines[0], routines[1], func(r routine) dbtable.DBTable { return r.DBTable },
+	)
+	for _, r := range missing {
+		ret = append(ret, inconsistency.MissingObject{Name: r.Name, ObjectType: r.routineType})
+	}
+	for _, r := range extraneous {
+		ret = append(ret, inconsistency.ExtraneousObject{Name: r.Name, ObjectType: r.routineType})
+	}
+	for _, c := range common {
+		ret = append(ret, inconsistency.RoutineRequiresReview{Name: c[0].Name, RoutineType: c[0].routineType})
+	}
+	return ret
+}
+
+func getRoutines(ctx context.Context, conn dbconn.Conn) ([]routine, error) {
+	var ret []routine
+	// Overloaded routines share a name, so are only listed once.
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		rows, err := conn.Query(
+			ctx,
+			`SELECT DISTINCT routine_schema, routine_name, lower(routine_type)
+FROM information_schema.routines
+WHERE routine_schema NOT IN ('pg_catalog', 'information_schema', 'crdb_internal', 'pg_extension')`,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var r routine
+			if err := rows.Scan(&r.Schema, &r.Table, &r.routineType); err != nil {
+				return nil, errors.Wrap(err, "error decoding routine")
+			}
+			ret = append(ret, r)
+		}
+		if rows.Err() != nil {
+			return nil, errors.Wrap(rows.Err(), "error collecting routines")
+		}
+		rows.Close()
+	case *dbconn.MySQLConn:
+		rows, err := conn.QueryContext(
+			ctx,
+			`SELECT DISTINCT routine_name, lower(routine_type)
+FROM information_schema.routines
+WHERE routine_schema = database()`,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var name string
+			r := routine{DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public"}}}
+			if err := rows.Scan(&name, &r.routineType); err != nil {
+				return nil, errors.Wrap(err, "error decoding routine")
+			}
+			r.Table = tree.Name(name)
+			ret = append(ret, r)
+		}
+		if rows.Err() != nil {
+			return nil, errors.Wrap(rows.Err(), "error collecting routines")
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Newf("routine verification is not supported for %s", conn.Dialect())
+	}
+	sortObjects(ret, func(r routine) dbtable.DBTable { return r.DBTable })
+	return ret, nil
+}
@@ -0,0 +1,301 @@
+package schemaverify
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+)
+
+type index struct {
+	name      string
+	columns   []string
+	unique    bool
+	predicate string
+}
+
+type checkConstraint struct {
+	name string
+	expr string
+}
+
+type foreignKey struct {
+	name       string
+	columns    []string
+	refTable   string
+	refColumns []string
+	onUpdate   string
+	onDelete   string
+}
+
+type column struct {
+	name          tree.Name
+	defaultExpr   string
+	generatedExpr string
+}
+
+// tableSchema is the schema of a table beyond its columns and types.
+type tableSchema struct {
+	indexes     []index
+	checks      []checkConstraint
+	foreignKeys []foreignKey
+	columns     []column
+}
+
+// pgFKActions maps pg_constraint FOREIGN KEY action codes to their names.
+var pgFKActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+func getTableSchema(
+	ctx context.Context, conn dbconn.Conn, table dbtable.DBTable,
+) (tableSchema, error) {
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		return getPGTableSchema(ctx, conn, table)
+	case *dbconn.MySQLConn:
+		return getMySQLTableSchema(ctx, conn, table)
+	}
+	return tableSchema{}, errors.Newf("schema object verification is not supported for %s", conn.Dialect())
+}
+
+func getPGTableSchema(
+	ctx context.Context, conn *dbconn.PGConn, table dbtable.DBTable,
+) (tableSchema, error) {
+	var ret tableSchema
+
+	rows, err := conn.Query(
+		ctx,
+		`SELECT i.relname, ix.indisunique, COALESCE(pg_get_expr(ix.indpred, ix.indrelid), ''),
+  ARRAY(SELECT pg_get_indexdef(ix.indexrelid, k, true) FROM generate_series(1, ix.indnkeyatts::INT8) AS k ORDER BY k)
+FROM pg_index ix
+JOIN pg_class i ON i.oid = ix.indexrelid
+WHERE ix.indrelid = $1 AND NOT ix.indisprimary
+ORDER BY i.relname`,
+		table.OID,
+	)
+	if err != nil {
+		return ret, errors.Wrap(err, "error querying indexes")
+	}
+	for rows.Next() {
+		var idx index
+		if err := rows.Scan(&idx.name, &idx.unique, &idx.predicate, &idx.columns); err != nil {
+			return ret, errors.Wrap(err, "error decoding index")
+		}
+		ret.indexes = append(ret.indexes, idx)
+	}
+	if rows.Err() != nil {
+		return ret, errors.Wrap(rows.Err(), "error collecting indexes")
+	}
+	rows.Close()
+
+	rows, err = conn.Query(
+		ctx,
+		`SELECT c.conname, c.contype::TEXT, pg_get_constraintdef(c.oid),
+  ARRAY(SELECT a.attname FROM unnest(c.conkey) WITH ORDINALITY AS k(attnum, ord)
+    JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum ORDER BY k.ord),
+  COALESCE(r.relname, ''),
+  ARRAY(SELECT a.attname FROM unnest(c.confkey) WITH ORDINALITY AS k(attnum, ord)
+    JOIN pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = k.attnum ORDER BY k.ord),
+  c.confupdtype::TEXT, c.confdeltype::TEXT
+FROM pg_constraint c
+LEFT JOIN pg_class r ON r.oid = c.confrelid
+WHERE c.conrelid = $1 AND c.contype IN ('c', 'f')
+ORDER BY c.conname`,
+		table.OID,
+	)
+	if err != nil {
+		return ret, errors.Wrap(err, "error querying constraints")
+	}
+	for rows.Next() {
+		var name, conType, def, refTable, onUpdate, onDelete string
+		var cols, refCols []string
+		if err := rows.Scan(&name, &conType, &def, &cols, &refTable, &refCols, &onUpdate, &onDelete); err != nil {
+			return ret, errors.Wrap(err, "error decoding constraint")
+		}
+		switch conType {
+		case "c":
+			def = strings.TrimSpace(strings.TrimSuffix(def, " NOT VALID"))
+			def = strings.TrimPrefix(def, "CHECK ")
+			ret.checks = append(ret.checks, checkConstraint{name: name, expr: def})
+		case "f":
+			ret.foreignKeys = append(ret.foreignKeys, foreignKey{
+				name:       name,
+				columns:    cols,
+				refTable:   refTable,
+				refColumns: refCols,
+				onUpdate:   pgFKActions[onUpdate],
+				onDelete:   pgFKActions[onDelete],
+			})
+		}
+	}
+	if rows.Err() != nil {
+		return ret, errors.Wrap(rows.Err(), "error collecting constraints")
+	}
+	rows.Close()
+
+	rows, err = conn.Query(
+		ctx,
+		`SELECT column_name, COALESCE(column_default, ''), COALESCE(generation_expression, '')
+FROM information_schema.columns
+WHERE table_schema = $1 AND table_name = $2
+ORDER BY ordinal_position`,
+		string(table.Schema),
+		string(table.Table),
+	)
+	if err != nil {
+		return ret, errors.Wrap(err, "error querying column defaults")
+	}
+	for rows.Next() {
+		var col column
+		if err := rows.Scan(&col.name, &col.defaultExpr, &col.generatedExpr); err != nil {
+			return ret, errors.Wrap(err, "error decoding column defaults")
+		}
+		ret.columns = append(ret.columns, col)
+	}
+	if rows.Err() != nil {
+		return ret, errors.Wrap(rows.Err(), "error collecting column defaults")
+	}
+	rows.Close()
+	return ret, nil
+}
+
+func getMySQLTableSchema(
+	ctx context.Context, conn *dbconn.MySQLConn, table dbtable.DBTable,
+) (tableSchema, error) {
+	var ret tableSchema
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`SELECT index_name, non_unique, COALESCE(column_name, expression)
+FROM information_schema.statistics
+WHERE table_schema = database() AND table_name = ? AND index_name <> 'PRIMARY'
+ORDER BY index_name, seq_in_index`,
+		string(table.Table),
+	)
+	if err != nil {
+		return ret, errors.Wrap(err, "error querying indexes")
+	}
+	for rows.Next() {
+		var name, col string
+		var nonUnique bool
+		if err := rows.Scan(&name, &nonUnique, &col); err != nil {
+			return ret, errors.Wrap(err, "error decoding index")
+		}
+		if n := len(ret.indexes); n == 0 || ret.indexes[n-1].name != name {
+			ret.indexes = append(ret.indexes, index{name: name, unique: !nonUnique})
+		}
+		idx := &ret.indexes[len(ret.indexes)-1]
+		idx.columns = append(idx.columns, strings.ToLower(col))
+	}
+	if rows.Err() != nil {
+		return ret, errors.Wrap(rows.Err(), "error collecting indexes")
+	}
+	if err := rows.Close(); err != nil {
+		return ret, err
+	}
+
+	rows, err = conn.QueryContext(
+		ctx,
+		`SELECT tc.constraint_name, cc.check_clause
+FROM information_schema.table_constraints tc
+JOIN information_schema.check_constraints cc
+  ON cc.constraint_schema = tc.constraint_schema AND cc.constraint_name = tc.constraint_name
+WHERE tc.table_schema = database() AND tc.table_name = ? AND tc.constraint_type = 'CHECK'
+ORDER BY tc.constraint_name`,
+		string(table.Table),
+	)
+	if err != nil {
+		return ret, errors.Wrap(err, "error querying check constraints")
+	}
+	for rows.Next() {
+		var c checkConstraint
+		if err := rows.Scan(&c.name, &c.expr); err != nil {
+			return ret, errors.Wrap(err, "error decoding check constraint")
+		}
+		ret.checks = append(ret.checks, c)
+	}
+	if rows.Err() != nil {
+		return ret, errors.Wrap(rows.Err(), "error collecting check constraints")
+	}
+	if err := rows.Close(); err != nil {
+		return ret, err
+	}
+
+	rows, err = conn.QueryContext(
+		ctx,
+		`SELECT k.constraint_name, k.column_name, k.referenced_table_name, k.referenced_column_name,
+  rc.update_rule, rc.delete_rule
+FROM information_schema.key_column_usage k
+JOIN information_schema.referential_constraints rc
+  ON rc.constraint_schema = k.constraint_schema AND rc.constraint_name = k.constraint_name
+WHERE k.table_schema = database() AND k.table_name = ? AND k.referenced_table_name IS NOT NULL
+ORDER BY k.constraint_name, k.ordinal_position`,
+		string(table.Table),
+	)
+	if err != nil {
+		return ret, errors.Wrap(err, "error querying foreign keys")
+	}
+	for rows.Next() {
+		var name, col, refTable, refCol, onUpdate, onDelete string
+		if err := rows.Scan(&name, &col, &refTable, &refCol, &onUpdate, &onDelete); err != nil {
+			return ret, errors.Wrap(err, "error decoding foreign key")
+		}
+		if n := len(ret.foreignKeys); n == 0 || ret.foreignKeys[n-1].name != name {
+			ret.foreignKeys = append(ret.foreignKeys, foreignKey{
+				name:     name,
+				refTable: strings.ToLower(refTable),
+				onUpdate: onUpdate,
+				onDelete: onDelete,
+			})
+		}
+		fk := &ret.foreignKeys[len(ret.foreignKeys)-1]
+		fk.columns = append(fk.columns, strings.ToLower(col))
+		fk.refColumns = append(fk.refColumns, strings.ToLower(refCol))
+	}
+	if rows.Err() != nil {
+		return ret, errors.Wrap(rows.Err(), "error collecting foreign keys")
+	}
+	if err := rows.Close(); err != nil {
+		return ret, err
+	}
+
+	rows, err = conn.QueryContext(
+		ctx,
+		`SELECT column_name, column_default, generation_expression
+FROM information_schema.columns
+WHERE table_schema = database() AND table_name = ?
+ORDER BY ordinal_position`,
+		string(table.Table),
+	)
+	if err != nil {
+		return ret, errors.Wrap(err, "error querying column defaults")
+	}
+	for rows.Next() {
+		var name string
+		var defaultExpr, generatedExpr sql.NullString
+		if err := rows.Scan(&name, &defaultExpr, &generatedExpr); err != nil {
+			return ret, errors.Wrap(err, "error decoding column defaults")
+		}
+		ret.columns = append(ret.columns, column{
+			name:          tree.Name(strings.ToLower(name)),
+			defaultExpr:   defaultExpr.String,
+			generatedExpr: generatedExpr.String,
+		})
+	}
+	if rows.Err() != nil {
+		return ret, errors.Wrap(rows.Err(), "error collecting column defaults")
+	}
+	if err := rows.Close(); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,220 @@
+// Package schemaverify is responsible for verifying schema objects beyond
+// columns and primary keys match between two databases, i.e. secondary
+// indexes, CHECK and FOREIGN KEY constraints, column defaults, computed
+// columns and sequences.
+package schemaverify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+)
+
+// Table is a table whose schema objects are verified.
+type Table struct {
+	// DBTables is the table on the source of truth and the target.
+	DBTables [2]dbtable.DBTable
+	// ExcludedColumns are columns whose defaults are not compared.
+	ExcludedColumns []tree.Name
+}
+
+// VerifyTables compares the secondary indexes, CHECK and FOREIGN KEY
+// constraints, column defaults and computed columns of each table, returning
+// any differences. Expressions are compared after normalizing the way each
+// database prints them, so indexes and constraints are matched by their
+// definition rather than by name.
+func VerifyTables(
+	ctx context.Context, conns dbconn.OrderedConns, tables []Table,
+) ([]inconsistency.ReportableObject, error) {
+	var ret []inconsistency.ReportableObject
+	for _, tbl := range tables {
+		var schemas [2]tableSchema
+		for i, conn := range conns {
+			var err error
+			schemas[i], err = getTableSchema(ctx, conn, tbl.DBTables[i])
+			if err != nil {
+				return nil, errors.Wrapf(err, "error getting schema of %s on %s", tbl.DBTables[i].String(), conn.ID())
+			}
+		}
+		ret = append(ret, compareTableSchemas(tbl, schemas)...)
+	}
+	return ret, nil
+}
+
+func compareTableSchemas(tbl Table, schemas [2]tableSchema) []inconsistency.ReportableObject {
+	var ret []inconsistency.ReportableObject
+	name := tbl.DBTables[0].Name
+
+	var indexes [2][]object
+	var checks [2][]object
+	var fks [2][]object
+	for i, s := range schemas {
+		for _, idx := range s.indexes {
+			indexes[i] = append(indexes[i], indexObject(idx))
+		}
+		for _, c := range s.checks {
+			checks[i] = append(checks[i], object{
+				name:       c.name,
+				identity:   c.name,
+				definition: "CHECK (" + normalizeExpr(c.expr) + ")",
+			})
+		}
+		for _, fk := range s.foreignKeys {
+			fks[i] = append(fks[i], foreignKeyObject(fk))
+		}
+	}
+	for _, d := range diffObjects(indexes[0], indexes[1]) {
+		ret = append(ret, inconsistency.MismatchingIndex{
+			Name:             name,
+			TruthIndex:       d[0].name,
+			TruthDefinition:  d[0].definition,
+			TargetIndex:      d[1].name,
+			TargetDefinition: d[1].definition,
+		})
+	}
+	for _, c := range []struct {
+		constraintType string
+		objects        [2][]object
+	}{
+		{constraintType: "CHECK", objects: checks},
+		{constraintType: "FOREIGN KEY", objects: fks},
+	} {
+		for _, d := range diffObjects(c.objects[0], c.objects[1]) {
+			ret = append(ret, inconsistency.MismatchingConstraint{
+				Name:             name,
+				ConstraintType:   c.constraintType,
+				TruthConstraint:  d[0].name,
+				TruthDefinition:  d[0].definition,
+				TargetConstraint: d[1].name,
+				TargetDefinition: d[1].definition,
+			})
+		}
+	}
+
+	excluded := make(map[tree.Name]struct{}, len(tbl.ExcludedColumns))
+	for _, col := range tbl.ExcludedColumns {
+		excluded[col] = struct{}{}
+	}
+	targetColumns := make(map[tree.Name]column, len(schemas[1].columns))
+	for _, col := range schemas[1].columns {
+		targetColumns[col.name] = col
+	}
+	for _, truthCol := range schemas[0].columns {
+		// Missing columns are already reported when verifying columns.
+		targetCol, ok := targetColumns[truthCol.name]
+		if !ok {
+			continue
+		}
+		if _, ok := excluded[truthCol.name]; ok {
+			continue
+		}
+		if normalizeExpr(truthCol.defaultExpr) != normalizeExpr(targetCol.defaultExpr) {
+			ret = append(ret, inconsistency.MismatchingColumnDefault{
+				Name:          name,
+				Column:        truthCol.name,
+				TruthDefault:  truthCol.defaultExpr,
+				TargetDefault: targetCol.defaultExpr,
+			})
+		}
+		if normalizeExpr(truthCol.generatedExpr) != normalizeExpr(targetCol.generatedExpr) {
+			ret = append(ret, inconsistency.MismatchingComputedColumn{
+				Name:             name,
+				Column:           truthCol.name,
+				TruthExpression:  truthCol.generatedExpr,
+				TargetExpression: targetCol.generatedExpr,
+			})
+		}
+	}
+	return ret
+}
+
+// object is a schema object which is compared by its definition.
+type object struct {
+	name string
+	// identity pairs up objects on each side whose definitions differ, e.g.
+	// indexes on the same columns.
+	identity   string
+	definition string
+}
+
+func indexObject(idx index) object {
+	cols := make([]string, len(idx.columns))
+	for i, col := range idx.columns {
+		cols[i] = normalizeExpr(col)
+	}
+	var sb strings.Builder
+	if idx.unique {
+		sb.WriteString("UNIQUE ")
+	}
+	sb.WriteString("INDEX (")
+	sb.WriteString(strings.Join(cols, ", "))
+	sb.WriteString(")")
+	if idx.predicate != "" {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(normalizeExpr(idx.predicate))
+	}
+	return object{
+		name:       idx.name,
+		identity:   strings.Join(cols, ", "),
+		definition: sb.String(),
+	}
+}
+
+func foreignKeyObject(fk foreignKey) object {
+	identity := fmt.Sprintf(
+		"(%s) REFERENCES %s (%s)",
+		strings.Join(fk.columns, ", "),
+		fk.refTable,
+		strings.Join(fk.refColumns, ", "),
+	)
+	return object{
+		name:       fk.name,
+		identity:   identity,
+		definition: fmt.Sprintf("FOREIGN KEY %s ON UPDATE %s ON DELETE %s", identity, fk.onUpdate, fk.onDelete),
+	}
+}
+
+// diffObjects returns the objects which differ between the source of truth
+// and the target. Objects with the same definition match regardless of their
+// names. Remaining objects with the same identity are paired up, and any
+// others are returned with an empty object on the side they are missing from.
+func diffObjects(truth []object, target []object) [][2]object {
+	truthMatched := make([]bool, len(truth))
+	targetMatched := make([]bool, len(target))
+	for i := range truth {
+		for j := range target {
+			if !targetMatched[j] && truth[i].definition == target[j].definition {
+				truthMatched[i], targetMatched[j] = true, true
+				break
+			}
+		}
+	}
+
+	var ret [][2]object
+	for i := range truth {
+		if truthMatched[i] {
+			continue
+		}
+		pair := [2]object{truth[i]}
+		for j := range target {
+			if !targetMatched[j] && truth[i].identity == target[j].identity {
+				targetMatched[j] = true
+				pair[1] = target[j]
+				break
+			}
+		}
+		ret = append(ret, pair)
+	}
+	for j := range target {
+		if !targetMatched[j] {
+			ret = append(ret, [2]object{{}, target[j]})
+		}
+	}
+	return ret
+}
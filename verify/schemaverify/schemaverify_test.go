@@ -0,0 +1,209 @@
+package schemaverify
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeExpr(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		a        string
+		b        string
+		expected bool
+	}{
+		{desc: "casts", a: "'abc'::text", b: "'abc':::STRING", expected: true},
+		{desc: "mysql string default", a: "'abc'::character varying", b: "abc", expected: true},
+		{desc: "parentheses and backticks", a: "((a > 0))", b: "(`a` > 0)", expected: true},
+		{desc: "timestamp functions", a: "now()", b: "CURRENT_TIMESTAMP", expected: true},
+		{desc: "nested casts", a: "((a)::text || 'x'::text)", b: "a || 'x'", expected: true},
+		{desc: "different values", a: "'abc'::text", b: "'abd'::text", expected: false},
+		{desc: "different operators", a: "a > 0", b: "a >= 0", expected: false},
+		{desc: "unparseable", a: "SOME  Weird(", b: "some weird(", expected: true},
+		{desc: "empty", a: "", b: "  ", expected: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, normalizeExpr(tc.a) == normalizeExpr(tc.b), "%q vs %q", normalizeExpr(tc.a), normalizeExpr(tc.b))
+		})
+	}
+}
+
+func TestCompareTableSchemas(t *testing.T) {
+	name := dbtable.Name{Schema: "public", Table: "tbl"}
+	tbl := Table{
+		DBTables:        [2]dbtable.DBTable{{Name: name}, {Name: name}},
+		ExcludedColumns: []tree.Name{"excluded"},
+	}
+	schemas := [2]tableSchema{
+		{
+			indexes: []index{
+				{name: "tbl_a_idx", columns: []string{"a"}},
+				{name: "tbl_b_key", columns: []string{"b"}, unique: true},
+				{name: "tbl_c_idx", columns: []string{"c"}, predicate: "(c > 0)"},
+				{name: "tbl_d_idx", columns: []string{"d"}},
+			},
+			checks: []checkConstraint{
+				{name: "check_a", expr: "((a > 0))"},
+				{name: "check_b", expr: "((b > 0))"},
+			},
+			foreignKeys: []foreignKey{
+				{name: "fk_a", columns: []string{"a"}, refTable: "other", refColumns: []string{"id"}, onUpdate: "NO ACTION", onDelete: "CASCADE"},
+			},
+			columns: []column{
+				{name: "a", defaultExpr: "0"},
+				{name: "b", defaultExpr: "'x'::text"},
+				{name: "c", generatedExpr: "(a + b)"},
+				{name: "excluded", defaultExpr: "now()"},
+				{name: "missing", defaultExpr: "1"},
+			},
+		},
+		{
+			indexes: []index{
+				{name: "a_idx", columns: []string{"a"}},
+				{name: "b_idx", columns: []string{"b"}},
+				{name: "c_idx", columns: []string{"c"}, predicate: "c > 0"},
+				{name: "e_idx", columns: []string{"e"}},
+			},
+			checks: []checkConstraint{
+				{name: "check_a", expr: "`a` > 0"},
+				{name: "check_b", expr: "`b` > 1"},
+			},
+			foreignKeys: []foreignKey{
+				{name: "other_fk", columns: []string{"a"}, refTable: "other", refColumns: []string{"id"}, onUpdate: "NO ACTION", onDelete: "NO ACTION"},
+			},
+			columns: []column{
+				{name: "a", defaultExpr: "1"},
+				{name: "b", defaultExpr: "x"},
+				{name: "c", generatedExpr: "a * b"},
+				{name: "excluded"},
+			},
+		},
+	}
+	require.Equal(
+		t,
+		[]inconsistency.ReportableObject{
+			inconsistency.MismatchingIndex{
+				Name:             name,
+				TruthIndex:       "tbl_b_key",
+				TruthDefinition:  "UNIQUE INDEX (b)",
+				TargetIndex:      "b_idx",
+				TargetDefinition: "INDEX (b)",
+			},
+			inconsistency.MismatchingIndex{
+				Name:            name,
+				TruthIndex:      "tbl_d_idx",
+				TruthDefinition: "INDEX (d)",
+			},
+			inconsistency.MismatchingIndex{
+				Name:             name,
+				TargetIndex:      "e_idx",
+				TargetDefinition: "INDEX (e)",
+			},
+			inconsistency.MismatchingConstraint{
+				Name:             name,
+				ConstraintType:   "CHECK",
+				TruthConstraint:  "check_b",
+				TruthDefinition:  "CHECK (b > 0)",
+				TargetConstraint: "check_b",
+				TargetDefinition: "CHECK (b > 1)",
+			},
+			inconsistency.MismatchingConstraint{
+				Name:             name,
+				ConstraintType:   "FOREIGN KEY",
+				TruthConstraint:  "fk_a",
+				TruthDefinition:  "FOREIGN KEY (a) REFERENCES other (id) ON UPDATE NO ACTION ON DELETE CASCADE",
+				TargetConstraint: "other_fk",
+				TargetDefinition: "FOREIGN KEY (a) REFERENCES other (id) ON UPDATE NO ACTION ON DELETE NO ACTION",
+			},
+			inconsistency.MismatchingColumnDefault{
+				Name:          name,
+				Column:        "a",
+				TruthDefault:  "0",
+				TargetDefault: "1",
+			},
+			inconsistency.MismatchingComputedColumn{
+				Name:             name,
+				Column:           "c",
+				TruthExpression:  "(a + b)",
+				TargetExpression: "a * b",
+			},
+		},
+		compareTableSchemas(tbl, schemas),
+	)
+}
+
+func TestCompareSequences(t *testing.T) {
+	seq := func(table string, nextValue int64) sequence {
+		return sequence{
+			DBTable:   dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: tree.Name(table)}},
+			nextValue: nextValue,
+		}
+	}
+	require.Equal(
+		t,
+		[]inconsistency.ReportableObject{
+			inconsistency.MissingSequence{Name: dbtable.Name{Schema: "public", Table: "a"}},
+			inconsistency.ExtraneousSequence{Name: dbtable.Name{Schema: "public", Table: "d"}},
+			inconsistency.MismatchingSequenceValue{Name: dbtable.Name{Schema: "public", Table: "b"}, TruthValue: 10, TargetValue: 1},
+		},
+		compareSequences([2][]sequence{
+			{seq("a", 1), seq("b", 10), seq("c", 5)},
+			{seq("b", 1), seq("c", 5), seq("d", 1)},
+		}),
+	)
+}
+
+func TestCompareEnums(t *testing.T) {
+	e := func(table string, labels ...string) enum {
+		return enum{
+			DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: tree.Name(table)}},
+			labels:  labels,
+		}
+	}
+	require.Equal(
+		t,
+		[]inconsistency.ReportableObject{
+			inconsistency.MissingObject{Name: dbtable.Name{Schema: "public", Table: "a"}, ObjectType: "enum"},
+			inconsistency.ExtraneousObject{Name: dbtable.Name{Schema: "public", Table: "e"}, ObjectType: "enum"},
+			inconsistency.MismatchingEnumLabels{
+				Name:         dbtable.Name{Schema: "public", Table: "b"},
+				TruthLabels:  []string{"x", "y"},
+				TargetLabels: []string{"y", "x"},
+			},
+			inconsistency.MismatchingEnumLabels{
+				Name:         dbtable.Name{Schema: "public", Table: "d"},
+				TruthLabels:  []string{"x"},
+				TargetLabels: []string{"x", "y"},
+			},
+		},
+		compareEnums([2][]enum{
+			{e("a", "x"), e("b", "x", "y"), e("c", "x", "y"), e("d", "x")},
+			{e("b", "y", "x"), e("c", "x", "y"), e("d", "x", "y"), e("e", "x")},
+		}),
+	)
+}
+
+func TestCompareRoutines(t *testing.T) {
+	r := func(table string, routineType string) routine {
+		return routine{
+			DBTable:     dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: tree.Name(table)}},
+			routineType: routineType,
+		}
+	}
+	require.Equal(
+		t,
+		[]inconsistency.ReportableObject{
+			inconsistency.MissingObject{Name: dbtable.Name{Schema: "public", Table: "a"}, ObjectType: "procedure"},
+			inconsistency.ExtraneousObject{Name: dbtable.Name{Schema: "public", Table: "c"}, ObjectType: "function"},
+			inconsistency.RoutineRequiresReview{Name: dbtable.Name{Schema: "public", Table: "b"}, RoutineType: "function"},
+		},
+		compareRoutines([2][]routine{
+			{r("a", "procedure"), r("b", "function")},
+			{r("b", "function"), r("c", "function")},
+		}),
+	)
+}
@@ -0,0 +1,116 @@
+package schemaverify
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+)
+
+type sequence struct {
+	dbtable.DBTable
+	// nextValue is the value the sequence returns next.
+	nextValue int64
+}
+
+// VerifySequences compares the sequences of each database for which include
+// returns true and their next values, returning any differences. Sequences
+// are only compared between PostgreSQL and CockroachDB, as MySQL does not
+// have sequences.
+func VerifySequences(
+	ctx context.Context, conns dbconn.OrderedConns, include func(dbtable.Name) bool,
+) ([]inconsistency.ReportableObject, error) {
+	var seqs [2][]sequence
+	for i, conn := range conns {
+		pgConn, ok := conn.(*dbconn.PGConn)
+		if !ok {
+			return nil, nil
+		}
+		all, err := getSequences(ctx, pgConn)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting sequences on %s", conn.ID())
+		}
+		for _, seq := range all {
+			if include(seq.Name) {
+				seqs[i] = append(seqs[i], seq)
+			}
+		}
+	}
+	return compareSequences(seqs), nil
+}
+
+func compareSequences(seqs [2][]sequence) []inconsistency.ReportableObject {
+	var ret []inconsistency.ReportableObject
+	missing, extraneous, common := matchObjects(
+		seqs[0], seqs[1], func(s sequence) dbtable.DBTable { return s.DBTable },
+	)
+	for _, s := range missing {
+		ret = append(ret, inconsistency.MissingSequence{Name: s.Name})
+	}
+	for _, s := range extraneous {
+		ret = append(ret, inconsistency.ExtraneousSequence{Name: s.Name})
+	}
+	for _, c := range common {
+		if c[0].nextValue != c[1].nextValue {
+			ret = append(ret, inconsistency.MismatchingSequenceValue{
+				Name:        c[0].Name,
+				TruthValue:  c[0].nextValue,
+				TargetValue: c[1].nextValue,
+			})
+		}
+	}
+	return ret
+}
+
+func getSequences(ctx context.Context, conn *dbconn.PGConn) ([]sequence, error) {
+	var ret []sequence
+	var increments []int64
+	rows, err := conn.Query(
+		ctx,
+		`SELECT sequence_schema, sequence_name, increment
+FROM information_schema.sequences
+WHERE sequence_schema NOT IN ('pg_catalog', 'information_schema', 'crdb_internal', 'pg_extension')`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var seq sequence
+		var increment string
+		if err := rows.Scan(&seq.Schema, &seq.Table, &increment); err != nil {
+			return nil, errors.Wrap(err, "error decoding sequence")
+		}
+		inc, err := strconv.ParseInt(increment, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing increment of sequence %s", seq.String())
+		}
+		ret = append(ret, seq)
+		increments = append(increments, inc)
+	}
+	if rows.Err() != nil {
+		return nil, errors.Wrap(rows.Err(), "error collecting sequences")
+	}
+	rows.Close()
+
+	for i := range ret {
+		tn := ret[i].MakeTableName()
+		var lastValue int64
+		var isCalled bool
+		if err := conn.QueryRow(
+			ctx,
+			"SELECT last_value, is_called FROM "+tree.AsString(&tn),
+		).Scan(&lastValue, &isCalled); err != nil {
+			return nil, errors.Wrapf(err, "error getting value of sequence %s", ret[i].String())
+		}
+		ret[i].nextValue = lastValue
+		if isCalled {
+			ret[i].nextValue += increments[i]
+		}
+	}
+	sortObjects(ret, func(s sequence) dbtable.DBTable { return s.DBTable })
+	return ret, nil
+}
@@ -2,9 +2,11 @@ package verify
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"go/constant"
 	"math"
+	"strconv"
 	"strings"
 
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
@@ -12,9 +14,12 @@ import (
 	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uint128"
 	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/comparectx"
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/mysqlconv"
+	"github.com/cockroachdb/molt/parsectx"
 	"github.com/cockroachdb/molt/pgconv"
+	"github.com/cockroachdb/molt/rowiterator"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/cockroachdb/molt/verify/rowverify"
 	"github.com/cockroachdb/molt/verify/tableverify"
@@ -33,81 +38,49 @@ func shardTable(
 	if numSplits < 1 {
 		return nil, errors.AssertionFailedf("failed to split rows: %d", numSplits)
 	}
-	if numSplits > 1 {
-		ret := make([]rowverify.TableShard, 0, numSplits)
-		// For now, be dumb and split only the first column.
-		min, err := getTableExtremes(ctx, truthConn, tbl, true)
-		if err != nil {
-			return nil, errors.Wrapf(err, "cannot get minimum of %s.%s", tbl.Schema, tbl.Table)
+	min, max, err := getTableBounds(ctx, truthConn, tbl)
+	if err != nil {
+		if numSplits > 1 {
+			return nil, err
 		}
-		max, err := getTableExtremes(ctx, truthConn, tbl, false)
+		// The bounds are only used to estimate progress if the table is not
+		// split, so verify the table without them.
+		min, max = nil, nil
+	}
+	if numSplits > 1 {
+		splitPoints, err := getSplitPoints(ctx, truthConn, tbl, reporter, numSplits, min, max)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot get maximum of %s.%s", tbl.Schema, tbl.Table)
+			return nil, err
 		}
-		var nextMin tree.Datums
-		if !(len(min) == 0 || len(max) == 0 || len(min) != len(max)) {
-			splittable := true
-		splitLoop:
-			for splitNum := 1; splitNum <= numSplits; splitNum++ {
+		if len(splitPoints) > 0 {
+			ret := make([]rowverify.TableShard, 0, len(splitPoints)+1)
+			var nextMin tree.Datums
+			for i := 0; i <= len(splitPoints); i++ {
 				var nextMax tree.Datums
-				if splitNum < numSplits {
-					// For now, split by only first column of PK.
-					switch min[0].ResolvedType().Family() {
-					case types.IntFamily:
-						minVal := int64(*min[0].(*tree.DInt))
-						maxVal := int64(*max[0].(*tree.DInt))
-						valRange := maxVal - minVal
-						if valRange <= 0 {
-							splittable = false
-							break splitLoop
-						}
-						splitVal := minVal + ((valRange / int64(numSplits)) * int64(splitNum))
-						nextMax = append(nextMax, tree.NewDInt(tree.DInt(splitVal)))
-					case types.FloatFamily:
-						minVal := float64(*min[0].(*tree.DFloat))
-						maxVal := float64(*max[0].(*tree.DFloat))
-						valRange := maxVal - minVal
-						if valRange <= 0 || math.IsNaN(valRange) || math.IsInf(valRange, 0) {
-							splittable = false
-							break splitLoop
-						}
-						splitVal := minVal + ((valRange / float64(numSplits)) * float64(splitNum))
-						nextMax = append(nextMax, tree.NewDFloat(tree.DFloat(splitVal)))
-					case types.UuidFamily:
-						// Use the high ranges to divide.
-						minVal := min[0].(*tree.DUuid).UUID.ToUint128().Hi
-						maxVal := max[0].(*tree.DUuid).UUID.ToUint128().Hi
-						valRange := maxVal - minVal
-						if valRange <= 0 {
-							splittable = false
-							break splitLoop
-						}
-						splitVal := minVal + ((valRange / uint64(numSplits)) * uint64(splitNum))
-						nextMax = append(nextMax, &tree.DUuid{UUID: uuid.FromUint128(uint128.Uint128{Hi: splitVal})})
-					default:
-						splittable = false
-						break splitLoop
-					}
+				if i < len(splitPoints) {
+					nextMax = splitPoints[i]
 				}
 				ret = append(ret, rowverify.TableShard{
-					VerifiedTable: tbl.VerifiedTable,
-					StartPKVals:   nextMin,
-					EndPKVals:     nextMax,
-					ShardNum:      splitNum,
-					TotalShards:   numSplits,
+					VerifiedTable:  tbl.VerifiedTable,
+					StartPKVals:    nextMin,
+					EndPKVals:      nextMax,
+					ShardNum:       i + 1,
+					TotalShards:    len(splitPoints) + 1,
+					TableMinPKVals: min,
+					TableMaxPKVals: max,
 				})
 				nextMin = nextMax
 			}
-			if splittable {
-				return ret, nil
-			}
+			return ret, nil
 		}
 	}
 	ret := []rowverify.TableShard{
 		{
-			VerifiedTable: tbl.VerifiedTable,
-			ShardNum:      1,
-			TotalShards:   1,
+			VerifiedTable:  tbl.VerifiedTable,
+			ShardNum:       1,
+			TotalShards:    1,
+			TableMinPKVals: min,
+			TableMaxPKVals: max,
 		},
 	}
 	if numSplits != 1 {
@@ -122,8 +95,452 @@ func shardTable(
 	return ret, nil
 }
 
+// getSplitPoints returns the primary key values at which to split the table
+// into shards, or nil if no split could be identified. Split points are
+// sourced from, in order of preference:
+//   - the start keys of the ranges of the primary index, for CockroachDB.
+//   - the histogram of the first primary key column from table statistics.
+//   - primary keys sampled at evenly spaced row counts, which works for any
+//     key, at the cost of scanning the primary key.
+//   - arithmetic on single column numeric and UUID keys, which ignores how
+//     rows are distributed over the key.
+//
+// The first source with a distinct split point for every shard is used,
+// otherwise the source with the most distinct split points.
+func getSplitPoints(
+	ctx context.Context,
+	truthConn dbconn.Conn,
+	tbl tableverify.Result,
+	reporter inconsistency.Reporter,
+	numSplits int,
+	min tree.Datums,
+	max tree.Datums,
+) ([]tree.Datums, error) {
+	if len(min) == 0 || len(max) == 0 || len(min) != len(max) {
+		return nil, nil
+	}
+	splitPoints, err := rangeSplitPoints(ctx, truthConn, tbl, numSplits, min)
+	if err != nil {
+		// Listing ranges may need privileges the user does not have, so fall
+		// back to the other sources.
+		reporter.Report(inconsistency.StatusReport{
+			Info: fmt.Sprintf(
+				"unable to list the ranges of %s.%s, using other sources of split points: %v",
+				tbl.Schema,
+				tbl.Table,
+				err,
+			),
+		})
+		splitPoints = nil
+	}
+	if len(splitPoints) == numSplits-1 {
+		return splitPoints, nil
+	}
+	best := splitPoints
+	splitPoints, err = statisticsSplitPoints(ctx, truthConn, tbl, numSplits, min)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get statistics of %s.%s", tbl.Schema, tbl.Table)
+	}
+	if len(splitPoints) == numSplits-1 {
+		return splitPoints, nil
+	}
+	if len(splitPoints) > len(best) {
+		best = splitPoints
+	}
+	splitPoints, err = keysetSplitPoints(ctx, truthConn, tbl, numSplits, min)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot sample primary keys of %s.%s", tbl.Schema, tbl.Table)
+	}
+	if len(splitPoints) == numSplits-1 {
+		return splitPoints, nil
+	}
+	if len(splitPoints) > len(best) {
+		best = splitPoints
+	}
+	// Arithmetic on the first column of a composite key ignores how rows
+	// are distributed over the other columns, so it is only used for single
+	// column keys.
+	if len(tbl.PrimaryKeyColumns) == 1 {
+		if splitPoints, ok := arithmeticSplitPoints(min, max, numSplits); ok && len(splitPoints) > len(best) {
+			best = splitPoints
+		}
+	}
+	return best, nil
+}
+
+// arithmeticSplitPoints divides the range between the first column of the
+// minimum and maximum primary key evenly, removing split points which would
+// result in an empty shard if the range is too small.
+func arithmeticSplitPoints(min tree.Datums, max tree.Datums, numSplits int) ([]tree.Datums, bool) {
+	ret := make([]tree.Datums, 0, numSplits-1)
+	for splitNum := 1; splitNum < numSplits; splitNum++ {
+		switch min[0].ResolvedType().Family() {
+		case types.IntFamily:
+			minVal := int64(*min[0].(*tree.DInt))
+			maxVal := int64(*max[0].(*tree.DInt))
+			valRange := maxVal - minVal
+			if valRange <= 0 {
+				return nil, false
+			}
+			splitVal := minVal + ((valRange / int64(numSplits)) * int64(splitNum))
+			ret = append(ret, tree.Datums{tree.NewDInt(tree.DInt(splitVal))})
+		case types.FloatFamily:
+			minVal := float64(*min[0].(*tree.DFloat))
+			maxVal := float64(*max[0].(*tree.DFloat))
+			valRange := maxVal - minVal
+			if valRange <= 0 || math.IsNaN(valRange) || math.IsInf(valRange, 0) {
+				return nil, false
+			}
+			splitVal := minVal + ((valRange / float64(numSplits)) * float64(splitNum))
+			ret = append(ret, tree.Datums{tree.NewDFloat(tree.DFloat(splitVal))})
+		case types.UuidFamily:
+			// Use the high ranges to divide.
+			minVal := min[0].(*tree.DUuid).UUID.ToUint128().Hi
+			maxVal := max[0].(*tree.DUuid).UUID.ToUint128().Hi
+			valRange := maxVal - minVal
+			if valRange <= 0 {
+				return nil, false
+			}
+			splitVal := minVal + ((valRange / uint64(numSplits)) * uint64(splitNum))
+			ret = append(ret, tree.Datums{&tree.DUuid{UUID: uuid.FromUint128(uint128.Uint128{Hi: splitVal})}})
+		default:
+			return nil, false
+		}
+	}
+	return dedupeSplitPoints(ret, min), true
+}
+
+// statisticsSplitPoints picks split points from the histogram of the first
+// primary key column, if the database has collected one.
+func statisticsSplitPoints(
+	ctx context.Context,
+	truthConn dbconn.Conn,
+	tbl tableverify.Result,
+	numSplits int,
+	min tree.Datums,
+) ([]tree.Datums, error) {
+	typ, ok := types.OidToType[tbl.ColumnOIDs[0][0]]
+	if !ok {
+		return nil, nil
+	}
+	var bounds tree.Datums
+	var err error
+	switch truthConn := truthConn.(type) {
+	case *dbconn.PGConn:
+		if truthConn.IsCockroach() {
+			bounds, err = getCRDBHistogram(ctx, truthConn, tbl, typ)
+		} else {
+			bounds, err = getPGHistogram(ctx, truthConn, tbl, typ)
+		}
+	}
+	if err != nil || len(bounds) == 0 {
+		return nil, err
+	}
+	candidates := make([]tree.Datums, 0, numSplits-1)
+	for splitNum := 1; splitNum < numSplits; splitNum++ {
+		candidates = append(candidates, tree.Datums{bounds[splitNum*len(bounds)/numSplits]})
+	}
+	return dedupeSplitPoints(candidates, min), nil
+}
+
+// rangeSplitPoints picks split points from the start keys of the ranges of
+// the primary index of a CockroachDB table. Unlike a histogram, this needs no
+// table statistics to have been collected.
+func rangeSplitPoints(
+	ctx context.Context,
+	truthConn dbconn.Conn,
+	tbl tableverify.Result,
+	numSplits int,
+	min tree.Datums,
+) ([]tree.Datums, error) {
+	pgConn, ok := truthConn.(*dbconn.PGConn)
+	if !ok || !pgConn.IsCockroach() {
+		return nil, nil
+	}
+	typs := make([]*types.T, len(tbl.PrimaryKeyColumns))
+	for i := range typs {
+		typ, ok := types.OidToType[tbl.ColumnOIDs[0][i]]
+		if !ok {
+			// Only the columns up to this one can be parsed from range keys.
+			typs = typs[:i]
+			break
+		}
+		typs[i] = typ
+	}
+	if len(typs) == 0 {
+		return nil, nil
+	}
+	startKeys, err := getCRDBRangeStartKeys(ctx, pgConn, tbl)
+	if err != nil {
+		return nil, err
+	}
+	var bounds []tree.Datums
+	for _, startKey := range startKeys {
+		if pk := parseCRDBRangeKey(startKey, typs); len(pk) > 0 {
+			bounds = append(bounds, pk)
+		}
+	}
+	if len(bounds) == 0 {
+		return nil, nil
+	}
+	candidates := make([]tree.Datums, 0, numSplits-1)
+	for splitNum := 1; splitNum < numSplits; splitNum++ {
+		candidates = append(candidates, bounds[splitNum*len(bounds)/numSplits])
+	}
+	return dedupeSplitPoints(candidates, min), nil
+}
+
+// getCRDBRangeStartKeys returns the start keys of the ranges of the primary
+// index of the table, pretty printed relative to the index.
+func getCRDBRangeStartKeys(
+	ctx context.Context, truthConn *dbconn.PGConn, tbl tableverify.Result,
+) ([]string, error) {
+	tn := tree.MakeTableNameFromPrefix(
+		tree.ObjectNamePrefix{SchemaName: tbl.Schema, ExplicitSchema: true},
+		tbl.Table,
+	)
+	var indexName string
+	if err := truthConn.QueryRow(
+		ctx,
+		`SELECT index_name FROM crdb_internal.table_indexes
+WHERE descriptor_id = $1::REGCLASS::INT8 AND index_type = 'primary'`,
+		tree.AsString(&tn),
+	).Scan(&indexName); err != nil {
+		return nil, errors.Wrap(err, "error finding primary index")
+	}
+	idx := tree.TableIndexName{Table: tn, Index: tree.UnrestrictedName(indexName)}
+	rows, err := truthConn.Query(
+		ctx,
+		fmt.Sprintf("SELECT start_key FROM [SHOW RANGES FROM INDEX %s]", tree.AsString(&idx)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []string
+	for rows.Next() {
+		var startKey *string
+		if err := rows.Scan(&startKey); err != nil {
+			return nil, err
+		}
+		if startKey != nil {
+			ret = append(ret, *startKey)
+		}
+	}
+	return ret, rows.Err()
+}
+
+// parseCRDBRangeKey parses the primary key values from a range key pretty
+// printed relative to the primary index, e.g. `…/1/"a"`. Only the values up
+// to the first which cannot be parsed are returned, so the result may be a
+// prefix of the primary key, or empty for keys such as `…/<IndexMin>`.
+func parseCRDBRangeKey(key string, typs []*types.T) tree.Datums {
+	key = strings.TrimPrefix(key, "…")
+	var ret tree.Datums
+	for len(ret) < len(typs) && strings.HasPrefix(key, "/") {
+		key = key[1:]
+		var val string
+		if strings.HasPrefix(key, `"`) {
+			quoted, err := strconv.QuotedPrefix(key)
+			if err != nil {
+				break
+			}
+			key = key[len(quoted):]
+			if val, err = strconv.Unquote(quoted); err != nil {
+				break
+			}
+		} else {
+			end := strings.IndexByte(key, '/')
+			if end < 0 {
+				end = len(key)
+			}
+			val, key = key[:end], key[end:]
+			// Markers such as <IndexMin> and PrefixEnd, and NULLs, are not
+			// values. Strings are always quoted, so are not confused with
+			// these.
+			if strings.HasPrefix(val, "<") || val == "PrefixEnd" || val == "NULL" {
+				break
+			}
+		}
+		d, _, err := tree.ParseAndRequireString(typs[len(ret)], val, parsectx.ParseContext)
+		if err != nil {
+			break
+		}
+		ret = append(ret, d)
+	}
+	return ret
+}
+
+func getPGHistogram(
+	ctx context.Context, truthConn *dbconn.PGConn, tbl tableverify.Result, typ *types.T,
+) (tree.Datums, error) {
+	rows, err := truthConn.Query(
+		ctx,
+		`SELECT histogram_bounds::TEXT FROM pg_catalog.pg_stats
+WHERE schemaname = $1 AND tablename = $2 AND attname = $3 AND histogram_bounds IS NOT NULL`,
+		string(tbl.Schema),
+		string(tbl.Table),
+		string(tbl.PrimaryKeyColumns[0]),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	var bounds string
+	if err := rows.Scan(&bounds); err != nil {
+		return nil, err
+	}
+	arr, _, err := tree.ParseDArrayFromString(parsectx.ParseContext, bounds, typ)
+	if err != nil {
+		return nil, err
+	}
+	return arr.Array, nil
+}
+
+type crdbTableStatistic struct {
+	Columns      []string `json:"columns"`
+	HistoBuckets []struct {
+		UpperBound string `json:"upper_bound"`
+	} `json:"histo_buckets"`
+}
+
+func getCRDBHistogram(
+	ctx context.Context, truthConn *dbconn.PGConn, tbl tableverify.Result, typ *types.T,
+) (tree.Datums, error) {
+	tn := tree.MakeTableNameFromPrefix(
+		tree.ObjectNamePrefix{SchemaName: tbl.Schema, ExplicitSchema: true},
+		tbl.Table,
+	)
+	var statsJSON []byte
+	if err := truthConn.QueryRow(
+		ctx,
+		fmt.Sprintf("SHOW STATISTICS USING JSON FOR TABLE %s", tree.AsString(&tn)),
+	).Scan(&statsJSON); err != nil {
+		return nil, err
+	}
+	return parseCRDBHistogram(statsJSON, tbl.PrimaryKeyColumns[0], typ)
+}
+
+// parseCRDBHistogram returns the upper bounds of the most recent histogram of
+// the given column from the output of SHOW STATISTICS USING JSON.
+func parseCRDBHistogram(statsJSON []byte, col tree.Name, typ *types.T) (tree.Datums, error) {
+	var stats []crdbTableStatistic
+	if err := json.Unmarshal(statsJSON, &stats); err != nil {
+		return nil, errors.Wrap(err, "error decoding statistics")
+	}
+	// Statistics are ordered by creation time, so the last is the most recent.
+	for i := len(stats) - 1; i >= 0; i-- {
+		stat := stats[i]
+		if len(stat.Columns) != 1 || stat.Columns[0] != string(col) || len(stat.HistoBuckets) == 0 {
+			continue
+		}
+		ret := make(tree.Datums, len(stat.HistoBuckets))
+		for j, bucket := range stat.HistoBuckets {
+			d, _, err := tree.ParseAndRequireString(typ, bucket.UpperBound, parsectx.ParseContext)
+			if err != nil {
+				return nil, err
+			}
+			ret[j] = d
+		}
+		return ret, nil
+	}
+	return nil, nil
+}
+
+// keysetSplitPoints samples the primary keys at evenly spaced row counts as
+// split points. Each key is found by skipping rows from the previous key
+// rather than from the start of the table, so the primary key is scanned at
+// most once in total.
+func keysetSplitPoints(
+	ctx context.Context,
+	truthConn dbconn.Conn,
+	tbl tableverify.Result,
+	numSplits int,
+	min tree.Datums,
+) ([]tree.Datums, error) {
+	count, err := getRowCount(ctx, truthConn, tbl)
+	if err != nil {
+		return nil, err
+	}
+	step := count / int64(numSplits)
+	if step < 1 {
+		step = 1
+	}
+	candidates := make([]tree.Datums, 0, numSplits-1)
+	var prev tree.Datums
+	for splitNum := 1; splitNum < numSplits; splitNum++ {
+		pk, err := getPKAtOffset(ctx, truthConn, tbl, true, prev, uint64(step))
+		if err != nil {
+			return nil, err
+		}
+		if len(pk) == 0 {
+			break
+		}
+		candidates = append(candidates, pk)
+		prev = pk
+	}
+	return dedupeSplitPoints(candidates, min), nil
+}
+
+// dedupeSplitPoints removes split points which would result in an empty
+// shard, i.e. those not strictly greater than the preceding split point (or
+// the minimum primary key). Split points may be a prefix of the primary key.
+func dedupeSplitPoints(candidates []tree.Datums, min tree.Datums) []tree.Datums {
+	var ret []tree.Datums
+	prev := min
+	for _, candidate := range candidates {
+		if comparePKPrefix(candidate, prev) <= 0 {
+			continue
+		}
+		ret = append(ret, candidate)
+		prev = candidate
+	}
+	return ret
+}
+
+func comparePKPrefix(a tree.Datums, b tree.Datums) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := a[i].Compare(comparectx.CompareContext, b[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// getTableBounds returns the minimum and maximum primary key of the table.
+func getTableBounds(
+	ctx context.Context, truthConn dbconn.Conn, tbl tableverify.Result,
+) (min tree.Datums, max tree.Datums, err error) {
+	min, err = getTableExtremes(ctx, truthConn, tbl, true)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot get minimum of %s.%s", tbl.Schema, tbl.Table)
+	}
+	max, err = getTableExtremes(ctx, truthConn, tbl, false)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot get maximum of %s.%s", tbl.Schema, tbl.Table)
+	}
+	return min, max, nil
+}
+
 func getTableExtremes(
 	ctx context.Context, truthConn dbconn.Conn, tbl tableverify.Result, isMin bool,
+) (tree.Datums, error) {
+	return getPKAtOffset(ctx, truthConn, tbl, isMin, nil, 0)
+}
+
+// getPKAtOffset returns the primary key at the given offset when ordering by
+// the primary key, starting from the primary key from if set, or nil if the
+// offset is past the end of the table.
+func getPKAtOffset(
+	ctx context.Context,
+	truthConn dbconn.Conn,
+	tbl tableverify.Result,
+	isMin bool,
+	from tree.Datums,
+	offset uint64,
 ) (tree.Datums, error) {
 	// Note here we use `.Query` instead of the `.QueryRow` counterpart.
 	// This is because the API for `.Query` actually has other metadata from
@@ -131,12 +548,12 @@ func getTableExtremes(
 	switch truthConn := truthConn.(type) {
 	case *dbconn.PGConn:
 		f := tree.NewFmtCtx(tree.FmtParsableNumerics)
-		s := buildSelectForSplitPG(tbl, isMin)
+		s := buildSelectForSplitPG(tbl, isMin, from, offset)
 		f.FormatNode(s)
 		q := f.CloseAndGetString()
 		rows, err := truthConn.Query(ctx, q)
 		if err != nil {
-			return nil, errors.Wrapf(err, "error getting minimum value for %s.%s", tbl.Schema, tbl.Table)
+			return nil, errors.Wrapf(err, "error getting primary key value for %s.%s", tbl.Schema, tbl.Table)
 		}
 		defer rows.Close()
 		if rows.Next() {
@@ -153,13 +570,13 @@ func getTableExtremes(
 		return nil, rows.Err()
 	case *dbconn.MySQLConn:
 		var sb strings.Builder
-		if err := buildSelectForSplitMySQL(tbl, isMin).Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		if err := buildSelectForSplitMySQL(tbl, isMin, from, offset).Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
 			return nil, errors.Wrap(err, "error generating MySQL statement")
 		}
 		q := sb.String()
 		rows, err := truthConn.QueryContext(ctx, q)
 		if err != nil {
-			return nil, errors.Wrapf(err, "error getting minimum value for %s.%s", tbl.Schema, tbl.Table)
+			return nil, errors.Wrapf(err, "error getting primary key value for %s.%s", tbl.Schema, tbl.Table)
 		}
 		defer rows.Close()
 		if rows.Next() {
@@ -171,7 +588,54 @@ func getTableExtremes(
 	return nil, errors.AssertionFailedf("unknown type for extremes: %T", truthConn)
 }
 
-func buildSelectForSplitPG(tbl tableverify.Result, isMin bool) *tree.Select {
+func getRowCount(
+	ctx context.Context, truthConn dbconn.Conn, tbl tableverify.Result,
+) (int64, error) {
+	var count int64
+	switch truthConn := truthConn.(type) {
+	case *dbconn.PGConn:
+		tn := tree.MakeTableNameFromPrefix(
+			tree.ObjectNamePrefix{SchemaName: tbl.Schema, ExplicitSchema: true},
+			tbl.Table,
+		)
+		if err := truthConn.QueryRow(
+			ctx,
+			fmt.Sprintf("SELECT count(*) FROM %s", tree.AsStringWithFlags(&tn, tree.FmtParsableNumerics)),
+		).Scan(&count); err != nil {
+			return 0, errors.Wrapf(err, "error counting rows for %s.%s", tbl.Schema, tbl.Table)
+		}
+		return count, nil
+	case *dbconn.MySQLConn:
+		stmt := &ast.SelectStmt{
+			From: &ast.TableRefsClause{
+				TableRefs: &ast.Join{
+					Left: &ast.TableSource{
+						Source: &ast.TableName{Name: model.NewCIStr(string(tbl.Table))},
+					},
+				},
+			},
+			Fields: &ast.FieldList{
+				Fields: []*ast.SelectField{
+					{Expr: &ast.AggregateFuncExpr{F: ast.AggFuncCount, Args: []ast.ExprNode{ast.NewValueExpr(1, "", "")}}},
+				},
+			},
+			Kind: ast.SelectStmtKindSelect,
+		}
+		var sb strings.Builder
+		if err := stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+			return 0, errors.Wrap(err, "error generating MySQL statement")
+		}
+		if err := truthConn.QueryRowContext(ctx, sb.String()).Scan(&count); err != nil {
+			return 0, errors.Wrapf(err, "error counting rows for %s.%s", tbl.Schema, tbl.Table)
+		}
+		return count, nil
+	}
+	return 0, errors.AssertionFailedf("unknown type for row count: %T", truthConn)
+}
+
+func buildSelectForSplitPG(
+	tbl tableverify.Result, isMin bool, from tree.Datums, offset uint64,
+) *tree.Select {
 	tn := tree.MakeTableNameFromPrefix(
 		tree.ObjectNamePrefix{SchemaName: tbl.Schema, ExplicitSchema: true},
 		tbl.Table,
@@ -181,6 +645,12 @@ func buildSelectForSplitPG(tbl tableverify.Result, isMin bool) *tree.Select {
 			Tables: tree.TableExprs{&tn},
 		},
 	}
+	if len(from) > 0 {
+		selectClause.Where = &tree.Where{
+			Type: tree.AstWhere,
+			Expr: rowiterator.PGShardBoundsExpr(splitFrom(tbl, from)),
+		}
+	}
 	for _, col := range tbl.PrimaryKeyColumns {
 		selectClause.Exprs = append(
 			selectClause.Exprs,
@@ -193,6 +663,9 @@ func buildSelectForSplitPG(tbl tableverify.Result, isMin bool) *tree.Select {
 		Select: selectClause,
 		Limit:  &tree.Limit{Count: tree.NewNumVal(constant.MakeUint64(uint64(1)), "", false)},
 	}
+	if offset > 0 {
+		baseSelectExpr.Limit.Offset = tree.NewNumVal(constant.MakeUint64(offset), "", false)
+	}
 	for _, pkCol := range tbl.PrimaryKeyColumns {
 		orderClause := &tree.Order{Expr: tree.NewUnresolvedName(string(pkCol))}
 		if !isMin {
@@ -206,7 +679,18 @@ func buildSelectForSplitPG(tbl tableverify.Result, isMin bool) *tree.Select {
 	return baseSelectExpr
 }
 
-func buildSelectForSplitMySQL(table tableverify.Result, isMin bool) *ast.SelectStmt {
+// splitFrom returns the table scanned from the primary key from when
+// sampling split points.
+func splitFrom(tbl tableverify.Result, from tree.Datums) rowiterator.ScanTable {
+	return rowiterator.ScanTable{
+		Table:       rowiterator.Table{ColumnNames: tbl.PrimaryKeyColumns},
+		StartPKVals: from,
+	}
+}
+
+func buildSelectForSplitMySQL(
+	table tableverify.Result, isMin bool, from tree.Datums, offset uint64,
+) *ast.SelectStmt {
 	fields := &ast.FieldList{
 		Fields: make([]*ast.SelectField, len(table.PrimaryKeyColumns)),
 	}
@@ -224,7 +708,11 @@ func buildSelectForSplitMySQL(table tableverify.Result, isMin bool) *ast.SelectS
 			orderBy.Items[i].Desc = true
 		}
 	}
-	return &ast.SelectStmt{
+	limit := &ast.Limit{Count: ast.NewValueExpr(1, "", "")}
+	if offset > 0 {
+		limit.Offset = ast.NewValueExpr(offset, "", "")
+	}
+	stmt := &ast.SelectStmt{
 		SelectStmtOpts: &ast.SelectStmtOpts{
 			SQLCache: true,
 		},
@@ -237,7 +725,11 @@ func buildSelectForSplitMySQL(table tableverify.Result, isMin bool) *ast.SelectS
 		},
 		Fields:  fields,
 		Kind:    ast.SelectStmtKindSelect,
-		Limit:   &ast.Limit{Count: ast.NewValueExpr(1, "", "")},
+		Limit:   limit,
 		OrderBy: orderBy,
 	}
+	if len(from) > 0 {
+		stmt.Where = rowiterator.MySQLShardBoundsExpr(splitFrom(table, from))
+	}
+	return stmt
 }
@@ -0,0 +1,251 @@
+package verify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/pingcap/tidb/parser/format"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupeSplitPoints(t *testing.T) {
+	for _, tc := range []struct {
+		desc       string
+		candidates []tree.Datums
+		min        tree.Datums
+		expected   []tree.Datums
+	}{
+		{
+			desc: "strictly increasing",
+			candidates: []tree.Datums{
+				{tree.NewDString("c")},
+				{tree.NewDString("e")},
+			},
+			min: tree.Datums{tree.NewDString("a")},
+			expected: []tree.Datums{
+				{tree.NewDString("c")},
+				{tree.NewDString("e")},
+			},
+		},
+		{
+			desc: "duplicates and minimum removed",
+			candidates: []tree.Datums{
+				{tree.NewDString("a")},
+				{tree.NewDString("c")},
+				{tree.NewDString("c")},
+				{tree.NewDString("e")},
+			},
+			min: tree.Datums{tree.NewDString("a")},
+			expected: []tree.Datums{
+				{tree.NewDString("c")},
+				{tree.NewDString("e")},
+			},
+		},
+		{
+			desc: "prefix equal to minimum removed",
+			candidates: []tree.Datums{
+				{tree.NewDInt(1)},
+				{tree.NewDInt(2)},
+			},
+			min: tree.Datums{tree.NewDInt(1), tree.NewDString("z")},
+			expected: []tree.Datums{
+				{tree.NewDInt(2)},
+			},
+		},
+		{
+			desc: "composite keys",
+			candidates: []tree.Datums{
+				{tree.NewDInt(1), tree.NewDInt(3)},
+				{tree.NewDInt(1), tree.NewDInt(3)},
+				{tree.NewDInt(1), tree.NewDInt(5)},
+			},
+			min: tree.Datums{tree.NewDInt(1), tree.NewDInt(1)},
+			expected: []tree.Datums{
+				{tree.NewDInt(1), tree.NewDInt(3)},
+				{tree.NewDInt(1), tree.NewDInt(5)},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, dedupeSplitPoints(tc.candidates, tc.min))
+		})
+	}
+}
+
+func TestArithmeticSplitPoints(t *testing.T) {
+	for _, tc := range []struct {
+		desc      string
+		min       tree.Datums
+		max       tree.Datums
+		numSplits int
+		expected  []tree.Datums
+		ok        bool
+	}{
+		{
+			desc:      "evenly spaced",
+			min:       tree.Datums{tree.NewDInt(0)},
+			max:       tree.Datums{tree.NewDInt(90)},
+			numSplits: 3,
+			expected:  []tree.Datums{{tree.NewDInt(30)}, {tree.NewDInt(60)}},
+			ok:        true,
+		},
+		{
+			desc:      "range smaller than splits",
+			min:       tree.Datums{tree.NewDInt(1)},
+			max:       tree.Datums{tree.NewDInt(3)},
+			numSplits: 4,
+			ok:        true,
+		},
+		{
+			desc:      "single value",
+			min:       tree.Datums{tree.NewDInt(1)},
+			max:       tree.Datums{tree.NewDInt(1)},
+			numSplits: 4,
+		},
+		{
+			desc:      "not numeric",
+			min:       tree.Datums{tree.NewDString("a")},
+			max:       tree.Datums{tree.NewDString("z")},
+			numSplits: 4,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			splitPoints, ok := arithmeticSplitPoints(tc.min, tc.max, tc.numSplits)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, splitPoints)
+		})
+	}
+}
+
+func TestParseCRDBHistogram(t *testing.T) {
+	const statsJSON = `[
+	{"columns": ["id"], "histo_buckets": [{"upper_bound": "a"}, {"upper_bound": "b"}], "name": "__auto__"},
+	{"columns": ["id", "v"], "name": "__auto__"},
+	{"columns": ["id"], "histo_buckets": [{"upper_bound": "c"}, {"upper_bound": "it's"}], "name": "__auto__"},
+	{"columns": ["v"], "histo_buckets": [{"upper_bound": "z"}], "name": "__auto__"}
+]`
+	bounds, err := parseCRDBHistogram([]byte(statsJSON), "id", types.String)
+	require.NoError(t, err)
+	require.Equal(t, tree.Datums{tree.NewDString("c"), tree.NewDString("it's")}, bounds)
+
+	bounds, err = parseCRDBHistogram([]byte(statsJSON), "missing", types.String)
+	require.NoError(t, err)
+	require.Nil(t, bounds)
+}
+
+func TestParseCRDBRangeKey(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		key      string
+		typs     []*types.T
+		expected tree.Datums
+	}{
+		{
+			desc:     "int",
+			key:      "…/100",
+			typs:     []*types.T{types.Int},
+			expected: tree.Datums{tree.NewDInt(100)},
+		},
+		{
+			desc:     "without elided prefix",
+			key:      "/100",
+			typs:     []*types.T{types.Int},
+			expected: tree.Datums{tree.NewDInt(100)},
+		},
+		{
+			desc:     "composite",
+			key:      `…/"a/b\"c"/5`,
+			typs:     []*types.T{types.String, types.Int},
+			expected: tree.Datums{tree.NewDString(`a/b"c`), tree.NewDInt(5)},
+		},
+		{
+			desc:     "prefix of primary key",
+			key:      `…/"a"/PrefixEnd`,
+			typs:     []*types.T{types.String, types.Int},
+			expected: tree.Datums{tree.NewDString("a")},
+		},
+		{
+			desc:     "column family suffix",
+			key:      "…/7/0",
+			typs:     []*types.T{types.Int},
+			expected: tree.Datums{tree.NewDInt(7)},
+		},
+		{
+			desc: "index min",
+			key:  "…/<IndexMin>",
+			typs: []*types.T{types.String},
+		},
+		{
+			desc: "null",
+			key:  "…/NULL",
+			typs: []*types.T{types.Int},
+		},
+		{
+			desc: "unparseable",
+			key:  "…/abc",
+			typs: []*types.T{types.Int},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, parseCRDBRangeKey(tc.key, tc.typs))
+		})
+	}
+}
+
+func TestBuildSelectForSplit(t *testing.T) {
+	tbl := tableverify.Result{
+		VerifiedTable: dbtable.VerifiedTable{
+			Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+			PrimaryKeyColumns: []tree.Name{"k", "id"},
+		},
+	}
+	for _, tc := range []struct {
+		desc          string
+		isMin         bool
+		from          tree.Datums
+		offset        uint64
+		expectedPG    string
+		expectedMySQL string
+	}{
+		{
+			desc:          "min",
+			isMin:         true,
+			expectedPG:    `SELECT k, id FROM public.tbl ORDER BY k, id LIMIT 1`,
+			expectedMySQL: "SELECT `k`,`id` FROM `tbl` ORDER BY `k`,`id` LIMIT 1",
+		},
+		{
+			desc:          "max",
+			expectedPG:    `SELECT k, id FROM public.tbl ORDER BY k DESC, id DESC LIMIT 1`,
+			expectedMySQL: "SELECT `k`,`id` FROM `tbl` ORDER BY `k` DESC,`id` DESC LIMIT 1",
+		},
+		{
+			desc:          "offset",
+			isMin:         true,
+			offset:        20,
+			expectedPG:    `SELECT k, id FROM public.tbl ORDER BY k, id LIMIT 1 OFFSET 20`,
+			expectedMySQL: "SELECT `k`,`id` FROM `tbl` ORDER BY `k`,`id` LIMIT 20,1",
+		},
+		{
+			desc:          "offset from primary key",
+			isMin:         true,
+			from:          tree.Datums{tree.NewDInt(3), tree.NewDString("a")},
+			offset:        20,
+			expectedPG:    `SELECT k, id FROM public.tbl WHERE ((k, id) >= (3, 'a')) AND true ORDER BY k, id LIMIT 1 OFFSET 20`,
+			expectedMySQL: "SELECT `k`,`id` FROM `tbl` WHERE ROW(`k`,`id`)>=ROW('3','a') AND 1 ORDER BY `k`,`id` LIMIT 20,1",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := tree.NewFmtCtx(tree.FmtParsableNumerics)
+			f.FormatNode(buildSelectForSplitPG(tbl, tc.isMin, tc.from, tc.offset))
+			require.Equal(t, tc.expectedPG, f.CloseAndGetString())
+
+			var sb strings.Builder
+			require.NoError(t, buildSelectForSplitMySQL(tbl, tc.isMin, tc.from, tc.offset).Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)))
+			require.Equal(t, tc.expectedMySQL, sb.String())
+		})
+	}
+}
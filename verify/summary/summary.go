@@ -0,0 +1,297 @@
+// Package summary collects the results of a verification run into per-table
+// and per-shard totals.
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/cockroachdb/molt/verify/tableverify"
+)
+
+// Exit codes used by molt verify, which may be combined if both schema and
+// row inconsistencies are found.
+const (
+	ExitCodeError                 = 1
+	ExitCodeSchemaInconsistencies = 2
+	ExitCodeRowInconsistencies    = 4
+)
+
+// ShardSummary is the result of verifying rows on a shard.
+type ShardSummary struct {
+	ShardNum    int `json:"shard_num"`
+	TotalShards int `json:"total_shards"`
+	rowverify.RowStats
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Error is set if the shard failed to verify.
+	Error string `json:"error,omitempty"`
+	// Completed is set if the shard was verified by an earlier run and
+	// skipped when resuming from its checkpoint.
+	Completed bool `json:"completed,omitempty"`
+}
+
+// TableSummary is the result of verifying a table, with row statistics
+// totalled across its shards.
+type TableSummary struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	rowverify.RowStats
+	SchemaInconsistencies int            `json:"schema_inconsistencies"`
+	RowInconsistencies    int            `json:"row_inconsistencies"`
+	DurationSeconds       float64        `json:"duration_seconds"`
+	Shards                []ShardSummary `json:"shards"`
+}
+
+// Summary is the result of a verification run.
+type Summary struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	// SchemaInconsistencies counts missing, extraneous and mismatching
+	// tables and schema objects.
+	SchemaInconsistencies int `json:"schema_inconsistencies"`
+	// RowInconsistencies counts missing, extraneous and mismatching rows,
+	// totalled from the row statistics of each shard, and mismatching row
+	// counts and aggregates.
+	RowInconsistencies int `json:"row_inconsistencies"`
+	// ManualReview counts objects which must be reviewed by hand.
+	ManualReview int            `json:"manual_review"`
+	FailedShards int            `json:"failed_shards"`
+	Tables       []TableSummary `json:"tables"`
+}
+
+// ExitCode returns the exit code molt verify should exit with.
+func (s Summary) ExitCode() int {
+	if s.FailedShards > 0 {
+		return ExitCodeError
+	}
+	code := 0
+	if s.SchemaInconsistencies > 0 {
+		code |= ExitCodeSchemaInconsistencies
+	}
+	if s.RowInconsistencies > 0 {
+		code |= ExitCodeRowInconsistencies
+	}
+	return code
+}
+
+// WriteTable writes the summary as a human readable table.
+func (s Summary) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tSHARDS\tROWS\tSUCCESS\tMISSING\tMISMATCH\tEXTRANEOUS\tLIVE RETRY\tSCHEMA ISSUES\tDURATION")
+	var total TableSummary
+	for _, t := range s.Tables {
+		writeTableRow(tw, t.Schema+"."+t.Table, len(t.Shards), t)
+		total.addStats(t.RowStats)
+		total.SchemaInconsistencies += t.SchemaInconsistencies
+	}
+	total.DurationSeconds = s.EndTime.Sub(s.StartTime).Seconds()
+	writeTableRow(tw, "TOTAL", -1, total)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(
+		w,
+		"schema inconsistencies: %d, row inconsistencies: %d, manual review: %d, failed shards: %d\n",
+		s.SchemaInconsistencies,
+		s.RowInconsistencies,
+		s.ManualReview,
+		s.FailedShards,
+	)
+	return err
+}
+
+func writeTableRow(w io.Writer, name string, numShards int, t TableSummary) {
+	shards := "-"
+	if numShards >= 0 {
+		shards = fmt.Sprintf("%d", numShards)
+	}
+	fmt.Fprintf(
+		w,
+		"%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n",
+		name,
+		shards,
+		t.NumVerified,
+		t.NumSuccess,
+		t.NumMissing,
+		t.NumMismatch,
+		t.NumExtraneous,
+		t.NumLiveRetry,
+		t.SchemaInconsistencies,
+		(time.Duration(t.DurationSeconds * float64(time.Second))).Round(time.Millisecond),
+	)
+}
+
+// WriteJSONFile writes the summary as JSON to the file at the given path.
+func (s Summary) WriteJSONFile(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error encoding summary")
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, "error writing summary to %s", path)
+	}
+	return nil
+}
+
+func (t *TableSummary) addStats(s rowverify.RowStats) {
+	t.NumVerified += s.NumVerified
+	t.NumSuccess += s.NumSuccess
+	t.NumMissing += s.NumMissing
+	t.NumMismatch += s.NumMismatch
+	t.NumExtraneous += s.NumExtraneous
+	t.NumLiveRetry += s.NumLiveRetry
+}
+
+// Collector collects a Summary from the objects reported during
+// verification and the results of each shard. It is safe for concurrent use.
+type Collector struct {
+	mu        sync.Mutex
+	startTime time.Time
+	summary   Summary
+	tables    map[dbtable.Name]*TableSummary
+}
+
+var _ inconsistency.Reporter = (*Collector)(nil)
+
+// NewCollector returns a Collector for a verification run starting now.
+func NewCollector() *Collector {
+	return &Collector{
+		startTime: time.Now(),
+		tables:    make(map[dbtable.Name]*TableSummary),
+	}
+}
+
+func (c *Collector) table(name dbtable.Name) *TableSummary {
+	t, ok := c.tables[name]
+	if !ok {
+		t = &TableSummary{Schema: string(name.Schema), Table: string(name.Table)}
+		c.tables[name] = t
+	}
+	return t
+}
+
+// AddTable includes a table in the summary, even if nothing is reported
+// about it.
+func (c *Collector) AddTable(name dbtable.Name) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.table(name)
+}
+
+// AddShard records the result of verifying rows on a shard. If the shard was
+// already recorded, e.g. during continuous verification, the latest result
+// replaces it.
+func (c *Collector) AddShard(name dbtable.Name, shard ShardSummary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.table(name)
+	for i := range t.Shards {
+		if t.Shards[i].ShardNum == shard.ShardNum {
+			t.Shards[i] = shard
+			return
+		}
+	}
+	t.Shards = append(t.Shards, shard)
+}
+
+// Report implements inconsistency.Reporter, counting each inconsistency.
+func (c *Collector) Report(obj inconsistency.ReportableObject) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch obj := obj.(type) {
+	case inconsistency.MissingTable:
+		c.schemaInconsistency(&obj.Name)
+	case inconsistency.ExtraneousTable:
+		c.schemaInconsistency(&obj.Name)
+	case inconsistency.MismatchingTableDefinition:
+		// Tables without a key are still verified, so are not inconsistent.
+		if obj.Info != tableverify.NoRowKeyInfo {
+			c.schemaInconsistency(&obj.Name)
+		}
+	case inconsistency.MismatchingIndex:
+		c.schemaInconsistency(&obj.Name)
+	case inconsistency.MismatchingConstraint:
+		c.schemaInconsistency(&obj.Name)
+	case inconsistency.MismatchingColumnDefault:
+		c.schemaInconsistency(&obj.Name)
+	case inconsistency.MismatchingComputedColumn:
+		c.schemaInconsistency(&obj.Name)
+	case inconsistency.MissingSequence, inconsistency.ExtraneousSequence,
+		inconsistency.MismatchingSequenceValue, inconsistency.MissingObject,
+		inconsistency.ExtraneousObject, inconsistency.MismatchingEnumLabels,
+		inconsistency.MismatchingViewDefinition:
+		c.schemaInconsistency(nil)
+	case inconsistency.RoutineRequiresReview:
+		c.summary.ManualReview++
+	// Missing, mismatching and extraneous rows are counted from the row
+	// statistics of shards instead, which include shards resumed from a
+	// checkpoint and only count the latest run of a shard.
+	case inconsistency.MismatchingRowCount:
+		c.rowInconsistency(obj.Name)
+	case inconsistency.MismatchingAggregate:
+		c.rowInconsistency(obj.Name)
+	}
+}
+
+// schemaInconsistency counts a schema inconsistency, against the given table
+// if it is set.
+func (c *Collector) schemaInconsistency(name *dbtable.Name) {
+	c.summary.SchemaInconsistencies++
+	if name != nil {
+		c.table(*name).SchemaInconsistencies++
+	}
+}
+
+func (c *Collector) rowInconsistency(name dbtable.Name) {
+	c.summary.RowInconsistencies++
+	c.table(name).RowInconsistencies++
+}
+
+// Close implements inconsistency.Reporter.
+func (c *Collector) Close() {}
+
+// Summary returns the summary of everything collected so far.
+func (c *Collector) Summary() Summary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := c.summary
+	ret.StartTime = c.startTime
+	ret.EndTime = time.Now()
+	ret.Tables = make([]TableSummary, 0, len(c.tables))
+	for _, t := range c.tables {
+		tbl := *t
+		tbl.RowStats = rowverify.RowStats{}
+		tbl.DurationSeconds = 0
+		tbl.Shards = append([]ShardSummary(nil), t.Shards...)
+		sort.Slice(tbl.Shards, func(i, j int) bool {
+			return tbl.Shards[i].ShardNum < tbl.Shards[j].ShardNum
+		})
+		for _, shard := range tbl.Shards {
+			tbl.addStats(shard.RowStats)
+			tbl.DurationSeconds += shard.DurationSeconds
+			if shard.Error != "" {
+				ret.FailedShards++
+			}
+		}
+		tbl.RowInconsistencies += tbl.RowStats.NumInconsistent()
+		ret.RowInconsistencies += tbl.RowStats.NumInconsistent()
+		ret.Tables = append(ret.Tables, tbl)
+	}
+	sort.Slice(ret.Tables, func(i, j int) bool {
+		if ret.Tables[i].Schema != ret.Tables[j].Schema {
+			return ret.Tables[i].Schema < ret.Tables[j].Schema
+		}
+		return ret.Tables[i].Table < ret.Tables[j].Table
+	})
+	return ret
+}
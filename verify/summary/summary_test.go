@@ -0,0 +1,102 @@
+package summary
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	a := dbtable.Name{Schema: "public", Table: "a"}
+	b := dbtable.Name{Schema: "public", Table: "b"}
+
+	c := NewCollector()
+	c.AddTable(b)
+	c.AddTable(a)
+	c.Report(inconsistency.MismatchingTableDefinition{DBTable: dbtable.DBTable{Name: a}, Info: "missing column x"})
+	c.Report(inconsistency.MismatchingTableDefinition{DBTable: dbtable.DBTable{Name: b}, Info: tableverify.NoRowKeyInfo})
+	c.Report(inconsistency.MissingSequence{Name: dbtable.Name{Schema: "public", Table: "seq"}})
+	c.Report(inconsistency.RoutineRequiresReview{Name: dbtable.Name{Schema: "public", Table: "fn"}, RoutineType: "function"})
+	c.Report(inconsistency.MissingRow{Name: a})
+	c.Report(inconsistency.StatusReport{Info: "ignored"})
+	c.AddShard(a, ShardSummary{ShardNum: 2, TotalShards: 2, RowStats: rowverify.RowStats{NumVerified: 5, NumSuccess: 5}})
+	c.AddShard(a, ShardSummary{ShardNum: 1, TotalShards: 2, RowStats: rowverify.RowStats{NumVerified: 3, NumSuccess: 1}})
+	// A later run of the same shard replaces the earlier one.
+	c.AddShard(a, ShardSummary{ShardNum: 1, TotalShards: 2, RowStats: rowverify.RowStats{NumVerified: 4, NumSuccess: 3, NumMissing: 1}})
+
+	s := c.Summary()
+	require.Equal(t, 2, s.SchemaInconsistencies)
+	require.Equal(t, 1, s.RowInconsistencies)
+	require.Equal(t, 1, s.ManualReview)
+	require.Equal(t, 0, s.FailedShards)
+	require.Len(t, s.Tables, 2)
+
+	tblA := s.Tables[0]
+	require.Equal(t, "a", tblA.Table)
+	require.Equal(t, 1, tblA.SchemaInconsistencies)
+	require.Equal(t, 1, tblA.RowInconsistencies)
+	require.Equal(t, rowverify.RowStats{NumVerified: 9, NumSuccess: 8, NumMissing: 1}, tblA.RowStats)
+	require.Equal(t, []int{1, 2}, []int{tblA.Shards[0].ShardNum, tblA.Shards[1].ShardNum})
+
+	tblB := s.Tables[1]
+	require.Equal(t, "b", tblB.Table)
+	require.Equal(t, 0, tblB.SchemaInconsistencies)
+
+	require.Equal(t, ExitCodeSchemaInconsistencies|ExitCodeRowInconsistencies, s.ExitCode())
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteTable(&buf))
+	require.Contains(t, buf.String(), "public.a")
+	require.Contains(t, buf.String(), "schema inconsistencies: 2, row inconsistencies: 1, manual review: 1, failed shards: 0")
+}
+
+func TestCollectorRowInconsistencies(t *testing.T) {
+	a := dbtable.Name{Schema: "public", Table: "a"}
+	b := dbtable.Name{Schema: "public", Table: "b"}
+
+	c := NewCollector()
+	// A shard completed before resuming has no reported rows, but its
+	// inconsistencies are counted from its row statistics.
+	c.AddShard(a, ShardSummary{
+		ShardNum:    1,
+		TotalShards: 2,
+		RowStats:    rowverify.RowStats{NumVerified: 10, NumSuccess: 7, NumMissing: 1, NumMismatch: 2},
+		Completed:   true,
+	})
+	c.AddShard(a, ShardSummary{
+		ShardNum:    2,
+		TotalShards: 2,
+		RowStats:    rowverify.RowStats{NumVerified: 5, NumSuccess: 4, NumExtraneous: 1},
+	})
+	c.Report(inconsistency.ExtraneousRow{Name: a})
+	c.Report(inconsistency.MismatchingRowCount{Name: b})
+
+	s := c.Summary()
+	require.Equal(t, 5, s.RowInconsistencies)
+	require.Equal(t, 4, s.Tables[0].RowInconsistencies)
+	require.Equal(t, 1, s.Tables[1].RowInconsistencies)
+	require.Equal(t, ExitCodeRowInconsistencies, s.ExitCode())
+}
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		summary  Summary
+		expected int
+	}{
+		{desc: "consistent", expected: 0},
+		{desc: "schema", summary: Summary{SchemaInconsistencies: 1}, expected: ExitCodeSchemaInconsistencies},
+		{desc: "rows", summary: Summary{RowInconsistencies: 3}, expected: ExitCodeRowInconsistencies},
+		{desc: "manual review only", summary: Summary{ManualReview: 1}, expected: 0},
+		{desc: "failed shard", summary: Summary{RowInconsistencies: 3, FailedShards: 1}, expected: ExitCodeError},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.summary.ExitCode())
+		})
+	}
+}
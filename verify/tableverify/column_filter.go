@@ -0,0 +1,111 @@
+package tableverify
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+)
+
+// ColumnFilter selects which columns of each table are verified. Primary key
+// columns are always verified. The zero value verifies every column.
+type ColumnFilter struct {
+	exclude []columnRef
+	include []columnRef
+}
+
+// columnRef refers to a column of a table. An empty schema matches any
+// schema.
+type columnRef struct {
+	schema tree.Name
+	table  tree.Name
+	column tree.Name
+}
+
+func (r columnRef) matchesTable(tbl dbtable.Name) bool {
+	return (r.schema == "" || r.schema == tbl.Schema) && r.table == tbl.Table
+}
+
+// ParseColumnFilter parses lists of columns to exclude from and include in
+// verification, each in the form `table.column` or `schema.table.column`.
+// If a table has any included columns, only those columns are verified.
+func ParseColumnFilter(exclude []string, include []string) (ColumnFilter, error) {
+	var ret ColumnFilter
+	for _, l := range []struct {
+		strs []string
+		refs *[]columnRef
+	}{
+		{strs: exclude, refs: &ret.exclude},
+		{strs: include, refs: &ret.include},
+	} {
+		for _, s := range l.strs {
+			ref, err := parseColumnRef(s)
+			if err != nil {
+				return ColumnFilter{}, err
+			}
+			*l.refs = append(*l.refs, ref)
+		}
+	}
+	return ret, nil
+}
+
+func parseColumnRef(s string) (columnRef, error) {
+	parts := strings.Split(s, ".")
+	switch len(parts) {
+	case 2:
+		return columnRef{table: tree.Name(parts[0]), column: tree.Name(parts[1])}, nil
+	case 3:
+		return columnRef{schema: tree.Name(parts[0]), table: tree.Name(parts[1]), column: tree.Name(parts[2])}, nil
+	}
+	return columnRef{}, errors.Newf("expected column %q to be in the form table.column or schema.table.column", s)
+}
+
+// filterColumns removes columns which should not be verified from both
+// sides, returning the remaining columns and the names of those removed.
+func (f ColumnFilter) filterColumns(
+	tbl dbtable.Name, pkCols []tree.Name, columns [2][]Column,
+) ([2][]Column, []tree.Name) {
+	if len(f.exclude) == 0 && len(f.include) == 0 {
+		return columns, nil
+	}
+	keep := func(col tree.Name) bool {
+		for _, pkCol := range pkCols {
+			if col == pkCol {
+				return true
+			}
+		}
+		for _, r := range f.exclude {
+			if r.matchesTable(tbl) && r.column == col {
+				return false
+			}
+		}
+		hasInclude := false
+		for _, r := range f.include {
+			if r.matchesTable(tbl) {
+				if r.column == col {
+					return true
+				}
+				hasInclude = true
+			}
+		}
+		return !hasInclude
+	}
+
+	var ret [2][]Column
+	var excluded []tree.Name
+	seen := make(map[tree.Name]struct{})
+	for i := range columns {
+		for _, col := range columns[i] {
+			if keep(col.Name) {
+				ret[i] = append(ret[i], col)
+				continue
+			}
+			if _, ok := seen[col.Name]; !ok {
+				seen[col.Name] = struct{}{}
+				excluded = append(excluded, col.Name)
+			}
+		}
+	}
+	return ret, excluded
+}
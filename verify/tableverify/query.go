@@ -284,3 +284,105 @@ func mapColumns(cols []Column) map[tree.Name]Column {
 	}
 	return ret
 }
+
+func getUniqueKeysForTables(
+	ctx context.Context, conns dbconn.OrderedConns, tbls [2]dbtable.DBTable,
+) ([2][][]tree.Name, error) {
+	var ret [2][][]tree.Name
+	for i, conn := range conns {
+		var err error
+		ret[i], err = getUniqueKeys(ctx, conn, tbls[i])
+		if err != nil {
+			return ret, err
+		}
+	}
+	return ret, nil
+}
+
+// getUniqueKeys returns the columns of each unique index of a table which is
+// not the primary key, excluding partial and expression indexes.
+func getUniqueKeys(
+	ctx context.Context, conn dbconn.Conn, table dbtable.DBTable,
+) ([][]tree.Name, error) {
+	var idxNames []string
+	idxCols := make(map[string][]tree.Name)
+	addCol := func(idxName string, col tree.Name) {
+		if _, ok := idxCols[idxName]; !ok {
+			idxNames = append(idxNames, idxName)
+		}
+		idxCols[idxName] = append(idxCols[idxName], col)
+	}
+
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		rows, err := conn.Query(
+			ctx,
+			`SELECT i.relname, a.attname
+FROM pg_index ix
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = ANY(ix.indkey)
+WHERE ix.indrelid = $1
+  AND ix.indisunique AND NOT ix.indisprimary
+  AND ix.indpred IS NULL AND ix.indexprs IS NULL
+  AND array_position(ix.indkey::INT2[], a.attnum) <= ix.indnkeyatts
+ORDER BY i.relname, array_position(ix.indkey::INT2[], a.attnum)`,
+			table.OID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var idxName string
+			var c tree.Name
+			if err := rows.Scan(&idxName, &c); err != nil {
+				return nil, errors.Wrap(err, "error decoding unique index column")
+			}
+			addCol(idxName, c)
+		}
+		if rows.Err() != nil {
+			return nil, errors.Wrap(rows.Err(), "error collecting unique indexes")
+		}
+		rows.Close()
+	case *dbconn.MySQLConn:
+		rows, err := conn.QueryContext(
+			ctx,
+			`SELECT index_name, column_name
+FROM information_schema.statistics
+WHERE table_schema = database()
+  AND table_name = ?
+  AND non_unique = 0
+  AND index_name <> 'PRIMARY'
+  AND column_name IS NOT NULL
+ORDER BY index_name, seq_in_index`,
+			string(table.Table),
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var idxName string
+			var c string
+			if err := rows.Scan(&idxName, &c); err != nil {
+				return nil, errors.Wrap(err, "error decoding unique index column")
+			}
+			addCol(idxName, tree.Name(strings.ToLower(c)))
+		}
+		if rows.Err() != nil {
+			return nil, errors.Wrap(rows.Err(), "error collecting unique indexes")
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	case *dbconn.OracleConn:
+		// Unique indexes are not yet used for Oracle.
+		return nil, nil
+	default:
+		return nil, errors.AssertionFailedf("unhandled database connection: %T", conn)
+	}
+
+	ret := make([][]tree.Name, len(idxNames))
+	for i, idxName := range idxNames {
+		ret[i] = idxCols[idxName]
+	}
+	return ret, nil
+}
@@ -15,14 +15,39 @@ import (
 	"github.com/lib/pq/oid"
 )
 
+// RowKey is how rows are matched between the source of truth and the target.
+type RowKey int
+
+const (
+	// RowKeyPrimaryKey matches rows by their PRIMARY KEY.
+	RowKeyPrimaryKey RowKey = iota
+	// RowKeyUniqueIndex matches rows by the columns of a UNIQUE index on NOT
+	// NULL columns, which are used as the PrimaryKeyColumns.
+	RowKeyUniqueIndex
+	// RowKeyNone is used for tables without a primary key or usable unique
+	// index, whose rows must be compared as a whole.
+	RowKeyNone
+)
+
 type Result struct {
 	RowVerifiable bool
+	// RowKey is how rows are matched if the table is RowVerifiable.
+	RowKey RowKey
 	dbtable.VerifiedTable
 	MismatchingTableDefinitions []inconsistency.MismatchingTableDefinition
+	// ExcludedColumns are the columns removed by the ColumnFilter.
+	ExcludedColumns []tree.Name
 }
 
+// NoRowKeyInfo is the table definition mismatch reported for tables which
+// have no key to match rows on. Such tables are still verified.
+const NoRowKeyInfo = "missing a PRIMARY KEY or UNIQUE index on NOT NULL columns - rows will be compared by hashing whole rows"
+
 func VerifyCommonTables(
-	ctx context.Context, conns dbconn.OrderedConns, allTables [][2]dbtable.DBTable,
+	ctx context.Context,
+	conns dbconn.OrderedConns,
+	allTables [][2]dbtable.DBTable,
+	columnFilter ColumnFilter,
 ) ([]Result, error) {
 	var ret []Result
 
@@ -35,10 +60,27 @@ func VerifyCommonTables(
 		if err != nil {
 			return nil, err
 		}
+		rowKey := RowKeyPrimaryKey
+		if len(pkCols[0]) == 0 {
+			// Without a PRIMARY KEY, order rows by a unique index instead.
+			uniqueKeys, err := getUniqueKeysForTables(ctx, conns, cmpTables)
+			if err != nil {
+				return nil, err
+			}
+			if key := chooseUniqueKey(uniqueKeys, pkCols[1], columns); len(key) > 0 {
+				pkCols = [2][]tree.Name{key, key}
+				rowKey = RowKeyUniqueIndex
+			}
+		}
+		columns, excludedColumns := columnFilter.filterColumns(cmpTables[0].Name, pkCols[0], columns)
 		res, err := verifyTable(ctx, conns, cmpTables, pkCols, columns)
 		if err != nil {
 			return nil, err
 		}
+		if res.RowKey != RowKeyNone {
+			res.RowKey = rowKey
+		}
+		res.ExcludedColumns = excludedColumns
 		ret = append(ret, res)
 	}
 	return ret, nil
@@ -76,7 +118,7 @@ func verifyTable(
 			res.MismatchingTableDefinitions,
 			inconsistency.MismatchingTableDefinition{
 				DBTable: truthTbl,
-				Info:    "missing a PRIMARY KEY - results cannot be compared",
+				Info:    NoRowKeyInfo,
 			},
 		)
 	}
@@ -123,7 +165,7 @@ func verifyTable(
 		if err != nil {
 			return Result{}, err
 		}
-		if !comparableType(truthTyp, compareTyp) {
+		if !ComparableType(truthTyp, compareTyp) {
 			res.MismatchingTableDefinitions = append(
 				res.MismatchingTableDefinitions,
 				inconsistency.MismatchingTableDefinition{
@@ -227,9 +269,66 @@ func verifyTable(
 		}
 	}
 	res.RowVerifiable = pkSame && len(truthPKCols) > 0 && !collationMismatch
+	if len(truthPKCols) == 0 {
+		// Rows without a key are compared by hashing every column, which does
+		// not depend on the order of rows.
+		res.RowKey = RowKeyNone
+		res.RowVerifiable = len(res.Columns) > 0
+	}
 	return res, nil
 }
 
+// chooseUniqueKey returns the columns of the first unique index of the source
+// of truth on NOT NULL columns which has a matching unique index or primary
+// key on the target, or nil if there is none.
+func chooseUniqueKey(
+	uniqueKeys [2][][]tree.Name, targetPKCols []tree.Name, columns [2][]Column,
+) []tree.Name {
+	var columnMaps [2]map[tree.Name]Column
+	for i := range columns {
+		columnMaps[i] = mapColumns(columns[i])
+	}
+	targetKeys := append([][]tree.Name{targetPKCols}, uniqueKeys[1]...)
+	for _, key := range uniqueKeys[0] {
+		notNull := true
+		for _, col := range key {
+			for i := range columnMaps {
+				if c, ok := columnMaps[i][col]; !ok || !c.NotNull {
+					notNull = false
+				}
+			}
+		}
+		if !notNull {
+			continue
+		}
+		for _, targetKey := range targetKeys {
+			if sameColumnSet(key, targetKey) {
+				return key
+			}
+		}
+	}
+	return nil
+}
+
+func sameColumnSet(a []tree.Name, b []tree.Name) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, colA := range a {
+		found := false
+		for _, colB := range b {
+			if colA == colB {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // This logic isn't 100% there, but it's good enough.
 func comparableCollation(a, b sql.NullString) bool {
 	if a == b {
@@ -259,7 +358,8 @@ func isUnicodeCollation(a string) bool {
 	return false
 }
 
-func comparableType(a, b *pgtype.Type) bool {
+// ComparableType returns whether values of the given types can be compared.
+func ComparableType(a, b *pgtype.Type) bool {
 	if a.Name == b.Name {
 		return true
 	}
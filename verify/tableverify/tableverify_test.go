@@ -85,8 +85,10 @@ func TestVerifyTable(t *testing.T) {
 				MismatchingTableDefinitions: []inconsistency.MismatchingTableDefinition{
 					{
 						DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}, OID: 0x0},
-						Info:    "missing a PRIMARY KEY - results cannot be compared"},
+						Info:    "missing a PRIMARY KEY or UNIQUE index on NOT NULL columns - rows will be compared by hashing whole rows"},
 				},
+				RowVerifiable: true,
+				RowKey:        RowKeyNone,
 			},
 		},
 	} {
@@ -98,3 +100,132 @@ func TestVerifyTable(t *testing.T) {
 		})
 	}
 }
+
+func TestChooseUniqueKey(t *testing.T) {
+	columns := [2][]Column{
+		{
+			{Name: "a", OID: oid.T_int4, NotNull: true},
+			{Name: "b", OID: oid.T_int4, NotNull: true},
+			{Name: "c", OID: oid.T_int4},
+		},
+		{
+			{Name: "a", OID: oid.T_int4, NotNull: true},
+			{Name: "b", OID: oid.T_int4, NotNull: true},
+			{Name: "c", OID: oid.T_int4, NotNull: true},
+		},
+	}
+	for _, tc := range []struct {
+		desc         string
+		uniqueKeys   [2][][]tree.Name
+		targetPKCols []tree.Name
+		expected     []tree.Name
+	}{
+		{
+			desc:       "no unique keys",
+			uniqueKeys: [2][][]tree.Name{nil, {{"a"}}},
+		},
+		{
+			desc:       "matching unique key",
+			uniqueKeys: [2][][]tree.Name{{{"a", "b"}}, {{"b", "a"}}},
+			expected:   []tree.Name{"a", "b"},
+		},
+		{
+			desc:         "matching target primary key",
+			uniqueKeys:   [2][][]tree.Name{{{"b"}}, nil},
+			targetPKCols: []tree.Name{"b"},
+			expected:     []tree.Name{"b"},
+		},
+		{
+			desc:       "nullable column skipped",
+			uniqueKeys: [2][][]tree.Name{{{"c"}, {"a"}}, {{"a"}, {"c"}}},
+			expected:   []tree.Name{"a"},
+		},
+		{
+			desc:       "no matching key on target",
+			uniqueKeys: [2][][]tree.Name{{{"a"}}, {{"a", "b"}}},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, chooseUniqueKey(tc.uniqueKeys, tc.targetPKCols, columns))
+		})
+	}
+}
+
+func TestColumnFilter(t *testing.T) {
+	tbl := dbtable.Name{Schema: "public", Table: "tbl"}
+	columns := [2][]Column{
+		{
+			{Name: "id", OID: oid.T_int4},
+			{Name: "a", OID: oid.T_text},
+			{Name: "b", OID: oid.T_text},
+			{Name: "c", OID: oid.T_text},
+		},
+		{
+			{Name: "id", OID: oid.T_int4},
+			{Name: "a", OID: oid.T_text},
+			{Name: "b", OID: oid.T_varchar},
+			{Name: "d", OID: oid.T_text},
+		},
+	}
+	names := func(cols []Column) []tree.Name {
+		var ret []tree.Name
+		for _, col := range cols {
+			ret = append(ret, col.Name)
+		}
+		return ret
+	}
+	for _, tc := range []struct {
+		desc             string
+		exclude          []string
+		include          []string
+		expectedColumns  [2][]tree.Name
+		expectedExcluded []tree.Name
+		expectedError    string
+	}{
+		{
+			desc:            "no filter",
+			expectedColumns: [2][]tree.Name{{"id", "a", "b", "c"}, {"id", "a", "b", "d"}},
+		},
+		{
+			desc:             "exclude",
+			exclude:          []string{"public.tbl.b", "tbl.d", "other.tbl.a", "public.other.a"},
+			expectedColumns:  [2][]tree.Name{{"id", "a", "c"}, {"id", "a"}},
+			expectedExcluded: []tree.Name{"b", "d"},
+		},
+		{
+			desc:            "primary key cannot be excluded",
+			exclude:         []string{"tbl.id"},
+			expectedColumns: [2][]tree.Name{{"id", "a", "b", "c"}, {"id", "a", "b", "d"}},
+		},
+		{
+			desc:             "include",
+			include:          []string{"tbl.a", "other.b"},
+			expectedColumns:  [2][]tree.Name{{"id", "a"}, {"id", "a"}},
+			expectedExcluded: []tree.Name{"b", "c", "d"},
+		},
+		{
+			desc:             "include and exclude",
+			include:          []string{"tbl.a", "tbl.b"},
+			exclude:          []string{"tbl.b"},
+			expectedColumns:  [2][]tree.Name{{"id", "a"}, {"id", "a"}},
+			expectedExcluded: []tree.Name{"b", "c", "d"},
+		},
+		{
+			desc:          "bad column",
+			exclude:       []string{"b"},
+			expectedError: `expected column "b" to be in the form table.column or schema.table.column`,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			f, err := ParseColumnFilter(tc.exclude, tc.include)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			filtered, excluded := f.filterColumns(tbl, []tree.Name{"id"}, columns)
+			require.Equal(t, tc.expectedColumns, [2][]tree.Name{names(filtered[0]), names(filtered[1])})
+			require.Equal(t, tc.expectedExcluded, excluded)
+		})
+	}
+}
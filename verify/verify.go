@@ -4,14 +4,25 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/moltlogger"
 	"github.com/cockroachdb/molt/molttelemetry"
+	"github.com/cockroachdb/molt/verify/aggverify"
+	"github.com/cockroachdb/molt/verify/checkpoint"
+	"github.com/cockroachdb/molt/verify/comparator"
 	"github.com/cockroachdb/molt/verify/dbverify"
+	"github.com/cockroachdb/molt/verify/hashverify"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/cockroachdb/molt/verify/schemaverify"
+	"github.com/cockroachdb/molt/verify/summary"
 	"github.com/cockroachdb/molt/verify/tableverify"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -24,6 +35,14 @@ const DefaultConcurrency = 8
 const DefaultRowBatchSize = 1000
 const DefaultTableSplits = 8
 
+// checkpointInterval is the minimum amount of time between checkpoints of
+// each shard.
+const checkpointInterval = 10 * time.Second
+
+// asOfPollInterval is how often the replication slot is checked when waiting
+// for the replicator to apply the snapshot verified at.
+const asOfPollInterval = time.Second
+
 type VerifyOpt func(*verifyOpts)
 
 type verifyOpts struct {
@@ -36,6 +55,40 @@ type verifyOpts struct {
 	rows                     bool
 	dbFilter                 dbverify.FilterConfig
 	liveVerificationSettings *rowverify.LiveReverificationSettings
+	aggregateSettings        *aggverify.Settings
+	checkpointPath           string
+	resume                   bool
+	comparator               comparator.Comparator
+	columnFilter             tableverify.ColumnFilter
+	schemaObjects            bool
+	asOf                     *time.Time
+	asOfSlot                 string
+	asOfTimeout              time.Duration
+	summary                  *summary.Collector
+	additionalTargets        []dbconn.Conn
+	tableSettings            map[dbtable.Name]TableSettings
+}
+
+// TableSettings override the row batch size and number of table splits for
+// a table. Zero values are not overridden.
+type TableSettings struct {
+	RowBatchSize int
+	TableSplits  int
+}
+
+// forTable returns the options with the settings of the table applied.
+func (o verifyOpts) forTable(name dbtable.Name) verifyOpts {
+	s, ok := o.tableSettings[name]
+	if !ok {
+		return o
+	}
+	if s.RowBatchSize > 0 {
+		o.rowBatchSize = s.RowBatchSize
+	}
+	if s.TableSplits > 0 {
+		o.tableSplits = s.TableSplits
+	}
+	return o
 }
 
 func (o verifyOpts) rateLimit() rate.Limit {
@@ -70,6 +123,14 @@ func WithTableSplits(c int) VerifyOpt {
 	}
 }
 
+// WithTableSettings overrides the row batch size and number of table splits
+// of specific tables.
+func WithTableSettings(settings map[dbtable.Name]TableSettings) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.tableSettings = settings
+	}
+}
+
 func WithContinuous(c bool, pauseLength time.Duration) VerifyOpt {
 	return func(o *verifyOpts) {
 		o.continuous = c
@@ -85,12 +146,95 @@ func WithLive(live bool, settings rowverify.LiveReverificationSettings) VerifyOp
 	}
 }
 
+// WithAggregatesOnly compares row counts and column aggregates of each
+// shard instead of verifying every row.
+func WithAggregatesOnly(aggregatesOnly bool, settings aggverify.Settings) VerifyOpt {
+	return func(o *verifyOpts) {
+		if aggregatesOnly {
+			o.aggregateSettings = &settings
+		}
+	}
+}
+
+// WithCheckpoints persists the progress of row verification to the file at
+// the given path. If resume is set, verification continues from the
+// progress recorded in the file by an earlier run.
+func WithCheckpoints(path string, resume bool) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.checkpointPath = path
+		o.resume = resume
+	}
+}
+
+// WithComparator sets the comparator used to decide whether column values
+// match.
+func WithComparator(c comparator.Comparator) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.comparator = c
+	}
+}
+
+// WithColumnFilter sets which columns of each table are verified.
+func WithColumnFilter(filter tableverify.ColumnFilter) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.columnFilter = filter
+	}
+}
+
 func WithDBFilter(filter dbverify.FilterConfig) VerifyOpt {
 	return func(o *verifyOpts) {
 		o.dbFilter = filter
 	}
 }
 
+// WithSchemaObjects sets whether secondary indexes, constraints, column
+// defaults, computed columns, sequences, enums, views and routines are
+// verified.
+func WithSchemaObjects(b bool) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.schemaObjects = b
+	}
+}
+
+// WithAsOf verifies rows AS OF SYSTEM TIME asOf if set, which is only
+// supported if both sides are CockroachDB.
+func WithAsOf(asOf *time.Time) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.asOf = asOf
+	}
+}
+
+// WithAsOfReplicationSlot verifies rows at a single point in time if slot is
+// set, which is the replication slot a replicator from a PostgreSQL source to
+// a CockroachDB target reads. The source is read under a snapshot exported at
+// the start of verification and shared by every shard. The target is read AS
+// OF SYSTEM TIME once the slot has confirmed the snapshot, waiting at most
+// timeout.
+func WithAsOfReplicationSlot(slot string, timeout time.Duration) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.asOfSlot = slot
+		o.asOfTimeout = timeout
+	}
+}
+
+// WithAdditionalTargets verifies the given targets as well as the target of
+// the ordered connections. The source of truth is scanned once, with each row
+// compared against every target, and inconsistencies are labelled with the ID
+// of the target they are on.
+func WithAdditionalTargets(targets ...dbconn.Conn) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.additionalTargets = targets
+	}
+}
+
+// WithSummary collects per-table and per-shard results into the given
+// Collector.
+func WithSummary(c *summary.Collector) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.summary = c
+	}
+}
+
 func WithRows(b bool) VerifyOpt {
 	return func(o *verifyOpts) {
 		o.rows = b
@@ -120,41 +264,53 @@ func Verify(
 		tableSplits:  DefaultTableSplits,
 		rows:         true,
 		dbFilter:     dbverify.DefaultFilterConfig(),
+		comparator:   comparator.Exact,
 	}
 	for _, applyOpt := range inOpts {
 		applyOpt(&opts)
 	}
+	logger = logger.With().Str(moltlogger.PhaseKey, moltlogger.PhaseVerify).Logger()
+	if opts.summary != nil {
+		reporter = inconsistency.CombinedReporter{
+			Reporters: []inconsistency.Reporter{reporter, opts.summary},
+		}
+	}
 
 	if err := dbconn.RegisterTelemetry(conns); err != nil {
 		return err
 	}
 	reportTelemetry(logger, opts, conns)
 
-	dbTables, err := dbverify.Verify(ctx, conns)
-	if err != nil {
-		return errors.Wrap(err, "error comparing database tables")
-	}
-	if dbTables, err = dbverify.FilterResult(opts.dbFilter, dbTables); err != nil {
-		return err
+	if len(opts.additionalTargets) > 0 {
+		if opts.liveVerificationSettings != nil || opts.aggregateSettings != nil || opts.asOf != nil || opts.asOfSlot != "" || opts.schemaObjects {
+			return errors.Newf("multiple targets are not supported with live, aggregate, point-in-time or schema object verification")
+		}
 	}
 
-	for _, missingTable := range dbTables.MissingTables {
-		reporter.Report(missingTable)
-	}
-	for _, extraneousTable := range dbTables.ExtraneousTables {
-		reporter.Report(extraneousTable)
+	targetReporter := reporter
+	if len(opts.additionalTargets) > 0 {
+		targetReporter = inconsistency.TargetReporter{Reporter: reporter, Target: conns[1].ID()}
 	}
-
-	// Grab columns for each table on both sides.
-	tbls, err := tableverify.VerifyCommonTables(ctx, conns, dbTables.Verified)
+	dbTables, tbls, err := verifyTables(ctx, conns, targetReporter, opts)
 	if err != nil {
 		return err
 	}
+	additionalTargetTbls := make([][]tableverify.Result, len(opts.additionalTargets))
+	for i, target := range opts.additionalTargets {
+		if _, additionalTargetTbls[i], err = verifyTables(
+			ctx,
+			dbconn.OrderedConns{conns[0], target},
+			inconsistency.TargetReporter{Reporter: reporter, Target: target.ID()},
+			opts,
+		); err != nil {
+			return err
+		}
+	}
+	additionalTargets := additionalTargetTables(tbls, opts.additionalTargets, additionalTargetTbls, reporter, logger)
 
-	// Report mismatching table definitions.
-	for _, tbl := range tbls {
-		for _, d := range tbl.MismatchingTableDefinitions {
-			reporter.Report(d)
+	if opts.schemaObjects {
+		if err := verifySchemaObjects(ctx, conns, reporter, opts, dbTables.Verified, tbls); err != nil {
+			return err
 		}
 	}
 
@@ -163,18 +319,169 @@ func Verify(
 		return nil
 	}
 
-	shards := make([]rowverify.TableShard, 0, len(tbls))
+	var snapshots [2]*pgSnapshot
+	if opts.asOf != nil || opts.asOfSlot != "" {
+		if opts.continuous || opts.liveVerificationSettings != nil {
+			return errors.Newf("verifying as of a point in time is not supported with continuous or live verification")
+		}
+		for _, conn := range conns {
+			if _, ok := conn.(*dbconn.PGConn); !ok {
+				return errors.Newf("verifying as of a point in time is not supported for %s", conn.Dialect())
+			}
+		}
+		if !conns[1].IsCockroach() {
+			return errors.Newf("verifying as of a point in time is only supported with a CockroachDB target")
+		}
+		if conns[0].IsCockroach() {
+			if opts.asOf == nil || opts.asOfSlot != "" {
+				return errors.Newf("a time to verify at must be set, rather than a replication slot, if the source is CockroachDB")
+			}
+		} else {
+			// The snapshot of the source is taken now, so the target must be
+			// read once the replicator has applied it rather than at a time
+			// chosen by the user.
+			if opts.asOf != nil || opts.asOfSlot == "" {
+				return errors.Newf("a replication slot must be set, rather than a time to verify at, if the source is PostgreSQL")
+			}
+			if snapshots, err = exportPGSnapshots(ctx, conns); err != nil {
+				return err
+			}
+			defer func() { _ = snapshots[0].close(ctx) }()
+			reporter.Report(inconsistency.StatusReport{
+				Info: fmt.Sprintf(
+					"reading %s at exported snapshot %s (lsn: %s, timestamp: %s), waiting for replication slot %q to confirm it",
+					conns[0].ID(),
+					snapshots[0].id,
+					snapshots[0].lsn,
+					snapshots[0].timestamp.UTC().Format(time.RFC3339Nano),
+					opts.asOfSlot,
+				),
+			})
+			asOf, err := waitForReplication(
+				ctx,
+				conns[0].(*dbconn.PGConn),
+				conns[1].(*dbconn.PGConn),
+				snapshots[0],
+				opts.asOfSlot,
+				asOfPollInterval,
+				opts.asOfTimeout,
+			)
+			if err != nil {
+				return err
+			}
+			opts.asOf = &asOf
+		}
+		for _, conn := range conns {
+			if conn.IsCockroach() {
+				reporter.Report(inconsistency.StatusReport{
+					Info: fmt.Sprintf(
+						"reading %s as of system time %s",
+						conn.ID(),
+						opts.asOf.UTC().Format(time.RFC3339Nano),
+					),
+				})
+			}
+		}
+	}
+
+	var checkpointStore *checkpoint.Store
+	if opts.resume && opts.checkpointPath == "" {
+		return errors.Newf("a checkpoint file is required to resume")
+	}
+	if opts.checkpointPath != "" {
+		if opts.continuous || opts.liveVerificationSettings != nil || opts.aggregateSettings != nil {
+			if opts.resume {
+				return errors.Newf("resuming is not supported with continuous, live or aggregate verification")
+			}
+			logger.Debug().Msgf("checkpoints are disabled for continuous, live or aggregate verification")
+		} else if opts.resume {
+			if checkpointStore, err = checkpoint.LoadStore(opts.checkpointPath); err != nil {
+				return err
+			}
+		} else {
+			checkpointStore = checkpoint.NewStore(opts.checkpointPath)
+		}
+	}
+
+	// Tables are split at the point in time they are verified at.
+	splitConn := conns[0]
+	if opts.asOf != nil {
+		if splitConn, err = conns[0].Clone(ctx); err != nil {
+			return errors.Wrap(err, "error establishing connection to split tables")
+		}
+		defer func() { _ = splitConn.Close(ctx) }()
+		if err := readAtPointInTime(ctx, splitConn, snapshots[0], opts.asOf); err != nil {
+			return err
+		}
+	}
+	if err := checkAvailableConns(append(conns[:], opts.additionalTargets...)); err != nil {
+		return err
+	}
+	shards := make([]verifyShard, 0, len(tbls))
 	for _, tbl := range tbls {
 		if !tbl.RowVerifiable {
-			logger.Warn().Msgf("skipping unverifiable table %s.%s", tbl.Schema, tbl.Table)
+			logger.Warn().
+				Str(moltlogger.TableKey, tbl.SafeString()).
+				Msgf("skipping unverifiable table %s.%s", tbl.Schema, tbl.Table)
+			continue
+		}
+		if tbl.RowKey == tableverify.RowKeyNone {
+			// Tables without a key cannot be split or checkpointed, so they
+			// are always verified in a single shard.
+			shards = append(shards, verifyShard{TableShard: rowverify.TableShard{
+				VerifiedTable: tbl.VerifiedTable,
+				ShardNum:      1,
+				TotalShards:   1,
+			}})
 			continue
 		}
+		if opts.resume {
+			resumableShards, err := checkpointStore.ResumableShards(tbl.VerifiedTable)
+			if err != nil {
+				return err
+			}
+			if len(resumableShards) > 0 {
+				for _, rs := range resumableShards {
+					rs := rs
+					if rs.Progress.Completed {
+						reporter.Report(inconsistency.StatusReport{
+							Info: fmt.Sprintf(
+								"skipping completed row verification on %s.%s (shard %d/%d): %s",
+								rs.Shard.Schema,
+								rs.Shard.Table,
+								rs.Shard.ShardNum,
+								rs.Shard.TotalShards,
+								rs.Progress.Stats.String(),
+							),
+						})
+						if opts.summary != nil {
+							opts.summary.AddShard(rs.Shard.Name, summary.ShardSummary{
+								ShardNum:    rs.Shard.ShardNum,
+								TotalShards: rs.Shard.TotalShards,
+								RowStats:    rs.Progress.Stats,
+								Completed:   true,
+							})
+						}
+						continue
+					}
+					shards = append(shards, verifyShard{TableShard: rs.Shard, resume: &rs.Progress})
+				}
+				continue
+			}
+		}
 		// Get and first and last of each PK.
-		tableShards, err := shardTable(ctx, conns[0], tbl, reporter, opts.tableSplits)
+		tableShards, err := shardTable(ctx, splitConn, tbl, reporter, opts.forTable(tbl.Name).tableSplits)
 		if err != nil {
 			return errors.Wrapf(err, "error splitting tables")
 		}
-		shards = append(shards, tableShards...)
+		if checkpointStore != nil {
+			if err := checkpointStore.Register(tableShards); err != nil {
+				return err
+			}
+		}
+		for _, shard := range tableShards {
+			shards = append(shards, verifyShard{TableShard: shard})
+		}
 	}
 
 	numGoroutines := opts.concurrency
@@ -198,8 +505,10 @@ func Verify(
 	}
 
 	// Compare rows up to the numGoroutines specified.
+	var failed atomic.Bool
+	lastSuccess := newLastSuccessTracker()
 	g, _ := errgroup.WithContext(ctx)
-	workQueue := make(chan rowverify.TableShard)
+	workQueue := make(chan verifyShard)
 	for goroutineIdx := 0; goroutineIdx < numGoroutines; goroutineIdx++ {
 		g.Go(func() error {
 			verificationShards.Inc()
@@ -210,6 +519,10 @@ func Verify(
 				if !ok {
 					return nil
 				}
+				shardLogger := logger.With().
+					Str(moltlogger.TableKey, shard.SafeString()).
+					Str(moltlogger.ShardKey, fmt.Sprintf("%d/%d", shard.ShardNum, shard.TotalShards)).
+					Logger()
 				for runNum := 1; opts.continuous || runNum <= 1; runNum++ {
 					msg := fmt.Sprintf(
 						"starting verify on %s.%s, shard %d/%d",
@@ -246,23 +559,50 @@ func Verify(
 							msg += "<end>]"
 						}
 					}
+					if shard.resume != nil && len(shard.resume.LastPKVals) > 0 {
+						msg += ", resuming from checkpoint"
+					}
 					reporter.Report(inconsistency.StatusReport{
 						Info: msg,
 					})
-					if err := verifyRowShard(
+					var checkpointSettings *rowverify.CheckpointSettings
+					if checkpointStore != nil {
+						checkpointSettings = &rowverify.CheckpointSettings{
+							Checkpointer: checkpointStore,
+							Interval:     checkpointInterval,
+							Resume:       shard.resume,
+						}
+					}
+					start := time.Now()
+					shardOpts := opts.forTable(shard.Name)
+					stats, err := verifyRowShard(
 						ctx,
 						conns,
 						reporter,
-						logger,
-						opts.rowBatchSize,
+						shardLogger,
 						shard,
-						opts.liveVerificationSettings,
-						rate.NewLimiter(opts.rateLimit(), 1),
-					); err != nil {
-						logger.Err(err).
-							Str("schema", string(shard.Schema)).
-							Str("table", string(shard.Table)).
-							Msgf("error verifying rows")
+						shardOpts,
+						rate.NewLimiter(shardOpts.rateLimit(), 1),
+						checkpointSettings,
+						snapshots,
+					)
+					if opts.summary != nil {
+						shardSummary := summary.ShardSummary{
+							ShardNum:        shard.ShardNum,
+							TotalShards:     shard.TotalShards,
+							RowStats:        stats,
+							DurationSeconds: time.Since(start).Seconds(),
+						}
+						if err != nil {
+							shardSummary.Error = err.Error()
+						}
+						opts.summary.AddShard(shard.Name, shardSummary)
+					}
+					if err == nil {
+						lastSuccess.shardSucceeded(shard.Name, shard.ShardNum, shard.TotalShards, time.Now())
+					} else {
+						failed.Store(true)
+						shardLogger.Err(err).Msgf("error verifying rows")
 						reporter.Report(inconsistency.StatusReport{
 							Info: "failed to verify",
 						})
@@ -273,22 +613,244 @@ func Verify(
 		})
 	}
 	for _, shard := range shards {
+		shard.AsOf = opts.asOf
+		shard.additionalTargets = additionalTargets[shard.Name]
 		workQueue <- shard
 	}
 	close(workQueue)
-	return g.Wait()
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	if checkpointStore != nil && !failed.Load() {
+		// Every shard is verified, so there is nothing left to resume.
+		return checkpointStore.Remove()
+	}
+	return nil
+}
+
+// verifyTables compares the tables of the source of truth and target,
+// reporting missing, extraneous and mismatching tables.
+func verifyTables(
+	ctx context.Context, conns dbconn.OrderedConns, reporter inconsistency.Reporter, opts verifyOpts,
+) (dbverify.Result, []tableverify.Result, error) {
+	dbTables, err := dbverify.Verify(ctx, conns)
+	if err != nil {
+		return dbverify.Result{}, nil, errors.Wrap(err, "error comparing database tables")
+	}
+	if dbTables, err = dbverify.FilterResult(opts.dbFilter, dbTables); err != nil {
+		return dbverify.Result{}, nil, err
+	}
+
+	for _, missingTable := range dbTables.MissingTables {
+		reporter.Report(missingTable)
+	}
+	for _, extraneousTable := range dbTables.ExtraneousTables {
+		reporter.Report(extraneousTable)
+	}
+
+	// Grab columns for each table on both sides.
+	tbls, err := tableverify.VerifyCommonTables(ctx, conns, dbTables.Verified, opts.columnFilter)
+	if err != nil {
+		return dbverify.Result{}, nil, err
+	}
+
+	// Report mismatching table definitions.
+	for _, tbl := range tbls {
+		if opts.summary != nil {
+			opts.summary.AddTable(tbl.Name)
+		}
+		if len(tbl.ExcludedColumns) > 0 {
+			excluded := make([]string, len(tbl.ExcludedColumns))
+			for i, col := range tbl.ExcludedColumns {
+				excluded[i] = string(col)
+			}
+			reporter.Report(inconsistency.StatusReport{
+				Info: fmt.Sprintf(
+					"excluding columns from verification on %s.%s: %s",
+					tbl.Schema,
+					tbl.Table,
+					strings.Join(excluded, ", "),
+				),
+			})
+		}
+		for _, d := range tbl.MismatchingTableDefinitions {
+			reporter.Report(d)
+		}
+		if tbl.RowVerifiable && tbl.RowKey == tableverify.RowKeyUniqueIndex {
+			pkCols := make([]string, len(tbl.PrimaryKeyColumns))
+			for i, col := range tbl.PrimaryKeyColumns {
+				pkCols[i] = string(col)
+			}
+			reporter.Report(inconsistency.StatusReport{
+				Info: fmt.Sprintf(
+					"%s.%s has no PRIMARY KEY; matching rows using unique columns (%s)",
+					tbl.Schema,
+					tbl.Table,
+					strings.Join(pkCols, ", "),
+				),
+			})
+		}
+	}
+	return dbTables, tbls, nil
+}
+
+// additionalTargetTables returns the additional targets each table of the
+// first target can be row verified on the same way, with the column OIDs of
+// the table on each of them. A table is still verified against the first
+// target and the other additional targets if it differs on one of them,
+// which is reported as an inconsistency on that target.
+func additionalTargetTables(
+	tbls []tableverify.Result,
+	additionalTargets []dbconn.Conn,
+	additionalTargetTbls [][]tableverify.Result,
+	reporter inconsistency.Reporter,
+	logger zerolog.Logger,
+) map[dbtable.Name][]rowverify.Target {
+	if len(additionalTargets) == 0 {
+		return nil
+	}
+	ret := make(map[dbtable.Name][]rowverify.Target, len(tbls))
+	for _, tbl := range tbls {
+		if !tbl.RowVerifiable {
+			continue
+		}
+		for i, target := range additionalTargets {
+			var targetTbl *tableverify.Result
+			for j := range additionalTargetTbls[i] {
+				if additionalTargetTbls[i][j].Name == tbl.Name {
+					targetTbl = &additionalTargetTbls[i][j]
+					break
+				}
+			}
+			switch {
+			case targetTbl == nil || !targetTbl.RowVerifiable:
+				// Verifying the tables of the target has already reported
+				// why the table cannot be verified on it.
+				logger.Warn().
+					Str("table_schema", string(tbl.Schema)).
+					Str("table_name", string(tbl.Table)).
+					Str("target", string(target.ID())).
+					Msgf("skipping row verification of table on target")
+			case targetTbl.RowKey != tbl.RowKey ||
+				!namesEqual(targetTbl.Columns, tbl.Columns) ||
+				!namesEqual(targetTbl.PrimaryKeyColumns, tbl.PrimaryKeyColumns):
+				reporter.Report(inconsistency.MismatchingTableDefinition{
+					DBTable: dbtable.DBTable{Name: tbl.Name},
+					Info:    "table cannot be row verified the same way as on the first target, so its rows are not verified on this target",
+					Target:  target.ID(),
+				})
+			default:
+				ret[tbl.Name] = append(ret[tbl.Name], rowverify.Target{
+					Conn:       target,
+					ColumnOIDs: targetTbl.ColumnOIDs[1],
+				})
+			}
+		}
+	}
+	return ret
+}
+
+func namesEqual(a []tree.Name, b []tree.Name) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// verifySchemaObjects reports differences in the schema objects of the given
+// tables and in sequences, enums, views and routines. tbls is the result of verifying each table in
+// dbTables.
+func verifySchemaObjects(
+	ctx context.Context,
+	conns dbconn.OrderedConns,
+	reporter inconsistency.Reporter,
+	opts verifyOpts,
+	dbTables [][2]dbtable.DBTable,
+	tbls []tableverify.Result,
+) error {
+	schemaTables := make([]schemaverify.Table, len(dbTables))
+	for i := range dbTables {
+		schemaTables[i] = schemaverify.Table{
+			DBTables:        dbTables[i],
+			ExcludedColumns: tbls[i].ExcludedColumns,
+		}
+	}
+	objs, err := schemaverify.VerifyTables(ctx, conns, schemaTables)
+	if err != nil {
+		return errors.Wrap(err, "error verifying schema objects")
+	}
+	matches, err := dbverify.NameMatcher(opts.dbFilter)
+	if err != nil {
+		return err
+	}
+	for _, v := range []struct {
+		kind   string
+		verify func(context.Context, dbconn.OrderedConns, func(dbtable.Name) bool) ([]inconsistency.ReportableObject, error)
+	}{
+		{kind: "sequences", verify: schemaverify.VerifySequences},
+		{kind: "enums", verify: schemaverify.VerifyEnums},
+		{kind: "views", verify: schemaverify.VerifyViews},
+		{kind: "routines", verify: schemaverify.VerifyRoutines},
+	} {
+		vObjs, err := v.verify(ctx, conns, matches)
+		if err != nil {
+			return errors.Wrapf(err, "error verifying %s", v.kind)
+		}
+		objs = append(objs, vObjs...)
+	}
+	for _, obj := range objs {
+		reporter.Report(obj)
+	}
+	return nil
 }
 
+// checkAvailableConns checks a connection can be cloned to each database to
+// verify shards with, once the connections kept open for the whole run have
+// been opened. Each shard holds one connection to each database at a time,
+// which are acquired in order, so shards only wait for other shards to
+// finish rather than for each other.
+func checkAvailableConns(conns []dbconn.Conn) error {
+	for _, conn := range conns {
+		if n, ok := dbconn.AvailableConns(conn); ok && n < 1 {
+			return errors.Newf(
+				"no connections to %s are left to verify shards with, as all are kept open for the whole run (e.g. by --results, --fixup or --as-of); increase the maximum number of connections to %s",
+				conn.ID(),
+				conn.ID(),
+			)
+		}
+	}
+	return nil
+}
+
+// verifyShard is a shard queued for verification.
+type verifyShard struct {
+	rowverify.TableShard
+	// resume is the progress made on the shard by an earlier run, if any.
+	resume *rowverify.ShardProgress
+	// additionalTargets are the additional targets the table is verified on,
+	// with the column OIDs of the table on each.
+	additionalTargets []rowverify.Target
+}
+
+// verifyRowShard verifies the rows of a shard, returning its statistics.
+// Aggregate verification does not compare rows, so returns no statistics.
 func verifyRowShard(
 	ctx context.Context,
 	conns dbconn.OrderedConns,
 	reporter inconsistency.Reporter,
 	logger zerolog.Logger,
-	rowBatchSize int,
-	tbl rowverify.TableShard,
-	liveVerifySettings *rowverify.LiveReverificationSettings,
+	shard verifyShard,
+	opts verifyOpts,
 	rateLimiter *rate.Limiter,
-) error {
+	checkpointSettings *rowverify.CheckpointSettings,
+	snapshots [2]*pgSnapshot,
+) (rowverify.RowStats, error) {
+	tbl := shard.TableShard
 	// Copy connections over naming wise, but initialize a new connection
 	// for each table.
 	var workerConns dbconn.OrderedConns
@@ -298,22 +860,117 @@ func verifyRowShard(
 		var err error
 		workerConns[i], err = conns[i].Clone(ctx)
 		if err != nil {
-			return errors.Wrap(err, "error establishing connection to compare")
+			return rowverify.RowStats{}, errors.Wrap(err, "error establishing connection to compare")
 		}
 		defer func() {
 			_ = workerConns[i].Close(ctx)
 		}()
+		if snapshots[i] != nil {
+			if err := snapshots[i].importInto(ctx, workerConns[i]); err != nil {
+				return rowverify.RowStats{}, err
+			}
+		}
+	}
+	additionalTargets := make([]rowverify.Target, len(shard.additionalTargets))
+	for i, target := range shard.additionalTargets {
+		conn, err := target.Conn.Clone(ctx)
+		if err != nil {
+			return rowverify.RowStats{}, errors.Wrap(err, "error establishing connection to compare")
+		}
+		defer func() {
+			_ = conn.Close(ctx)
+		}()
+		additionalTargets[i] = rowverify.Target{Conn: conn, ColumnOIDs: target.ColumnOIDs}
+	}
+	// Inconsistencies are labelled with their target when verifying against
+	// several, even if the table is only verified on the first.
+	reporter = labelTarget(reporter, conns[1], len(opts.additionalTargets) > 0 && len(additionalTargets) == 0)
+	if opts.aggregateSettings != nil {
+		return rowverify.RowStats{}, aggverify.VerifyAggregatesOnShard(
+			ctx,
+			workerConns,
+			tbl,
+			*opts.aggregateSettings,
+			reporter,
+		)
+	}
+	if len(tbl.PrimaryKeyColumns) == 0 {
+		return verifyRowHashesOnTargets(ctx, workerConns, additionalTargets, tbl, opts, reporter, rateLimiter)
 	}
 	return rowverify.VerifyRowsOnShard(
 		ctx,
 		workerConns,
+		additionalTargets,
 		tbl,
-		rowBatchSize,
+		opts.rowBatchSize,
 		reporter,
 		logger,
-		liveVerifySettings,
+		opts.liveVerificationSettings,
+		rateLimiter,
+		checkpointSettings,
+		opts.comparator,
+	)
+}
+
+// verifyRowHashesOnTargets verifies the row hashes of a table without a key
+// against each target in turn, as unordered rows cannot be compared against
+// several targets in a single scan.
+func verifyRowHashesOnTargets(
+	ctx context.Context,
+	conns dbconn.OrderedConns,
+	additionalTargets []rowverify.Target,
+	tbl rowverify.TableShard,
+	opts verifyOpts,
+	reporter inconsistency.Reporter,
+	rateLimiter *rate.Limiter,
+) (rowverify.RowStats, error) {
+	stats, err := hashverify.VerifyRowHashesOnShard(
+		ctx,
+		conns,
+		tbl,
+		opts.rowBatchSize,
+		labelTarget(reporter, conns[1], len(additionalTargets) > 0),
 		rateLimiter,
+		opts.comparator,
 	)
+	if err != nil || len(additionalTargets) == 0 {
+		return stats.RowStats(), err
+	}
+	ret := stats.RowStats()
+	for _, target := range additionalTargets {
+		targetTbl := tbl
+		targetTbl.ColumnOIDs[1] = target.ColumnOIDs
+		targetStats, err := hashverify.VerifyRowHashesOnShard(
+			ctx,
+			dbconn.OrderedConns{conns[0], target.Conn},
+			targetTbl,
+			opts.rowBatchSize,
+			labelTarget(reporter, target.Conn, true),
+			rateLimiter,
+			opts.comparator,
+		)
+		if err != nil {
+			return ret, err
+		}
+		// Each scan verifies the same rows of the source of truth, so only
+		// count rows as successful if they are on every target.
+		targetRowStats := targetStats.RowStats()
+		if targetRowStats.NumSuccess < ret.NumSuccess {
+			ret.NumSuccess = targetRowStats.NumSuccess
+		}
+		ret.NumMissing += targetRowStats.NumMissing
+		ret.NumExtraneous += targetRowStats.NumExtraneous
+	}
+	return ret, nil
+}
+
+func labelTarget(
+	reporter inconsistency.Reporter, target dbconn.Conn, label bool,
+) inconsistency.Reporter {
+	if !label {
+		return reporter
+	}
+	return inconsistency.TargetReporter{Reporter: reporter, Target: target.ID()}
 }
 
 func reportTelemetry(logger zerolog.Logger, opts verifyOpts, conns dbconn.OrderedConns) {
@@ -331,5 +988,20 @@ func reportTelemetry(logger zerolog.Logger, opts verifyOpts, conns dbconn.Ordere
 	if opts.liveVerificationSettings != nil {
 		features = append(features, "molt_verify_live")
 	}
+	if opts.aggregateSettings != nil {
+		features = append(features, "molt_verify_aggregates")
+	}
+	if opts.resume {
+		features = append(features, "molt_verify_resume")
+	}
+	if opts.comparator != comparator.Exact {
+		features = append(features, "molt_verify_comparator")
+	}
+	if opts.schemaObjects {
+		features = append(features, "molt_verify_schema_objects")
+	}
+	if opts.asOf != nil || opts.asOfSlot != "" {
+		features = append(features, "molt_verify_as_of")
+	}
 	molttelemetry.ReportTelemetryAsync(logger, features...)
 }
@@ -10,7 +10,9 @@ import (
 
 	"github.com/cockroachdb/datadriven"
 	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/testutils"
+	"github.com/cockroachdb/molt/verify/aggverify"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
@@ -58,6 +60,26 @@ func TestVerifyOpts_rateLimit(t *testing.T) {
 	}
 }
 
+func TestVerifyOpts_forTable(t *testing.T) {
+	orders := dbtable.Name{Schema: "public", Table: "orders"}
+	users := dbtable.Name{Schema: "public", Table: "users"}
+	opts := verifyOpts{
+		rowBatchSize: 20000,
+		tableSplits:  1,
+		tableSettings: map[dbtable.Name]TableSettings{
+			orders: {RowBatchSize: 1000, TableSplits: 8},
+			users:  {TableSplits: 4},
+		},
+	}
+	require.Equal(t, 1000, opts.forTable(orders).rowBatchSize)
+	require.Equal(t, 8, opts.forTable(orders).tableSplits)
+	require.Equal(t, 20000, opts.forTable(users).rowBatchSize)
+	require.Equal(t, 4, opts.forTable(users).tableSplits)
+	other := opts.forTable(dbtable.Name{Schema: "public", Table: "other"})
+	require.Equal(t, 20000, other.rowBatchSize)
+	require.Equal(t, 1, other.tableSplits)
+}
+
 func TestDataDrivenPG(t *testing.T) {
 	datadriven.Walk(
 		t,
@@ -109,12 +131,18 @@ func testDataDriven(t *testing.T, path string, connArgs []connArg) {
 			return testutils.QueryConnCommand(t, d, conns)
 		case "verify":
 			numSplits := 1
+			aggregatesOnly := false
+			schemaObjects := false
 			for _, arg := range d.CmdArgs {
 				switch arg.Key {
 				case "splits":
 					var err error
 					numSplits, err = strconv.Atoi(arg.Vals[0])
 					require.NoError(t, err)
+				case "aggregates":
+					aggregatesOnly = true
+				case "schema_objects":
+					schemaObjects = true
 				}
 			}
 			reporter := &inconsistency.LogReporter{
@@ -129,6 +157,8 @@ func testDataDriven(t *testing.T, path string, connArgs []connArg) {
 				WithConcurrency(1),
 				WithRowBatchSize(2),
 				WithTableSplits(numSplits),
+				WithAggregatesOnly(aggregatesOnly, aggverify.Settings{ColumnAggregates: true}),
+				WithSchemaObjects(schemaObjects),
 			)
 			if err != nil {
 				sb.WriteString(fmt.Sprintf("error: %s\n", err.Error()))